@@ -11,6 +11,8 @@ include $(TOP)/config.mk
 DOCKER := DOCKER_BUILDKIT=$(DOCKER_BUILDKIT) $(DOCKER_CMD) $(DOCKERFLAGS)
 ifeq (${SGX_MODE}, HW)
 	GOTAGS = -tags sgx_hw_mode
+else
+	GOTAGS = -tags sgx_sim_mode
 endif
 GO := $(GO_CMD) $(GOFLAGS)
 
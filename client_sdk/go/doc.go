@@ -16,9 +16,24 @@ SPDX-License-Identifier: Apache-2.0
 // Reference: https://godoc.org/github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/client/resmgmt
 //
 // pkg/gateway: Enables Go developers to build client applications using the Hyperledger
-// Fabric programming model.
+// Fabric programming model. FPC contracts are created from a Fabric Go SDK network.
 // Reference: https://godoc.org/github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway
 //
+// pkg/gateway/fabricgateway: Creates FPC contracts from a Fabric Gateway client
+// (https://pkg.go.dev/github.com/hyperledger/fabric-gateway/pkg/client) network, as available with Fabric v2.4 and
+// later. This package is a separate Go module which requires Go 1.16 or later.
+// Reference: https://godoc.org/github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/fabricgateway
+//
+// pkg/offline: Enables FPC transactions whose proposals and transactions are signed outside of the SDK, e.g., by a HSM.
+// Reference: https://godoc.org/github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/offline
+//
+// pkg/crypto: Provides the crypto service providers used by the SDK, including ones which keep the ECDSA signing keys
+// in a BCCSP or a PKCS#11 token (HSM), and the encoding (PEM, DER, JWK) and fingerprinting of enclave keys.
+// Reference: https://godoc.org/github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/crypto
+//
+// pkg/crypto/csptest: Provides the conformance test suite for custom crypto service providers.
+// Reference: https://godoc.org/github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/crypto/csptest
+//
 // Usage samples
 //
 // samples/main.go: Illustrates the use of the FPC Client SDK. The application can be used with our test-network.
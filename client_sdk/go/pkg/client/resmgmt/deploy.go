@@ -0,0 +1,236 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/fab/ccpackager"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	lifecyclepkg "github.com/hyperledger/fabric-sdk-go/pkg/fab/ccpackager/lifecycle"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultEndorsementPlugin = "escc"
+	defaultValidationPlugin  = "vscc"
+)
+
+// DeployStep identifies a step of the FPC chaincode deployment performed by DeployFPCChaincode
+type DeployStep string
+
+const (
+	DeployStepPackage     DeployStep = "package"
+	DeployStepInstall     DeployStep = "install"
+	DeployStepApprove     DeployStep = "approve"
+	DeployStepCommit      DeployStep = "commit"
+	DeployStepInitEnclave DeployStep = "initEnclave"
+)
+
+// DeployProgressFunc is called by DeployFPCChaincode whenever a deployment step has been completed for a target.
+// The target is the chaincode label for DeployStepPackage, the (comma-separated) peers of an organization for
+// DeployStepInstall and DeployStepApprove, the channel for DeployStepCommit, and the enclave peer for
+// DeployStepInitEnclave.
+type DeployProgressFunc func(step DeployStep, target string)
+
+// OrgDeployment contains the resource management client of an admin of an organization and the peers of this
+// organization where a FPC chaincode is installed.
+type OrgDeployment struct {
+	Client *Client
+	Peers  []string
+}
+
+// DeployFPCChaincodeRequest contains the parameters to deploy a FPC chaincode.
+// The chaincode version is always set to the mrenclave of the chaincode, as required by FPC.
+type DeployFPCChaincodeRequest struct {
+	ChaincodeID string
+	// Path defines the location of FPC enclave artifacts, see ccpackager.Descriptor
+	Path string
+	// Label of the chaincode package; if empty, ChaincodeID is used
+	Label string
+	// SGXMode defines SGX runtime mode. Supported types are SIM and HW.
+	SGXMode         string
+	Sequence        int64
+	SignaturePolicy *common.SignaturePolicyEnvelope
+
+	// Peers of the organization of the client where the chaincode is installed and approved
+	Peers []string
+	// OtherOrgs are the organizations, besides the one of the client, that install and approve the chaincode
+	OtherOrgs []OrgDeployment
+
+	// EnclavePeers are the peers where an enclave is initialized after the chaincode definition is committed
+	EnclavePeers      []string
+	AttestationParams *sgx.AttestationParams
+
+	// Progress is an optional callback which is called after each completed step
+	Progress DeployProgressFunc
+}
+
+// DeployFPCChaincodeResponse contains the results of a FPC chaincode deployment
+type DeployFPCChaincodeResponse struct {
+	PackageID string
+	// Mrenclave is the mrenclave of the chaincode, which is used as chaincode version
+	Mrenclave        string
+	CommitTxID       fab.TransactionID
+	InitEnclaveTxIDs []fab.TransactionID
+}
+
+// DeployFPCChaincode packages a FPC chaincode and installs it on the peers of all given organizations, approves the
+// chaincode definition for each organization with the mrenclave as version, commits the chaincode definition, and
+// finally initializes and registers an enclave at each of the enclave peers.
+//
+// The options are passed to all lifecycle operations, e.g., resmgmt.WithRetry or resmgmt.WithOrdererEndpoint.
+// The target peers of each step are set according to the request. If the initialization of an enclave fails,
+// the response of the completed steps is returned along with the error.
+func (rc *Client) DeployFPCChaincode(channelId string, req DeployFPCChaincodeRequest, options ...resmgmt.RequestOption) (*DeployFPCChaincodeResponse, error) {
+	err := verifyDeployRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := req.Progress
+	if progress == nil {
+		progress = func(DeployStep, string) {}
+	}
+
+	label := req.Label
+	if label == "" {
+		label = req.ChaincodeID
+	}
+
+	mrenclave, err := ccpackager.ReadMrenclave(req.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read mrenclave")
+	}
+
+	// package
+	ccPkg, err := ccpackager.NewCCPackage(&ccpackager.Descriptor{
+		Path:    req.Path,
+		Type:    ccpackager.ChaincodeType,
+		Label:   label,
+		SGXMode: req.SGXMode,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create chaincode package")
+	}
+	packageID := lifecyclepkg.ComputePackageID(label, ccPkg)
+	logger.Debugf("%s packaged with package id %s", req.ChaincodeID, packageID)
+	progress(DeployStepPackage, label)
+
+	orgs := append([]OrgDeployment{{Client: rc, Peers: req.Peers}}, req.OtherOrgs...)
+	var allPeers []string
+
+	// install and approve for each org
+	for _, org := range orgs {
+		peers := strings.Join(org.Peers, ",")
+		orgOptions := append(append([]resmgmt.RequestOption{}, options...), resmgmt.WithTargetEndpoints(org.Peers...))
+
+		_, err := org.Client.lifecycle.LifecycleInstallCC(resmgmt.LifecycleInstallCCRequest{
+			Label:   label,
+			Package: ccPkg,
+		}, orgOptions...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to install chaincode at %s", peers)
+		}
+		logger.Debugf("%s installed at %s", req.ChaincodeID, peers)
+		progress(DeployStepInstall, peers)
+
+		txID, err := org.Client.lifecycle.LifecycleApproveCC(channelId, resmgmt.LifecycleApproveCCRequest{
+			Name:              req.ChaincodeID,
+			Version:           mrenclave,
+			PackageID:         packageID,
+			Sequence:          req.Sequence,
+			EndorsementPlugin: defaultEndorsementPlugin,
+			ValidationPlugin:  defaultValidationPlugin,
+			SignaturePolicy:   req.SignaturePolicy,
+		}, orgOptions...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to approve chaincode at %s", peers)
+		}
+		logger.Debugf("%s approved at %s with txid %s", req.ChaincodeID, peers, txID)
+		progress(DeployStepApprove, peers)
+
+		allPeers = append(allPeers, org.Peers...)
+	}
+
+	// commit
+	commitTxID, err := rc.lifecycle.LifecycleCommitCC(channelId, resmgmt.LifecycleCommitCCRequest{
+		Name:              req.ChaincodeID,
+		Version:           mrenclave,
+		Sequence:          req.Sequence,
+		EndorsementPlugin: defaultEndorsementPlugin,
+		ValidationPlugin:  defaultValidationPlugin,
+		SignaturePolicy:   req.SignaturePolicy,
+	}, append(append([]resmgmt.RequestOption{}, options...), resmgmt.WithTargetEndpoints(allPeers...))...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to commit chaincode")
+	}
+	logger.Debugf("%s committed with txid %s", req.ChaincodeID, commitTxID)
+	progress(DeployStepCommit, channelId)
+
+	resp := &DeployFPCChaincodeResponse{
+		PackageID:  packageID,
+		Mrenclave:  mrenclave,
+		CommitTxID: commitTxID,
+	}
+
+	// init enclaves
+	for _, peer := range req.EnclavePeers {
+		txID, err := rc.LifecycleInitEnclave(channelId, LifecycleInitEnclaveRequest{
+			ChaincodeID:         req.ChaincodeID,
+			EnclavePeerEndpoint: peer,
+			AttestationParams:   req.AttestationParams,
+		}, options...)
+		if err != nil {
+			return resp, errors.Wrapf(err, "failed to init enclave at %s", peer)
+		}
+		logger.Debugf("%s enclave initialized at %s with txid %s", req.ChaincodeID, peer, txID)
+		progress(DeployStepInitEnclave, peer)
+		resp.InitEnclaveTxIDs = append(resp.InitEnclaveTxIDs, txID)
+	}
+
+	return resp, nil
+}
+
+func verifyDeployRequest(req DeployFPCChaincodeRequest) error {
+	if req.ChaincodeID == "" {
+		return errors.New("chaincodeId is required")
+	}
+
+	if req.Path == "" {
+		return errors.New("chaincode path is required")
+	}
+
+	if req.Sequence <= 0 {
+		return errors.New("sequence must be greater than 0")
+	}
+
+	if len(req.Peers) == 0 {
+		return errors.New("peers are required")
+	}
+
+	for _, org := range req.OtherOrgs {
+		if org.Client == nil || len(org.Peers) == 0 {
+			return errors.New("client and peers are required for each organization")
+		}
+	}
+
+	if len(req.EnclavePeers) > 0 && req.AttestationParams == nil {
+		return errors.New("attestation params are required to init enclaves")
+	}
+
+	if req.AttestationParams != nil {
+		if err := req.AttestationParams.Validate(); err != nil {
+			return errors.Wrap(err, "attestation params are invalid")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/client/resmgmt/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/stretchr/testify/assert"
+)
+
+const mrenclave = "98aed61c91f258a37f67b7a3e5b9b0e2a27b3c8e5c8a6f5f4e1f1c3b0c9d7a11"
+
+// lifecycleStub records the lifecycle operations of an organization
+type lifecycleStub struct {
+	installs  []resmgmt.LifecycleInstallCCRequest
+	approvals []resmgmt.LifecycleApproveCCRequest
+	commits   []resmgmt.LifecycleCommitCCRequest
+	commitErr error
+
+	definitions []resmgmt.LifecycleChaincodeDefinition
+}
+
+func (l *lifecycleStub) LifecycleInstallCC(req resmgmt.LifecycleInstallCCRequest, options ...resmgmt.RequestOption) ([]resmgmt.LifecycleInstallCCResponse, error) {
+	l.installs = append(l.installs, req)
+	return nil, nil
+}
+
+func (l *lifecycleStub) LifecycleApproveCC(channelID string, req resmgmt.LifecycleApproveCCRequest, options ...resmgmt.RequestOption) (fab.TransactionID, error) {
+	l.approvals = append(l.approvals, req)
+	return "approveTxID", nil
+}
+
+func (l *lifecycleStub) LifecycleCommitCC(channelID string, req resmgmt.LifecycleCommitCCRequest, options ...resmgmt.RequestOption) (fab.TransactionID, error) {
+	l.commits = append(l.commits, req)
+	return "commitTxID", l.commitErr
+}
+
+func (l *lifecycleStub) LifecycleQueryCommittedCC(channelID string, req resmgmt.LifecycleQueryCommittedCCRequest, options ...resmgmt.RequestOption) ([]resmgmt.LifecycleChaincodeDefinition, error) {
+	return l.definitions, nil
+}
+
+func createChaincodeArtifacts(t *testing.T) string {
+	ccPath, err := ioutil.TempDir("", "fpc-deploy-test")
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(ccPath, "mrenclave"), []byte(mrenclave+"\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(ccPath, "enclave.signed.so"), []byte("some enclave"), 0644))
+	return ccPath
+}
+
+func TestDeployFPCChaincodeInvalidRequest(t *testing.T) {
+	client := setupClient(&fakes.ChannelClient{}, &fakes.CredentialConverter{})
+
+	for _, req := range []DeployFPCChaincodeRequest{
+		{},
+		{ChaincodeID: chaincodeId},
+		{ChaincodeID: chaincodeId, Path: "/some/path"},
+		{ChaincodeID: chaincodeId, Path: "/some/path", Sequence: 1},
+		{ChaincodeID: chaincodeId, Path: "/some/path", Sequence: 1, Peers: []string{"peer0"}, OtherOrgs: []OrgDeployment{{}}},
+		{ChaincodeID: chaincodeId, Path: "/some/path", Sequence: 1, Peers: []string{"peer0"}, EnclavePeers: []string{"peer0"}},
+		{ChaincodeID: chaincodeId, Path: "/some/path", Sequence: 1, Peers: []string{"peer0"}, EnclavePeers: []string{"peer0"}, AttestationParams: &sgx.AttestationParams{}},
+	} {
+		resp, err := client.DeployFPCChaincode(channelID, req)
+		assert.Nil(t, resp)
+		assert.Error(t, err)
+	}
+
+	// no chaincode artifacts
+	resp, err := client.DeployFPCChaincode(channelID, DeployFPCChaincodeRequest{ChaincodeID: chaincodeId, Path: "/some/path", Sequence: 1, Peers: []string{"peer0"}})
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+}
+
+func TestDeployFPCChaincode(t *testing.T) {
+	ccPath := createChaincodeArtifacts(t)
+	defer os.RemoveAll(ccPath)
+
+	fakeChannelClient := &fakes.ChannelClient{}
+	fakeChannelClient.QueryReturns(channel.Response{}, nil)
+	fakeChannelClient.ExecuteReturns(channel.Response{TransactionID: expectedTxID}, nil)
+
+	org1 := &lifecycleStub{}
+	client := setupClient(fakeChannelClient, &fakes.CredentialConverter{})
+	client.lifecycle = org1
+
+	org2 := &lifecycleStub{}
+	otherClient := &Client{lifecycle: org2}
+
+	var steps []string
+	req := DeployFPCChaincodeRequest{
+		ChaincodeID:  chaincodeId,
+		Path:         ccPath,
+		SGXMode:      sgx.SGXModeSimType,
+		Sequence:     1,
+		Peers:        []string{"peer0.org1"},
+		OtherOrgs:    []OrgDeployment{{Client: otherClient, Peers: []string{"peer0.org2", "peer1.org2"}}},
+		EnclavePeers: []string{"peer0.org1", "peer0.org2"},
+		AttestationParams: &sgx.AttestationParams{
+			AttestationType: attestationType,
+		},
+		Progress: func(step DeployStep, target string) {
+			steps = append(steps, fmt.Sprintf("%s:%s", step, target))
+		},
+	}
+
+	resp, err := client.DeployFPCChaincode(channelID, req)
+	assert.NoError(t, err)
+	assert.Equal(t, mrenclave, resp.Mrenclave)
+	assert.Equal(t, fab.TransactionID("commitTxID"), resp.CommitTxID)
+	assert.Equal(t, []fab.TransactionID{expectedTxID, expectedTxID}, resp.InitEnclaveTxIDs)
+
+	// each org installs and approves with mrenclave as version
+	for _, org := range []*lifecycleStub{org1, org2} {
+		assert.Len(t, org.installs, 1)
+		assert.Equal(t, chaincodeId, org.installs[0].Label)
+		assert.Len(t, org.approvals, 1)
+		assert.Equal(t, mrenclave, org.approvals[0].Version)
+		assert.Equal(t, resp.PackageID, org.approvals[0].PackageID)
+	}
+
+	// only the client commits
+	assert.Len(t, org1.commits, 1)
+	assert.Len(t, org2.commits, 0)
+	assert.Equal(t, mrenclave, org1.commits[0].Version)
+
+	// two enclaves are initialized
+	assert.Equal(t, 2, fakeChannelClient.QueryCallCount())
+	assert.Equal(t, 2, fakeChannelClient.ExecuteCallCount())
+
+	assert.Equal(t, []string{
+		"package:" + chaincodeId,
+		"install:peer0.org1",
+		"approve:peer0.org1",
+		"install:peer0.org2,peer1.org2",
+		"approve:peer0.org2,peer1.org2",
+		"commit:" + channelID,
+		"initEnclave:peer0.org1",
+		"initEnclave:peer0.org2",
+	}, steps)
+
+	// commit fails
+	org1.commitErr = fmt.Errorf("commit error")
+	resp, err = client.DeployFPCChaincode(channelID, req)
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	assert.Equal(t, 2, fakeChannelClient.QueryCallCount())
+}
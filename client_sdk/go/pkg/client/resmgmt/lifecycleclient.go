@@ -78,7 +78,9 @@ type LifecycleInitEnclaveRequest struct {
 type Client struct {
 	*resmgmt.Client
 	getChannelClient getChannelClientFunction
-	converter        credentialConverter
+	converter        CredentialConverter
+	lifecycle        lifecycleClient
+	ctxProvider      context.ClientProvider
 }
 
 // helper interfaces for better testing
@@ -91,14 +93,85 @@ type channelClient interface {
 }
 type getChannelClientFunction func(channelId string) (channelClient, error)
 
-type credentialConverter interface {
+type lifecycleClient interface {
+	LifecycleInstallCC(req resmgmt.LifecycleInstallCCRequest, options ...resmgmt.RequestOption) ([]resmgmt.LifecycleInstallCCResponse, error)
+	LifecycleApproveCC(channelID string, req resmgmt.LifecycleApproveCCRequest, options ...resmgmt.RequestOption) (fab.TransactionID, error)
+	LifecycleCommitCC(channelID string, req resmgmt.LifecycleCommitCCRequest, options ...resmgmt.RequestOption) (fab.TransactionID, error)
+	LifecycleQueryCommittedCC(channelID string, req resmgmt.LifecycleQueryCommittedCCRequest, options ...resmgmt.RequestOption) ([]resmgmt.LifecycleChaincodeDefinition, error)
+}
+
+// CredentialConverter performs the attestation to evidence conversion of the enclave credentials before
+// an enclave is registered at ERCC.
+type CredentialConverter interface {
 	ConvertCredentials(credentialsOnlyAttestation string) (credentialsWithEvidence string, err error)
 }
 
+// Converter converts the attestation of a particular attestation type to evidence, see WithConverters.
+type Converter = attestation.Converter
+
+// ClientOption describes a functional parameter for NewWithOptions
+type ClientOption func(*clientOptions) error
+
+type clientOptions struct {
+	sdkOptions []resmgmt.ClientOption
+	converter  CredentialConverter
+	converters []*Converter
+}
+
+// WithSDKClientOptions passes options to the underlying resmgmt.Client of the Fabric Go SDK,
+// e.g., resmgmt.WithDefaultTargetFilter.
+func WithSDKClientOptions(opts ...resmgmt.ClientOption) ClientOption {
+	return func(o *clientOptions) error {
+		o.sdkOptions = append(o.sdkOptions, opts...)
+		return nil
+	}
+}
+
+// WithCredentialConverter replaces the default credential converter, for instance, with a proxy to a remote
+// attestation service. It cannot be combined with WithConverters.
+func WithCredentialConverter(converter CredentialConverter) ClientOption {
+	return func(o *clientOptions) error {
+		if converter == nil {
+			return errors.New("credential converter must not be nil")
+		}
+		o.converter = converter
+		return nil
+	}
+}
+
+// WithConverters adds converters for additional attestation types to the default credential converter,
+// which supports simulation and EPID attestations.
+func WithConverters(converters ...*Converter) ClientOption {
+	return func(o *clientOptions) error {
+		for _, c := range converters {
+			if c == nil || c.Type == "" || c.Converter == nil {
+				return errors.New("converter requires a type and a convert function")
+			}
+		}
+		o.converters = append(o.converters, converters...)
+		return nil
+	}
+}
+
 // New returns a FPC resource management client instance.
+// The options are passed to the underlying resmgmt.Client of the Fabric Go SDK; use NewWithOptions to
+// configure FPC specific settings such as the credential converter.
 func New(ctxProvider context.ClientProvider, opts ...resmgmt.ClientOption) (*Client, error) {
+	return NewWithOptions(ctxProvider, WithSDKClientOptions(opts...))
+}
+
+// NewWithOptions returns a FPC resource management client instance configured with the given options.
+func NewWithOptions(ctxProvider context.ClientProvider, opts ...ClientOption) (*Client, error) {
+	clientOpts := &clientOptions{}
+	for _, opt := range opts {
+		err := opt(clientOpts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to apply client option")
+		}
+	}
+
 	// get resource management client
-	client, err := resmgmt.New(ctxProvider, opts...)
+	client, err := resmgmt.New(ctxProvider, clientOpts.sdkOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -112,20 +185,48 @@ func New(ctxProvider context.ClientProvider, opts ...resmgmt.ClientOption) (*Cli
 		return channel.New(channelProvider)
 	}
 
-	// use default credentials converter
-	// TODO allow to override credential converter using opts
-	converter := attestation.NewCredentialConverter()
+	converter, err := newCredentialConverter(clientOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{client, getChannelClient, converter, client, ctxProvider}, nil
+}
+
+func newCredentialConverter(opts *clientOptions) (CredentialConverter, error) {
+	if opts.converter != nil {
+		if len(opts.converters) > 0 {
+			return nil, errors.New("converters cannot be added to a custom credential converter")
+		}
+		return opts.converter, nil
+	}
 
-	return &Client{client, getChannelClient, converter}, nil
+	// use default credentials converter
+	converter, err := attestation.NewCredentialConverterWithConverters(opts.converters...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create credential converter")
+	}
+	return converter, nil
 }
 
 // LifecycleInitEnclave initializes and registers an enclave for a particular FPC chaincode.
+//
+// The options control the enclave initialization at the enclave peer and the enclave registration at ERCC.
+// Timeouts and the parent context apply to both. Retry options only apply to the enclave registration; the enclave
+// initialization is never retried, as each attempt would create a new enclave. Target peers
+// (resmgmt.WithTargetEndpoints or resmgmt.WithTargets) and target filters select the peers that endorse the enclave
+// registration, and resmgmt.WithOrdererEndpoint selects the orderer the registration is sent to.
 func (rc *Client) LifecycleInitEnclave(channelId string, req LifecycleInitEnclaveRequest, options ...resmgmt.RequestOption) (fab.TransactionID, error) {
 	err := rc.verifyInitEnclaveRequest(req)
 	if err != nil {
 		return fab.EmptyTransactionID, err
 	}
 
+	opts, err := rc.resolveRequestOptions(options)
+	if err != nil {
+		return fab.EmptyTransactionID, err
+	}
+
 	channelClient, err := rc.getChannelClient(channelId)
 	if err != nil {
 		return fab.EmptyTransactionID, errors.Wrap(err, "Failed to create new channel client")
@@ -149,7 +250,9 @@ func (rc *Client) LifecycleInitEnclave(channelId string, req LifecycleInitEnclav
 		Args:        [][]byte{[]byte(utils.MarshallProto(initMsg))},
 	}
 
+	// __initEnclave is never retried, as each invocation creates a new enclave
 	var initOpts []channel.RequestOption
+	initOpts = append(initOpts, opts.contextChannelOptions()...)
 	initOpts = append(initOpts, channel.WithRetry(retry.Opts{Attempts: 0}))
 	initOpts = append(initOpts, channel.WithTargetEndpoints(req.EnclavePeerEndpoint))
 
@@ -173,12 +276,17 @@ func (rc *Client) LifecycleInitEnclave(channelId string, req LifecycleInitEnclav
 	}
 
 	var registerOpts []channel.RequestOption
-	// TODO translate `resmgmt.RequestOption` to `channel.Option` options so we can pass it to execute
-	//registerOpts = append(registerOpts, options...)
+	registerOpts = append(registerOpts, opts.commonChannelOptions()...)
+	registerOpts = append(registerOpts, opts.targetChannelOptions()...)
 
 	logger.Debugf("calling registerEnclave")
 	// invoke registerEnclave at enclave registry
-	registerResponse, err := channelClient.Execute(registerRequest, registerOpts...)
+	var registerResponse channel.Response
+	if opts.Orderer != nil {
+		registerResponse, err = channelClient.InvokeHandler(newOrdererHandler(opts.Orderer), registerRequest, registerOpts...)
+	} else {
+		registerResponse, err = channelClient.Execute(registerRequest, registerOpts...)
+	}
 	if err != nil {
 		return fab.EmptyTransactionID, errors.Wrap(err, "Failed to execute register enclave")
 	}
@@ -12,7 +12,9 @@ import (
 
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/client/resmgmt/fakes"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
 	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
@@ -25,25 +27,25 @@ type chClient interface {
 	channelClient
 }
 
-//go:generate counterfeiter -o fakes/credential_converter.go -fake-name CredentialConverter . credentialConverter
+//go:generate counterfeiter -o fakes/credential_converter.go -fake-name CredentialConverter . CredentialConverter
 type credConverter interface {
-	credentialConverter
+	CredentialConverter
 }
 
 const (
 	channelID           = "mychannel"
 	chaincodeId         = "my-fpc-chaincode"
 	enclavePeerEndpoint = "mypeer.myorg.example.com"
-	attestationType     = "simulation"
+	attestationType     = sgx.AttestationTypeSimulated
 	expectedTxID        = fab.TransactionID("someTxID")
 )
 
-func setupClient(client channelClient, converter credentialConverter) *Client {
+func setupClient(client channelClient, converter CredentialConverter) *Client {
 	getChannelClient := func(channelId string) (channelClient, error) {
 		return client, nil
 	}
 
-	return &Client{nil, getChannelClient, converter}
+	return &Client{getChannelClient: getChannelClient, converter: converter}
 }
 
 func createClientContext(fabCtx context.Client) context.ClientProvider {
@@ -52,12 +54,23 @@ func createClientContext(fabCtx context.Client) context.ClientProvider {
 	}
 }
 
+type peerFilterStub struct{}
+
+func (f *peerFilterStub) Accept(peer fab.Peer) bool {
+	return true
+}
+
 func TestCreateNewClient(t *testing.T) {
 	clientCtx := fcmocks.NewMockContext(mspmocks.NewMockSigningIdentity("test", "Org1MSP"))
 	client, err := New(createClientContext(clientCtx))
 	assert.NotNil(t, client)
 	assert.NoError(t, err)
 
+	// options of the Fabric Go SDK
+	client, err = New(createClientContext(clientCtx), resmgmt.WithDefaultTargetFilter(&peerFilterStub{}))
+	assert.NotNil(t, client)
+	assert.NoError(t, err)
+
 	// MSP is missing
 	invalidClientCtx := fcmocks.NewMockContext(mspmocks.NewMockSigningIdentity("test", ""))
 	client, err = New(createClientContext(invalidClientCtx))
@@ -65,6 +78,46 @@ func TestCreateNewClient(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCreateNewClientWithOptions(t *testing.T) {
+	clientCtx := fcmocks.NewMockContext(mspmocks.NewMockSigningIdentity("test", "Org1MSP"))
+	dummyConverter := &Converter{
+		Type: "dummy",
+		Converter: func(attestationBytes []byte) ([]byte, error) {
+			return []byte("dummy evidence"), nil
+		},
+	}
+
+	// custom credential converter
+	fakeConverter := &fakes.CredentialConverter{}
+	client, err := NewWithOptions(createClientContext(clientCtx), WithCredentialConverter(fakeConverter), WithSDKClientOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, fakeConverter, client.converter)
+
+	// additional converters
+	client, err = NewWithOptions(createClientContext(clientCtx), WithConverters(dummyConverter))
+	assert.NoError(t, err)
+	assert.NotNil(t, client.converter)
+
+	// default attestation types cannot be overridden
+	client, err = NewWithOptions(createClientContext(clientCtx), WithConverters(attestation.NewSimulationConverter()))
+	assert.Nil(t, client)
+	assert.Error(t, err)
+
+	// converters cannot be added to a custom credential converter
+	client, err = NewWithOptions(createClientContext(clientCtx), WithCredentialConverter(fakeConverter), WithConverters(dummyConverter))
+	assert.Nil(t, client)
+	assert.EqualError(t, err, "converters cannot be added to a custom credential converter")
+
+	// invalid options
+	client, err = NewWithOptions(createClientContext(clientCtx), WithCredentialConverter(nil))
+	assert.Nil(t, client)
+	assert.EqualError(t, err, "failed to apply client option: credential converter must not be nil")
+
+	client, err = NewWithOptions(createClientContext(clientCtx), WithConverters(&Converter{Type: "dummy"}))
+	assert.Nil(t, client)
+	assert.EqualError(t, err, "failed to apply client option: converter requires a type and a convert function")
+}
+
 func TestLifecycleInitEnclaveFailedWithInvalidRequest(t *testing.T) {
 	fakeChannelClient := &fakes.ChannelClient{}
 	fakeConverter := &fakes.CredentialConverter{}
@@ -89,12 +142,22 @@ func TestLifecycleInitEnclaveFailedWithInvalidRequest(t *testing.T) {
 	assert.Error(t, err)
 
 	// invalid AttestationParams
-	// TODO implement me once
-	//request = LifecycleInitEnclaveRequest{ChaincodeID: chaincodeId, EnclavePeerEndpoint: enclavePeerEndpoint, AttestationParams: &sgx.AttestationParams{
-	//	AttestationType: "InvalidType",
-	//}}
-	//_, err = client.LifecycleInitEnclave(channelID, request)
-	//assert.Error(t, err)
+	request = LifecycleInitEnclaveRequest{ChaincodeID: chaincodeId, EnclavePeerEndpoint: enclavePeerEndpoint, AttestationParams: &sgx.AttestationParams{
+		AttestationType: "InvalidType",
+	}}
+	_, err = client.LifecycleInitEnclave(channelID, request)
+	assert.EqualError(t, err, "attestation params are invalid: invalid attestation type 'InvalidType', must be one of simulated, epid-linkable, epid-unlinkable, dcap")
+
+	request = LifecycleInitEnclaveRequest{ChaincodeID: chaincodeId, EnclavePeerEndpoint: enclavePeerEndpoint, AttestationParams: &sgx.AttestationParams{
+		AttestationType: sgx.AttestationTypeEpidLinkable,
+		HexSpid:         "1234",
+	}}
+	_, err = client.LifecycleInitEnclave(channelID, request)
+	assert.EqualError(t, err, "attestation params are invalid: SPID must be 32 hex characters but has 4")
+
+	// no peer has been called
+	assert.Equal(t, 0, fakeChannelClient.QueryCallCount())
+	assert.Equal(t, 0, fakeChannelClient.ExecuteCallCount())
 }
 
 func TestLifecycleInitEnclaveFailedToCreateChannelClient(t *testing.T) {
@@ -103,7 +166,7 @@ func TestLifecycleInitEnclaveFailedToCreateChannelClient(t *testing.T) {
 		return nil, expectedError
 	}
 
-	client := &Client{nil, getChannelClient, nil}
+	client := &Client{getChannelClient: getChannelClient}
 
 	initReq := LifecycleInitEnclaveRequest{
 		ChaincodeID:         chaincodeId,
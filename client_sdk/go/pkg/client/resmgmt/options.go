@@ -0,0 +1,150 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	reqContext "context"
+	"reflect"
+	"time"
+
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/context"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric-sdk-go/pkg/fab/txn"
+	"github.com/pkg/errors"
+)
+
+// requestOptions contains the settings of resmgmt.RequestOption which are relevant for the channel requests
+// issued by the FPC resource management client
+type requestOptions struct {
+	Targets       []fab.Peer
+	TargetFilter  fab.TargetFilter
+	Orderer       fab.Orderer
+	Timeouts      map[fab.TimeoutType]time.Duration
+	ParentContext reqContext.Context
+	Retry         retry.Opts
+}
+
+// resolveRequestOptions applies the given options and returns the resulting settings.
+// Note that the Fabric Go SDK does not expose the settings of a resmgmt.RequestOption, as these are applied to an
+// unexported struct. Hence, we apply the options to an instance of this struct using reflection and copy the fields
+// afterwards. TestRequestOptionsMatchSDK fails if the fields of this struct change with a Fabric Go SDK update.
+func (rc *Client) resolveRequestOptions(options []resmgmt.RequestOption) (*requestOptions, error) {
+	opts := &requestOptions{}
+	if len(options) == 0 {
+		return opts, nil
+	}
+
+	var ctx context.Client
+	if rc.ctxProvider != nil {
+		var err error
+		ctx, err = rc.ctxProvider()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get client context")
+		}
+	}
+
+	optionType := reflect.TypeOf(options[0])
+	ctxValue := reflect.Zero(optionType.In(0))
+	if ctx != nil {
+		ctxValue = reflect.ValueOf(ctx)
+	}
+	sdkOpts := reflect.New(optionType.In(1).Elem())
+
+	for _, o := range options {
+		if o == nil {
+			continue
+		}
+		out := reflect.ValueOf(o).Call([]reflect.Value{ctxValue, sdkOpts})
+		if err, ok := out[0].Interface().(error); ok && err != nil {
+			return nil, errors.Wrap(err, "invalid request option")
+		}
+	}
+
+	// copy all fields we know of
+	src := sdkOpts.Elem()
+	dst := reflect.ValueOf(opts).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		name := dst.Type().Field(i).Name
+		f := src.FieldByName(name)
+		if !f.IsValid() || !f.Type().AssignableTo(dst.Field(i).Type()) {
+			logger.Warningf("request option %s not supported by this version of the Fabric Go SDK", name)
+			continue
+		}
+		dst.Field(i).Set(f)
+	}
+
+	return opts, nil
+}
+
+// contextChannelOptions returns the channel options for the timeouts and the parent context
+func (o *requestOptions) contextChannelOptions() []channel.RequestOption {
+	var opts []channel.RequestOption
+	for timeoutType, timeout := range o.Timeouts {
+		opts = append(opts, channel.WithTimeout(timeoutType, timeout))
+	}
+	if o.ParentContext != nil {
+		opts = append(opts, channel.WithParentContext(o.ParentContext))
+	}
+	return opts
+}
+
+// commonChannelOptions returns the channel options which apply to any idempotent channel request, i.e., timeouts,
+// retries, and the parent context. If no retry options are set, the default of the channel client is used.
+func (o *requestOptions) commonChannelOptions() []channel.RequestOption {
+	opts := o.contextChannelOptions()
+	if !reflect.DeepEqual(o.Retry, retry.Opts{}) {
+		opts = append(opts, channel.WithRetry(o.Retry))
+	}
+	return opts
+}
+
+// targetChannelOptions returns the channel options which select the endorsing peers
+func (o *requestOptions) targetChannelOptions() []channel.RequestOption {
+	var opts []channel.RequestOption
+	if len(o.Targets) > 0 {
+		opts = append(opts, channel.WithTargets(o.Targets...))
+	}
+	if o.TargetFilter != nil {
+		opts = append(opts, channel.WithTargetFilter(o.TargetFilter))
+	}
+	return opts
+}
+
+// ordererHandler executes a transaction like invoke.NewExecuteHandler but sends it to a specific orderer
+type ordererHandler struct {
+	orderer fab.Orderer
+	next    invoke.Handler
+}
+
+func newOrdererHandler(orderer fab.Orderer) invoke.Handler {
+	return &ordererHandler{orderer: orderer, next: invoke.NewExecuteHandler()}
+}
+
+func (h *ordererHandler) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	ctx := *clientContext
+	ctx.Transactor = &ordererTransactor{
+		Transactor: clientContext.Transactor,
+		orderer:    h.orderer,
+		reqCtx:     requestContext.Ctx,
+	}
+	h.next.Handle(requestContext, &ctx)
+}
+
+// ordererTransactor overrides SendTransaction of a fab.Transactor to use a specific orderer
+type ordererTransactor struct {
+	fab.Transactor
+	orderer fab.Orderer
+	reqCtx  reqContext.Context
+}
+
+func (t *ordererTransactor) SendTransaction(tx *fab.Transaction) (*fab.TransactionResponse, error) {
+	return txn.Send(t.reqCtx, tx, []fab.Orderer{t.orderer})
+}
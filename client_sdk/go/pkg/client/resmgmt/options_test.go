@@ -0,0 +1,182 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	reqContext "context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/client/resmgmt/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel/invoke"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/retry"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	fcmocks "github.com/hyperledger/fabric-sdk-go/pkg/fab/mocks"
+	mspmocks "github.com/hyperledger/fabric-sdk-go/pkg/msp/test/mockmsp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveRequestOptions(t *testing.T) {
+	client := &Client{}
+
+	// no options
+	opts, err := client.resolveRequestOptions(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &requestOptions{}, opts)
+
+	peer := fcmocks.NewMockPeer("peer1", "peer1.example.com:7051")
+	orderer := fcmocks.NewMockOrderer("orderer.example.com:7050", nil)
+	parentCtx := reqContext.Background()
+	retryOpts := retry.Opts{Attempts: 3}
+
+	opts, err = client.resolveRequestOptions([]resmgmt.RequestOption{
+		resmgmt.WithTargets(peer),
+		resmgmt.WithOrderer(orderer),
+		resmgmt.WithTimeout(fab.Execute, time.Minute),
+		resmgmt.WithParentContext(parentCtx),
+		resmgmt.WithRetry(retryOpts),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []fab.Peer{peer}, opts.Targets)
+	assert.Equal(t, orderer, opts.Orderer)
+	assert.Equal(t, time.Minute, opts.Timeouts[fab.Execute])
+	assert.Equal(t, parentCtx, opts.ParentContext)
+	assert.Equal(t, retryOpts, opts.Retry)
+
+	assert.Len(t, opts.contextChannelOptions(), 2)
+	assert.Len(t, opts.commonChannelOptions(), 3)
+	assert.Len(t, opts.targetChannelOptions(), 1)
+
+	// options resolved with the client context
+	clientCtx := fcmocks.NewMockContext(mspmocks.NewMockSigningIdentity("test", "Org1MSP"))
+	client.ctxProvider = createClientContext(clientCtx)
+	opts, err = client.resolveRequestOptions([]resmgmt.RequestOption{resmgmt.WithOrdererEndpoint("Invalid")})
+	assert.Nil(t, opts)
+	assert.Error(t, err)
+}
+
+func TestCommonChannelOptionsDefaultRetry(t *testing.T) {
+	opts := &requestOptions{}
+	assert.Empty(t, opts.contextChannelOptions())
+	assert.Empty(t, opts.commonChannelOptions())
+	assert.Empty(t, opts.targetChannelOptions())
+}
+
+// TestRequestOptionsMatchSDK fails if the settings of resmgmt.RequestOption, which are read by resolveRequestOptions
+// using reflection, change in the Fabric Go SDK
+func TestRequestOptionsMatchSDK(t *testing.T) {
+	// settings of resmgmt.RequestOption which are not relevant for the FPC channel requests
+	ignored := map[string]bool{
+		"Signatures": true, // channel configuration updates only
+	}
+
+	sdkType := reflect.TypeOf(resmgmt.RequestOption(nil)).In(1).Elem()
+	fpcType := reflect.TypeOf(requestOptions{})
+
+	for i := 0; i < fpcType.NumField(); i++ {
+		field := fpcType.Field(i)
+		sdkField, ok := sdkType.FieldByName(field.Name)
+		if assert.True(t, ok, "request option %s missing in the Fabric Go SDK", field.Name) {
+			assert.Equal(t, field.Type, sdkField.Type, "request option %s changed in the Fabric Go SDK", field.Name)
+		}
+	}
+	for i := 0; i < sdkType.NumField(); i++ {
+		name := sdkType.Field(i).Name
+		_, ok := fpcType.FieldByName(name)
+		assert.True(t, ok || ignored[name], "request option %s of the Fabric Go SDK not supported", name)
+	}
+}
+
+// channelRetryOptions returns the retry options set by the given channel options
+func channelRetryOptions(t *testing.T, options []channel.RequestOption) retry.Opts {
+	optionType := reflect.TypeOf(channel.RequestOption(nil))
+	ctx := reflect.ValueOf(fcmocks.NewMockContext(mspmocks.NewMockSigningIdentity("test", "Org1MSP")))
+	opts := reflect.New(optionType.In(1).Elem())
+	for _, o := range options {
+		// errors of other options, e.g., unknown target endpoints, are irrelevant here
+		reflect.ValueOf(o).Call([]reflect.Value{ctx, opts})
+	}
+	retryOpts, ok := opts.Elem().FieldByName("Retry").Interface().(retry.Opts)
+	assert.True(t, ok)
+	return retryOpts
+}
+
+func TestLifecycleInitEnclaveWithOptions(t *testing.T) {
+	fakeChannelClient := &fakes.ChannelClient{}
+	fakeChannelClient.QueryReturns(channel.Response{}, nil)
+	fakeChannelClient.ExecuteReturns(channel.Response{TransactionID: expectedTxID}, nil)
+	fakeChannelClient.InvokeHandlerReturns(channel.Response{TransactionID: expectedTxID}, nil)
+
+	client := setupClient(fakeChannelClient, &fakes.CredentialConverter{})
+
+	initReq := LifecycleInitEnclaveRequest{
+		ChaincodeID:         chaincodeId,
+		EnclavePeerEndpoint: enclavePeerEndpoint,
+		AttestationParams: &sgx.AttestationParams{
+			AttestationType: attestationType,
+		},
+	}
+
+	peer := fcmocks.NewMockPeer("peer1", "peer1.example.com:7051")
+
+	// without orderer
+	txId, err := client.LifecycleInitEnclave(channelID, initReq,
+		resmgmt.WithTargets(peer),
+		resmgmt.WithRetry(retry.DefaultResMgmtOpts),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTxID, txId)
+
+	// __initEnclave is sent without retry and with the enclave peer as target
+	_, initOpts := fakeChannelClient.QueryArgsForCall(0)
+	assert.Len(t, initOpts, 2)
+	assert.Equal(t, retry.Opts{Attempts: 0}, channelRetryOptions(t, initOpts))
+
+	// registerEnclave is sent with retry and the given targets
+	_, registerOpts := fakeChannelClient.ExecuteArgsForCall(0)
+	assert.Len(t, registerOpts, 2)
+	assert.Equal(t, retry.DefaultResMgmtOpts, channelRetryOptions(t, registerOpts))
+	assert.Equal(t, 0, fakeChannelClient.InvokeHandlerCallCount())
+
+	// with orderer
+	orderer := fcmocks.NewMockOrderer("orderer.example.com:7050", nil)
+	txId, err = client.LifecycleInitEnclave(channelID, initReq, resmgmt.WithOrderer(orderer))
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTxID, txId)
+	assert.Equal(t, 1, fakeChannelClient.ExecuteCallCount())
+	assert.Equal(t, 1, fakeChannelClient.InvokeHandlerCallCount())
+
+	handler, registerRequest, _ := fakeChannelClient.InvokeHandlerArgsForCall(0)
+	assert.IsType(t, &ordererHandler{}, handler)
+	assert.Equal(t, registerEnclaveCMD, registerRequest.Fcn)
+}
+
+type handlerStub struct {
+	clientContext *invoke.ClientContext
+}
+
+func (h *handlerStub) Handle(requestContext *invoke.RequestContext, clientContext *invoke.ClientContext) {
+	h.clientContext = clientContext
+}
+
+func TestOrdererHandler(t *testing.T) {
+	orderer := fcmocks.NewMockOrderer("orderer.example.com:7050", nil)
+	next := &handlerStub{}
+	handler := &ordererHandler{orderer: orderer, next: next}
+
+	clientContext := &invoke.ClientContext{}
+	handler.Handle(&invoke.RequestContext{}, clientContext)
+
+	// the transactor is replaced for the next handlers only
+	assert.Nil(t, clientContext.Transactor)
+	assert.IsType(t, &ordererTransactor{}, next.clientContext.Transactor)
+	assert.Equal(t, orderer, next.clientContext.Transactor.(*ordererTransactor).orderer)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/pkg/errors"
+)
+
+const (
+	getEnclaveStatusCMD            = "__getEnclaveStatus"
+	queryListEnclaveCredentialsCMD = "queryListEnclaveCredentials"
+)
+
+// LifecycleQueryEnclaveStatusRequest contains the parameters to query the enclave status of a FPC chaincode
+type LifecycleQueryEnclaveStatusRequest struct {
+	ChaincodeID string
+	// Peers to query; if empty, the peers hosting the enclaves registered at ERCC are queried
+	Peers []string
+}
+
+// EnclaveStatus describes the enclave of a FPC chaincode hosted by a peer
+type EnclaveStatus struct {
+	PeerEndpoint string
+	// Error is set if the status could not be queried at the peer; all other fields are unset in this case
+	Error error
+
+	Initialized     bool
+	EnclaveID       string
+	Mrenclave       string
+	Sequence        int64
+	SGXMode         string
+	AttestationType string
+
+	// Registered and Provisioned are reported by the peer according to its view of ERCC
+	Registered  bool
+	Provisioned bool
+	// MatchesERCC is true if the enclave is registered at ERCC for this peer
+	MatchesERCC bool
+	// MatchesDefinition is true if mrenclave and sequence of the enclave match the committed chaincode definition
+	MatchesDefinition bool
+}
+
+// RegisteredEnclave describes an enclave registered at ERCC
+type RegisteredEnclave struct {
+	EnclaveID    string
+	PeerEndpoint string
+	Mrenclave    string
+	Sequence     int64
+}
+
+// LifecycleQueryEnclaveStatusResponse contains the enclave status of each queried peer and ERCC's view
+type LifecycleQueryEnclaveStatusResponse struct {
+	Peers []EnclaveStatus
+	// Registered contains all enclaves registered at ERCC for the chaincode
+	Registered []RegisteredEnclave
+	// Unreported contains the registered enclaves not reported by any of the queried peers
+	Unreported []RegisteredEnclave
+}
+
+// LifecycleQueryEnclaveStatus queries the status of the enclave of a FPC chaincode at each peer and compares it with
+// the enclaves registered at ERCC and with the committed chaincode definition.
+// A failing status query at a peer is reported in the status of that peer and does not fail the whole operation.
+//
+// Timeouts, retry options, and the parent context apply to all queries. Target peers and target filters select the
+// peers that are queried for the ERCC state.
+func (rc *Client) LifecycleQueryEnclaveStatus(channelId string, req LifecycleQueryEnclaveStatusRequest, options ...resmgmt.RequestOption) (*LifecycleQueryEnclaveStatusResponse, error) {
+	if req.ChaincodeID == "" {
+		return nil, errors.New("chaincodeId is required")
+	}
+
+	opts, err := rc.resolveRequestOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	channelClient, err := rc.getChannelClient(channelId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create new channel client")
+	}
+
+	definition, err := rc.queryChaincodeDefinition(channelId, req.ChaincodeID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	registered, err := queryRegisteredEnclaves(channelClient, req.ChaincodeID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := req.Peers
+	if len(peers) == 0 {
+		seen := make(map[string]bool)
+		for _, enclave := range registered {
+			if !seen[enclave.PeerEndpoint] {
+				seen[enclave.PeerEndpoint] = true
+				peers = append(peers, enclave.PeerEndpoint)
+			}
+		}
+	}
+
+	resp := &LifecycleQueryEnclaveStatusResponse{Registered: registered}
+	reported := make(map[string]bool)
+	for _, peer := range peers {
+		status := queryEnclaveStatus(channelClient, req.ChaincodeID, peer, opts)
+		if status.Initialized {
+			reported[status.EnclaveID] = true
+			for _, enclave := range registered {
+				if enclave.EnclaveID == status.EnclaveID && enclave.PeerEndpoint == peer {
+					status.MatchesERCC = true
+				}
+			}
+			status.MatchesDefinition = status.Mrenclave == definition.Version && status.Sequence == definition.Sequence
+		}
+		resp.Peers = append(resp.Peers, status)
+	}
+
+	for _, enclave := range registered {
+		if !reported[enclave.EnclaveID] {
+			resp.Unreported = append(resp.Unreported, enclave)
+		}
+	}
+
+	return resp, nil
+}
+
+// queryChaincodeDefinition returns the committed definition of a chaincode
+func (rc *Client) queryChaincodeDefinition(channelId, chaincodeId string, options []resmgmt.RequestOption) (*resmgmt.LifecycleChaincodeDefinition, error) {
+	definitions, err := rc.lifecycle.LifecycleQueryCommittedCC(channelId, resmgmt.LifecycleQueryCommittedCCRequest{Name: chaincodeId}, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query chaincode definition")
+	}
+	if len(definitions) == 0 {
+		return nil, errors.Errorf("no chaincode definition committed for %s", chaincodeId)
+	}
+
+	return &definitions[0], nil
+}
+
+// queryEnclaveStatus queries the enclave status at a peer
+func queryEnclaveStatus(channelClient channelClient, chaincodeId, peer string, opts *requestOptions) EnclaveStatus {
+	var queryOpts []channel.RequestOption
+	queryOpts = append(queryOpts, opts.commonChannelOptions()...)
+	queryOpts = append(queryOpts, channel.WithTargetEndpoints(peer))
+
+	result := EnclaveStatus{PeerEndpoint: peer}
+	queryResponse, err := channelClient.Query(channel.Request{
+		ChaincodeID: chaincodeId,
+		Fcn:         getEnclaveStatusCMD,
+	}, queryOpts...)
+	if err != nil {
+		result.Error = errors.Wrap(err, "failed to query enclave status")
+		return result
+	}
+
+	statusBytes, err := base64.StdEncoding.DecodeString(string(queryResponse.Payload))
+	if err != nil {
+		result.Error = errors.Wrap(err, "cannot decode enclave status")
+		return result
+	}
+
+	status := &protos.EnclaveStatus{}
+	if err := proto.Unmarshal(statusBytes, status); err != nil {
+		result.Error = errors.Wrap(err, "cannot unmarshal enclave status")
+		return result
+	}
+
+	result.Initialized = status.GetInitialized()
+	result.EnclaveID = status.GetEnclaveId()
+	result.Mrenclave = status.GetCcParams().GetVersion()
+	result.Sequence = status.GetCcParams().GetSequence()
+	result.SGXMode = status.GetSgxMode()
+	result.AttestationType = status.GetAttestationType()
+	result.Registered = status.GetRegistered()
+	result.Provisioned = status.GetProvisioned()
+	return result
+}
+
+// queryRegisteredEnclaves returns the enclaves registered at ERCC for a chaincode, sorted by peer endpoint
+func queryRegisteredEnclaves(channelClient channelClient, chaincodeId string, opts *requestOptions) ([]RegisteredEnclave, error) {
+	var queryOpts []channel.RequestOption
+	queryOpts = append(queryOpts, opts.commonChannelOptions()...)
+	queryOpts = append(queryOpts, opts.targetChannelOptions()...)
+
+	queryResponse, err := channelClient.Query(channel.Request{
+		ChaincodeID: ercc,
+		Fcn:         queryListEnclaveCredentialsCMD,
+		Args:        [][]byte{[]byte(chaincodeId)},
+	}, queryOpts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query enclave credentials")
+	}
+
+	var allCredentials []string
+	if len(queryResponse.Payload) > 0 {
+		if err := json.Unmarshal(queryResponse.Payload, &allCredentials); err != nil {
+			return nil, errors.Wrap(err, "cannot unmarshal enclave credentials")
+		}
+	}
+
+	var registered []RegisteredEnclave
+	for _, credentialsBase64 := range allCredentials {
+		credentials, err := utils.UnmarshalCredentials(credentialsBase64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid enclave credentials")
+		}
+
+		attestedData := &protos.AttestedData{}
+		if err := ptypes.UnmarshalAny(credentials.SerializedAttestedData, attestedData); err != nil {
+			return nil, errors.Wrap(err, "invalid attested data")
+		}
+
+		registered = append(registered, RegisteredEnclave{
+			EnclaveID:    utils.GetEnclaveId(attestedData),
+			PeerEndpoint: attestedData.GetHostParams().GetPeerEndpoint(),
+			Mrenclave:    attestedData.GetCcParams().GetVersion(),
+			Sequence:     attestedData.GetCcParams().GetSequence(),
+		})
+	}
+
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].PeerEndpoint < registered[j].PeerEndpoint
+	})
+
+	return registered, nil
+}
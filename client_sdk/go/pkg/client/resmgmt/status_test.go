@@ -0,0 +1,160 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package resmgmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/client/resmgmt/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/channel"
+	"github.com/hyperledger/fabric-sdk-go/pkg/client/resmgmt"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func createEnclaveCredentials(enclaveVk, version string, sequence int64, peerEndpoint string) (string, string) {
+	attestedData := &protos.AttestedData{
+		EnclaveVk: []byte(enclaveVk),
+		CcParams: &protos.CCParameters{
+			ChaincodeId: chaincodeId,
+			Version:     version,
+			Sequence:    sequence,
+			ChannelId:   channelID,
+		},
+		HostParams: &protos.HostParameters{
+			PeerEndpoint: peerEndpoint,
+		},
+	}
+	credentials := &protos.Credentials{
+		SerializedAttestedData: &any.Any{
+			TypeUrl: proto.MessageName(attestedData),
+			Value:   protoutil.MarshalOrPanic(attestedData),
+		},
+	}
+	return utils.MarshallProto(credentials), utils.GetEnclaveId(attestedData)
+}
+
+func setupStatusClient(t *testing.T, credentials ...string) (*Client, *fakes.ChannelClient) {
+	payload, err := json.Marshal(credentials)
+	assert.NoError(t, err)
+
+	fakeChannelClient := &fakes.ChannelClient{}
+	fakeChannelClient.QueryReturns(channel.Response{}, nil)
+	fakeChannelClient.QueryReturnsOnCall(0, channel.Response{Payload: payload}, nil)
+	fakeChannelClient.ExecuteReturns(channel.Response{TransactionID: expectedTxID}, nil)
+
+	client := setupClient(fakeChannelClient, &fakes.CredentialConverter{})
+	client.lifecycle = &lifecycleStub{definitions: []resmgmt.LifecycleChaincodeDefinition{{
+		Name:     chaincodeId,
+		Version:  mrenclave,
+		Sequence: 2,
+	}}}
+	return client, fakeChannelClient
+}
+
+func TestLifecycleQueryEnclaveStatusInvalidRequest(t *testing.T) {
+	client, _ := setupStatusClient(t)
+
+	resp, err := client.LifecycleQueryEnclaveStatus(channelID, LifecycleQueryEnclaveStatusRequest{})
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "chaincodeId is required")
+}
+
+func TestLifecycleQueryEnclaveStatus(t *testing.T) {
+	const otherPeer = "otherpeer.myorg.example.com"
+	const unreportedPeer = "unreported.myorg.example.com"
+
+	registered, registeredId := createEnclaveCredentials("registeredVk", mrenclave, 2, enclavePeerEndpoint)
+	unreported, unreportedId := createEnclaveCredentials("unreportedVk", mrenclave, 1, unreportedPeer)
+	client, fakeChannelClient := setupStatusClient(t, registered, unreported)
+
+	// enclavePeerEndpoint hosts the registered enclave
+	fakeChannelClient.QueryReturnsOnCall(1, channel.Response{Payload: []byte(utils.MarshallProto(&protos.EnclaveStatus{
+		Initialized: true,
+		EnclaveId:   registeredId,
+		CcParams: &protos.CCParameters{
+			ChaincodeId: chaincodeId,
+			Version:     mrenclave,
+			Sequence:    2,
+		},
+		SgxMode:         "SIM",
+		AttestationType: "simulated",
+		Registered:      true,
+		Provisioned:     true,
+	}))}, nil)
+	// otherPeer has no enclave
+	fakeChannelClient.QueryReturnsOnCall(2, channel.Response{Payload: []byte(utils.MarshallProto(&protos.EnclaveStatus{
+		SgxMode: "SIM",
+	}))}, nil)
+	// unreachable peer
+	fakeChannelClient.QueryReturnsOnCall(3, channel.Response{}, fmt.Errorf("peer not reachable"))
+
+	resp, err := client.LifecycleQueryEnclaveStatus(channelID, LifecycleQueryEnclaveStatusRequest{
+		ChaincodeID: chaincodeId,
+		Peers:       []string{enclavePeerEndpoint, otherPeer, "unreachable.myorg.example.com"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Registered, 2)
+	assert.Equal(t, []RegisteredEnclave{{
+		EnclaveID:    unreportedId,
+		PeerEndpoint: unreportedPeer,
+		Mrenclave:    mrenclave,
+		Sequence:     1,
+	}}, resp.Unreported)
+
+	assert.Len(t, resp.Peers, 3)
+	assert.Equal(t, EnclaveStatus{
+		PeerEndpoint:      enclavePeerEndpoint,
+		Initialized:       true,
+		EnclaveID:         registeredId,
+		Mrenclave:         mrenclave,
+		Sequence:          2,
+		SGXMode:           "SIM",
+		AttestationType:   "simulated",
+		Registered:        true,
+		Provisioned:       true,
+		MatchesERCC:       true,
+		MatchesDefinition: true,
+	}, resp.Peers[0])
+	assert.Equal(t, EnclaveStatus{PeerEndpoint: otherPeer, SGXMode: "SIM"}, resp.Peers[1])
+	assert.Contains(t, resp.Peers[2].Error.Error(), "peer not reachable")
+
+	// status queries target the individual peers
+	query, _ := fakeChannelClient.QueryArgsForCall(1)
+	assert.Equal(t, chaincodeId, query.ChaincodeID)
+	assert.Equal(t, getEnclaveStatusCMD, query.Fcn)
+}
+
+func TestLifecycleQueryEnclaveStatusRegisteredPeers(t *testing.T) {
+	registered, _ := createEnclaveCredentials("registeredVk", "oldMrenclave", 1, enclavePeerEndpoint)
+	client, fakeChannelClient := setupStatusClient(t, registered)
+	fakeChannelClient.QueryReturnsOnCall(1, channel.Response{Payload: []byte("invalid base64!")}, nil)
+
+	// without peers, the peers of the registered enclaves are queried
+	resp, err := client.LifecycleQueryEnclaveStatus(channelID, LifecycleQueryEnclaveStatusRequest{
+		ChaincodeID: chaincodeId,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Peers, 1)
+	assert.Equal(t, enclavePeerEndpoint, resp.Peers[0].PeerEndpoint)
+	assert.Error(t, resp.Peers[0].Error)
+	assert.Len(t, resp.Unreported, 1)
+
+	// ERCC query fails
+	fakeChannelClient.QueryReturnsOnCall(2, channel.Response{}, fmt.Errorf("ercc not available"))
+	resp, err = client.LifecycleQueryEnclaveStatus(channelID, LifecycleQueryEnclaveStatusRequest{
+		ChaincodeID: chaincodeId,
+	})
+	assert.Nil(t, resp)
+	assert.Contains(t, err.Error(), "ercc not available")
+}
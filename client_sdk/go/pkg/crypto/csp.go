@@ -0,0 +1,48 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package crypto provides the crypto service providers (CSP) used by the FPC Client SDK.
+// Besides the default software CSP, a CSP can use a Fabric BCCSP or, if built with the pkcs11 build tag, a PKCS#11
+// token, so that ECDSA signing keys never leave a HSM. The RSA and AES keys used to encrypt requests and responses are
+// always software keys.
+package crypto
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric/bccsp"
+)
+
+// CSP (Crypto Service Provider) offers the cryptographic primitives used in FPC
+type CSP = crypto.CSP
+
+// BCCSPKeyReferenceType is the PEM block type of the private keys returned by a BCCSP based CSP
+const BCCSPKeyReferenceType = crypto.BCCSPKeyReferenceType
+
+// GetDefaultCSP returns the software CSP
+func GetDefaultCSP() CSP {
+	return crypto.GetDefaultCSP()
+}
+
+// NewBCCSPCrypto returns a CSP which uses the given BCCSP for ECDSA key generation, signing and verification.
+// The private keys returned by NewECDSAKeys are references to the keys in the BCCSP.
+// All other operations are performed in software.
+func NewBCCSPCrypto(b bccsp.BCCSP) CSP {
+	return crypto.NewBCCSPCrypto(b)
+}
+
+// PaddingPolicy determines the padded size of requests and responses (see PowerOfTwoPadding and BucketPadding)
+type PaddingPolicy = crypto.PaddingPolicy
+
+// PowerOfTwoPadding returns a padding policy which pads a message to the next power of two larger than the message
+func PowerOfTwoPadding() *PaddingPolicy {
+	return crypto.PowerOfTwoPadding()
+}
+
+// BucketPadding returns a padding policy which pads a message to the smallest of the given sizes larger than the
+// message, or to a multiple of the largest size for larger messages
+func BucketPadding(sizes ...uint32) *PaddingPolicy {
+	return crypto.BucketPadding(sizes...)
+}
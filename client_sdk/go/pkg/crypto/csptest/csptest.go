@@ -0,0 +1,28 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package csptest provides the conformance test suite for crypto service providers (CSP).
+// A custom CSP, e.g., one that uses a HSM, can prove its compatibility with the crypto of the chaincode enclave by
+// running the suite in its tests:
+//
+//  func TestMyCSP(t *testing.T) {
+//  	csptest.Run(t, NewMyCSP(), crypto.GetDefaultCSP())
+//  }
+package csptest
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto/csptest"
+)
+
+// Run runs the conformance test suite against csp, i.e., it tests the key formats, the signatures and the
+// encryptions, including the behavior on tampered input, and decrypts and verifies fixed test vectors.
+// Additionally, the keys, signatures and ciphertexts of csp are used with each of the references, and vice versa.
+func Run(t *testing.T, csp crypto.CSP, references ...crypto.CSP) {
+	csptest.Run(t, csp, references...)
+}
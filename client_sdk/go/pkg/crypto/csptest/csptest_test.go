@@ -0,0 +1,17 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package csptest
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/crypto"
+)
+
+func TestDefaultCSP(t *testing.T) {
+	Run(t, crypto.GetDefaultCSP())
+}
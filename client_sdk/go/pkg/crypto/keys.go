@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+)
+
+// KeyFormat is an encoding of a public key
+type KeyFormat = crypto.KeyFormat
+
+const (
+	// PEM is the encoding of the public keys of the CSPs and the enclave, e.g., enclave_vk and chaincode_ek
+	PEM = crypto.PEM
+	// DER is the PKIX (SubjectPublicKeyInfo) encoding
+	DER = crypto.DER
+	// JWK is the JSON Web Key (RFC 7517) encoding
+	JWK = crypto.JWK
+)
+
+// ParsePublicKey parses a PEM, DER or JWK encoded RSA or ECDSA public key and returns an *rsa.PublicKey or
+// *ecdsa.PublicKey
+func ParsePublicKey(key []byte) (interface{}, error) {
+	return crypto.ParsePublicKey(key)
+}
+
+// EncodePublicKey converts a PEM, DER or JWK encoded public key into the given format, e.g., to exchange the
+// enclave keys with external systems
+func EncodePublicKey(key []byte, format KeyFormat) ([]byte, error) {
+	return crypto.EncodePublicKey(key, format)
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a public key as "SHA256:<hex>".
+// The fingerprint does not depend on the encoding of the key and can be used to display and pin enclave keys.
+func Fingerprint(key []byte) (string, error) {
+	return crypto.Fingerprint(key)
+}
+
+// JWKThumbprint returns the JWK thumbprint (RFC 7638) of a RSA or ECDSA public key
+func JWKThumbprint(key []byte) (string, error) {
+	return crypto.JWKThumbprint(key)
+}
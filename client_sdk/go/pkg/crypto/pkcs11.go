@@ -0,0 +1,22 @@
+// +build pkcs11
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+)
+
+// PKCS11Opts configures the PKCS#11 token, i.e., the path of the PKCS#11 library, and the label and the pin of the token
+type PKCS11Opts = crypto.PKCS11Opts
+
+// NewPKCS11Crypto returns a CSP whose ECDSA keys are generated and used by a PKCS#11 token, e.g., a HSM or SoftHSM.
+// All other operations are performed in software.
+func NewPKCS11Crypto(opts PKCS11Opts) (CSP, error) {
+	return crypto.NewPKCS11Crypto(opts)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/pkg/errors"
+)
+
+// TransactionRequest is a single transaction function invocation of a batch, see Contract.SubmitTransactionBatch
+type TransactionRequest struct {
+	// Name is the name of the transaction function to be invoked in the smart contract
+	Name string
+
+	// Args are the arguments to be sent to the transaction function
+	Args []string
+}
+
+func (c *contractState) SubmitTransactionBatch(requests ...TransactionRequest) ([][]byte, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("no transaction requests given")
+	}
+
+	c.syncSequence()
+
+	ctx, err := c.ep.NewEncryptionContext()
+	if err != nil {
+		return nil, err
+	}
+
+	batchCtx, ok := ctx.(crypto.BatchEncryptionContext)
+	if !ok {
+		return nil, errors.New("encryption context does not support batch invocations")
+	}
+
+	invocations := make([]crypto.Invocation, len(requests))
+	for i, r := range requests {
+		invocations[i] = crypto.Invocation{Function: r.Name, Args: r.Args}
+	}
+
+	encryptedRequest, err := batchCtx.ConcealBatch(invocations)
+	if err != nil {
+		return nil, err
+	}
+
+	// call __invoke
+	encryptedResponse, err := c.evaluateTransaction(encryptedRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	responses, err := batchCtx.RevealBatch(encryptedResponse)
+	if err != nil {
+		c.invalidate()
+		return nil, &ExecutionError{Err: err}
+	}
+	if len(responses) != len(requests) {
+		return nil, &ExecutionError{Err: errors.Errorf("enclave returned %d responses for a batch of %d requests", len(responses), len(requests))}
+	}
+
+	logger.Debugf("submitting batch of %d requests!", len(requests))
+	if err := c.endorse(NewPendingTransaction(encryptedResponse, responses...)); err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// batchEncryptionContext adds batch support to the EncryptionContext fake
+type batchEncryptionContext struct {
+	fakes.EncryptionContext
+	invocations []crypto.Invocation
+	responses   [][]byte
+	revealErr   error
+}
+
+func (e *batchEncryptionContext) ConcealBatch(invocations []crypto.Invocation) (string, error) {
+	e.invocations = invocations
+	return "someEncryptedBatch", nil
+}
+
+func (e *batchEncryptionContext) RevealBatch(r []byte) ([][]byte, error) {
+	return e.responses, e.revealErr
+}
+
+func TestContractSubmitTransactionBatch(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("encryptedResponse"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1"), nil)
+
+	batchCtx := &batchEncryptionContext{responses: [][]byte{[]byte("r1"), []byte("r2")}}
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(batchCtx, nil)
+
+	contract := &contractState{
+		contract: mockContract,
+		ercc:     mockERCC,
+		ep:       mockEncryptionProvider,
+	}
+
+	// empty batch
+	resp, err := contract.SubmitTransactionBatch()
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+
+	// success
+	resp, err = contract.SubmitTransactionBatch(
+		TransactionRequest{Name: "put", Args: []string{"k1", "v1"}},
+		TransactionRequest{Name: "put", Args: []string{"k2", "v2"}},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("r1"), []byte("r2")}, resp)
+	assert.Equal(t, []crypto.Invocation{
+		{Function: "put", Args: []string{"k1", "v1"}},
+		{Function: "put", Args: []string{"k2", "v2"}},
+	}, batchCtx.invocations)
+
+	// one __invoke and one __endorse for the whole batch
+	assert.Equal(t, 1, txn.EvaluateCallCount())
+	assert.Equal(t, []string{"someEncryptedBatch"}, txn.EvaluateArgsForCall(0))
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+	name, args := mockContract.SubmitTransactionArgsForCall(0)
+	assert.Equal(t, "__endorse", name)
+	assert.Equal(t, []string{"encryptedResponse"}, args)
+
+	// response count mismatch is not endorsed
+	resp, err = contract.SubmitTransactionBatch(TransactionRequest{Name: "put"})
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "enclave execution failed: enclave returned 2 responses for a batch of 1 requests")
+	assert.True(t, errors.As(err, new(*ExecutionError)))
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+
+	// reveal fails
+	batchCtx.revealErr = fmt.Errorf("reveal error")
+	resp, err = contract.SubmitTransactionBatch(TransactionRequest{Name: "put"}, TransactionRequest{Name: "put"})
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "enclave execution failed: reveal error")
+	assert.True(t, errors.As(err, new(*ExecutionError)))
+	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
+
+	// __endorse fails, the results are kept for resubmission
+	batchCtx.revealErr = nil
+	mockContract.SubmitTransactionReturnsOnCall(1, nil, fmt.Errorf("endorse failed"))
+	resp, err = contract.SubmitTransactionBatch(TransactionRequest{Name: "put"}, TransactionRequest{Name: "put"})
+	assert.Nil(t, resp)
+	var commitErr *CommitError
+	assert.True(t, errors.As(err, &commitErr))
+	assert.Equal(t, [][]byte{[]byte("r1"), []byte("r2")}, commitErr.Pending.Results())
+	assert.Equal(t, []byte("encryptedResponse"), commitErr.Pending.SignedResponse())
+
+	invokeCount := txn.EvaluateCallCount()
+	assert.NoError(t, contract.ResubmitTransaction(commitErr.Pending))
+	assert.Equal(t, invokeCount, txn.EvaluateCallCount())
+	assert.Equal(t, 3, mockContract.SubmitTransactionCallCount())
+	name, args = mockContract.SubmitTransactionArgsForCall(2)
+	assert.Equal(t, "__endorse", name)
+	assert.Equal(t, []string{"encryptedResponse"}, args)
+
+	// encryption context without batch support
+	mockEncryptionProvider.NewEncryptionContextReturns(&fakes.EncryptionContext{}, nil)
+	resp, err = contract.SubmitTransactionBatch(TransactionRequest{Name: "put"})
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "encryption context does not support batch invocations")
+}
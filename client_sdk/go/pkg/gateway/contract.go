@@ -10,12 +10,16 @@ package gateway
 
 import (
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/internal"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	lb "github.com/hyperledger/fabric-protos-go/peer/lifecycle"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
-	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/pkg/errors"
 )
 
 var logger = flogging.MustGetLogger("fpc-client-gateway")
@@ -52,8 +56,42 @@ type Contract interface {
 	//
 	//  Returns:
 	//  The return value of the transaction function in the smart contract.
+	//  An ExecutionError if the enclave did not execute the transaction, or a CommitError if the enclave executed the
+	//  transaction but its commit failed. A CommitError keeps the result, which can be committed with
+	//  ResubmitTransaction under the conditions given there.
 	SubmitTransaction(name string, args ...string) ([]byte, error)
 
+	// SubmitTransactionBatch will submit several transaction function invocations as a single transaction to the ledger.
+	// All requests are sent to the enclave in a single `__invoke` and executed, in the given order, on a common
+	// read/writeset which is committed with a single `__endorse`. Hence, either all or none of the requests take effect.
+	// Note that, as for a single transaction, a request does not observe the writes of earlier requests of the batch.
+	// If a request fails, the enclave aborts the whole batch. The responses of all requests are returned in a single
+	// signed enclave response, which is limited to enclave.MaxSignedResponseSize bytes (see
+	// github.com/hyperledger/fabric-private-chaincode/ecc/chaincode/enclave), including the base64 encoding of the
+	// responses, the response padding (see WithPadding) and the read/writeset. The enclave rejects batches whose
+	// responses exceed this limit. Hence, large amounts of records must be split into several batches.
+	//  Parameters:
+	//  requests are the transaction functions and their arguments to be invoked in the smart contract.
+	//
+	//  Returns:
+	//  The return values of the transaction functions, in the order of the requests.
+	//  An ExecutionError or a CommitError on failure, as with SubmitTransaction.
+	SubmitTransactionBatch(requests ...TransactionRequest) ([][]byte, error)
+
+	// ResubmitTransaction submits a transaction whose commit failed (see CommitError) again, i.e., it calls
+	// `__endorse` with the same signed enclave response, so that the chaincode is not executed again.
+	// Note that the resubmission is not idempotent: `__endorse` creates a new transaction with a new transaction ID,
+	// and only a read of a key written by an earlier submission causes an MVCC conflict. If the chaincode writes keys
+	// without reading them (blind writes), the resubmission commits again even if an earlier submission has been
+	// committed. Hence, a transaction must only be resubmitted if the earlier submissions are known to not have been
+	// committed, e.g., since they failed validation, or if the chaincode reads every key it writes.
+	//  Parameters:
+	//  pending is the pending transaction of a CommitError
+	//
+	//  Returns:
+	//  A CommitError if the commit failed again.
+	ResubmitTransaction(pending *PendingTransaction) error
+
 	// RegisterEvent registers for chaincode events. Unregister must be called when the registration is no longer needed.
 	//  Parameters:
 	//  eventFilter is the chaincode event filter (regular expression) for which events are to be received
@@ -62,44 +100,221 @@ type Contract interface {
 	//  the registration and a channel that is used to receive events. The channel is closed when Unregister is called.
 	RegisterEvent(eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error)
 
+	// RegisterEncryptedEvent registers for encrypted chaincode events as set by a FPC chaincode using `set_encrypted_event`.
+	// Each received event is verified against the credentials of the emitting enclave as registered at ERCC and then
+	// decrypted; events that fail verification or decryption are dropped.
+	// Unregister must be called when the registration is no longer needed.
+	//  Parameters:
+	//  eventFilter is the chaincode event filter (regular expression) for which events are to be received
+	//  eventKey is the (symmetric) key used by the chaincode to encrypt the event payload
+	//
+	//  Returns:
+	//  the registration and a channel that is used to receive events with decrypted payload. The channel is closed when Unregister is called.
+	RegisterEncryptedEvent(eventFilter string, eventKey []byte) (fab.Registration, <-chan *fab.CCEvent, error)
+
 	// Unregister removes the given registration and closes the event channel.
 	//  Parameters:
 	//  registration is the registration handle that was returned from RegisterContractEvent method
 	Unregister(registration fab.Registration)
 }
 
-// GetContract is the factory method for creating FPC Contract objects.
+// DefaultCacheTTL is the default time the chaincode encryption key and the peer endpoints
+// fetched from ERCC are cached by a Contract
+const DefaultCacheTTL = 5 * time.Minute
+
+// Default backoff of a failing `__endorse`, see WithEndorseRetries
+const (
+	DefaultEndorseInitialBackoff = 1 * time.Second
+	DefaultEndorseMaxBackoff     = 30 * time.Second
+)
+
+// ContractOption configures a Contract created by GetContract
+type ContractOption func(*contractOptions)
+
+type contractOptions struct {
+	cacheTTL          time.Duration
+	strategy          PeerSelectionStrategy
+	blacklistDuration time.Duration
+	channelID         string
+	requestPadding    *crypto.PaddingPolicy
+	responsePadding   *crypto.PaddingPolicy
+	endorseRetries    int
+	initialBackoff    time.Duration
+	maxBackoff        time.Duration
+}
+
+// WithCacheTTL sets the time the chaincode encryption key and the peer endpoints fetched from ERCC are cached.
+// A ttl <= 0 keeps them cached until they are invalidated, e.g., due to a failing invocation.
+func WithCacheTTL(ttl time.Duration) ContractOption {
+	return func(o *contractOptions) {
+		o.cacheTTL = ttl
+	}
+}
+
+// WithPeerSelectionStrategy sets the strategy used to pick the FPC enclave peers that execute a transaction.
+// If not set, FirstHealthyStrategy is used.
+func WithPeerSelectionStrategy(strategy PeerSelectionStrategy) ContractOption {
+	return func(o *contractOptions) {
+		o.strategy = strategy
+	}
+}
+
+// WithBlacklistDuration sets the time a peer is skipped after the invocation of its enclave failed.
+func WithBlacklistDuration(duration time.Duration) ContractOption {
+	return func(o *contractOptions) {
+		o.blacklistDuration = duration
+	}
+}
+
+// WithRequestBinding binds the encryption of requests and responses to the channel and the chaincode, so that an
+// encrypted request (or response) cannot be replayed against another chaincode or transaction.
+// This requires that the chaincode enclave supports the request nonce of the key transport message.
+func WithRequestBinding(channelID string) ContractOption {
+	return func(o *contractOptions) {
+		o.channelID = channelID
+	}
+}
+
+// WithPadding pads requests and responses before their encryption according to the given policies, e.g.,
+// crypto.PowerOfTwoPadding(), so that the size of the ciphertexts does not reveal the exact size of the arguments and
+// responses. A nil policy disables the padding of requests or responses, respectively.
+// This requires that the chaincode enclave supports padding.
+func WithPadding(requestPadding, responsePadding *crypto.PaddingPolicy) ContractOption {
+	return func(o *contractOptions) {
+		o.requestPadding = requestPadding
+		o.responsePadding = responsePadding
+	}
+}
+
+// WithEndorseRetries retries a `__endorse` failing due to a transient error, e.g., an unreachable peer or orderer, up
+// to the given number of times with the same signed enclave response, i.e., without executing the chaincode again.
+// The first retry waits initialBackoff; the waiting time doubles with every retry up to maxBackoff.
+// Other failures, such as an invalid enclave signature or an MVCC conflict, are not retried, as the transaction is
+// either rejected again or may have been committed already (see Contract.ResubmitTransaction).
+func WithEndorseRetries(retries int, initialBackoff, maxBackoff time.Duration) ContractOption {
+	return func(o *contractOptions) {
+		o.endorseRetries = retries
+		o.initialBackoff = initialBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// GetContract is the factory method for creating FPC Contract objects using the Fabric Go SDK.
 //  Parameters:
 //  network is an initialized Fabric network object
 //  chaincodeID is the ID of the target chaincode
+//  options are optional settings, such as WithCacheTTL or WithPeerSelectionStrategy
 //
 //  Returns:
 //  The contract object
-func GetContract(network internal.Network, chaincodeID string) Contract {
-
+func GetContract(network internal.Network, chaincodeID string, options ...ContractOption) Contract {
 	contract := network.GetContract(chaincodeID)
 	ercc := network.GetContract("ercc")
+	lifecycle := network.GetContract("_lifecycle")
+	return newContract(
+		&internal.ContractAdapter{Contract: contract},
+		&internal.ContractAdapter{Contract: ercc},
+		&internal.ContractAdapter{Contract: lifecycle},
+		chaincodeID,
+		applyContractOptions(options))
+}
+
+// NewContract is the factory method for creating FPC Contract objects on top of a client library other than the
+// Fabric Go SDK, such as the Fabric Gateway client as supported by the fabricgateway module.
+//  Parameters:
+//  contract, ercc and lifecycle adapt the target chaincode, ERCC and the lifecycle chaincode of the client library
+//  chaincodeID is the ID of the target chaincode
+//  options are optional settings, such as WithCacheTTL or WithPeerSelectionStrategy
+//
+//  Returns:
+//  The contract object
+func NewContract(contract, ercc, lifecycle internal.Contract, chaincodeID string, options ...ContractOption) Contract {
+	return newContract(contract, ercc, lifecycle, chaincodeID, applyContractOptions(options))
+}
+
+func applyContractOptions(options []ContractOption) *contractOptions {
+	opts := &contractOptions{
+		cacheTTL:          DefaultCacheTTL,
+		strategy:          FirstHealthyStrategy(),
+		blacklistDuration: DefaultBlacklistDuration,
+		initialBackoff:    DefaultEndorseInitialBackoff,
+		maxBackoff:        DefaultEndorseMaxBackoff,
+	}
+	for _, o := range options {
+		o(opts)
+	}
+	return opts
+}
+
+func newContract(contract, ercc, lifecycle internal.Contract, chaincodeID string, opts *contractOptions) Contract {
+	ep := crypto.NewCachingEncryptionProvider(crypto.GetDefaultCSP(), opts.cacheTTL, func() ([]byte, error) {
+		// Note that this function is called during EncryptionProvider.NewEncryptionContext() when no valid key is cached
+		return ercc.EvaluateTransaction("queryChaincodeEncryptionKey", chaincodeID)
+	})
+	if len(opts.channelID) != 0 {
+		ep.ChannelId = opts.channelID
+		ep.ChaincodeId = chaincodeID
+	}
+	ep.RequestPadding = opts.requestPadding
+	ep.ResponsePadding = opts.responsePadding
 	return &contractState{
-		contract:      &internal.ContractAdapter{Contract: contract},
-		ercc:          &internal.ContractAdapter{Contract: ercc},
-		peerEndpoints: nil,
-		ep: &crypto.EncryptionProviderImpl{
-			CSP: crypto.GetDefaultCSP(),
-			GetCcEncryptionKey: func() ([]byte, error) {
-				// Note that this function is called during EncryptionProvider.NewEncryptionContext()
-				return ercc.EvaluateTransaction("queryChaincodeEncryptionKey", chaincodeID)
-			}}}
+		contract:       contract,
+		ercc:           ercc,
+		lifecycle:      lifecycle,
+		peerEndpoints:  nil,
+		cacheTTL:       opts.cacheTTL,
+		strategy:       opts.strategy,
+		health:         newPeerHealth(opts.blacklistDuration),
+		ep:             ep,
+		endorseRetries: opts.endorseRetries,
+		initialBackoff: opts.initialBackoff,
+		maxBackoff:     opts.maxBackoff,
+	}
 }
 
 type contractState struct {
 	// note that we wrap the target chaincode and ercc with an adapter that
 	// implements the internal.Contract interface. This removes the direct
-	// dependency to gateway.Contract struct as provided by the Fabric Go SDK,
-	// and therefore allows better of this component.
-	contract      internal.Contract
-	ercc          internal.Contract
+	// dependency to the contract types as provided by the Fabric Go SDK or
+	// the Fabric Gateway client, and therefore allows better testing of this component.
+	contract internal.Contract
+	ercc     internal.Contract
+	ep       crypto.EncryptionProvider
+
+	// lifecycle is the lifecycle system chaincode, which is queried for the sequence of the committed chaincode
+	// definition to detect chaincode upgrades; if nil, upgrades are only detected by failing invocations
+	lifecycle internal.Contract
+
+	// peer endpoints are cached for cacheTTL; a cacheTTL <= 0 keeps them until invalidated
+	mutex         sync.Mutex
 	peerEndpoints []string
-	ep            crypto.EncryptionProvider
+	peerExpiry    time.Time
+	cacheTTL      time.Duration
+
+	// sequence of the committed chaincode definition, which is re-read from lifecycle once sequenceExpiry passed
+	sequence       int64
+	sequenceExpiry time.Time
+
+	// strategy orders the enclave peers for each invocation; peers with a failing enclave are blacklisted in health
+	strategy PeerSelectionStrategy
+	health   *peerHealth
+
+	// endorseRetries is the number of times a `__endorse` failing due to a transient error is retried, waiting
+	// initialBackoff before the first retry and doubling the waiting time up to maxBackoff
+	endorseRetries int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// invalidator is implemented by encryption providers that cache ERCC state, see crypto.CachingEncryptionProvider
+type invalidator interface {
+	Invalidate()
+}
+
+// sequenceObserver is implemented by encryption providers that drop cached ERCC state when the chaincode sequence
+// changes, see crypto.CachingEncryptionProvider
+type sequenceObserver interface {
+	ObserveSequence(sequence int64)
 }
 
 func (c *contractState) Name() string {
@@ -109,17 +324,100 @@ func (c *contractState) Name() string {
 // getPeerEndpoints returns an array of peer endpoints that host the FPC chaincode enclave
 // An endpoint is a simple string with the format `host:port`
 func (c *contractState) getPeerEndpoints() ([]string, error) {
-	if len(c.peerEndpoints) == 0 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	if len(c.peerEndpoints) == 0 || (c.cacheTTL > 0 && !now.Before(c.peerExpiry)) {
 		resp, err := c.ercc.EvaluateTransaction("queryChaincodeEndPoints", c.Name())
 		if err != nil {
 			return nil, err
 		}
-		c.peerEndpoints = strings.Split(string(resp), ",")
+		c.peerEndpoints = nil
+		for _, endpoint := range strings.Split(string(resp), ",") {
+			if len(endpoint) != 0 {
+				c.peerEndpoints = append(c.peerEndpoints, endpoint)
+			}
+		}
+		c.peerExpiry = now.Add(c.cacheTTL)
 	}
 	return c.peerEndpoints, nil
 }
 
+// invalidate drops the cached peer endpoints and, if supported by the encryption provider, the cached chaincode
+// encryption key, and forces the chaincode sequence to be re-read. This is called whenever an invocation of the enclave
+// fails or its response cannot be revealed, as this may be caused by stale ERCC state, e.g., after a chaincode upgrade.
+func (c *contractState) invalidate() {
+	c.mutex.Lock()
+	c.peerEndpoints = nil
+	c.sequenceExpiry = time.Time{}
+	c.mutex.Unlock()
+
+	if i, ok := c.ep.(invalidator); ok {
+		i.Invalidate()
+	}
+}
+
+// syncSequence re-reads the sequence of the committed chaincode definition once the cached one expired. After a
+// chaincode upgrade, the cached peer endpoints and the cached chaincode encryption key are dropped, as the chaincode is
+// then hosted by new enclaves. Failing to read the sequence is not fatal, as stale state is also detected by failing
+// invocations.
+func (c *contractState) syncSequence() {
+	if c.lifecycle == nil {
+		return
+	}
+
+	now := time.Now()
+	c.mutex.Lock()
+	fresh := !c.sequenceExpiry.IsZero() && (c.cacheTTL <= 0 || now.Before(c.sequenceExpiry))
+	c.mutex.Unlock()
+	if fresh {
+		return
+	}
+
+	sequence, err := c.querySequence()
+
+	c.mutex.Lock()
+	c.sequenceExpiry = now.Add(c.cacheTTL)
+	if err != nil {
+		c.mutex.Unlock()
+		logger.Warningf("cannot query chaincode definition of %s: %s", c.Name(), err)
+		return
+	}
+	if c.sequence != sequence {
+		logger.Debugf("chaincode sequence changed from %d to %d; invalidating cached peer endpoints", c.sequence, sequence)
+		c.sequence = sequence
+		c.peerEndpoints = nil
+	}
+	c.mutex.Unlock()
+
+	if o, ok := c.ep.(sequenceObserver); ok {
+		o.ObserveSequence(sequence)
+	}
+}
+
+// querySequence returns the sequence of the committed chaincode definition
+func (c *contractState) querySequence() (int64, error) {
+	args, err := proto.Marshal(&lb.QueryChaincodeDefinitionArgs{Name: c.Name()})
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.lifecycle.EvaluateTransaction("QueryChaincodeDefinition", string(args))
+	if err != nil {
+		return 0, err
+	}
+
+	definition := &lb.QueryChaincodeDefinitionResult{}
+	if err := proto.Unmarshal(resp, definition); err != nil {
+		return 0, errors.Wrap(err, "invalid chaincode definition")
+	}
+	return definition.Sequence, nil
+}
+
 func (c *contractState) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	c.syncSequence()
+
 	ctx, err := c.ep.NewEncryptionContext()
 	if err != nil {
 		return nil, err
@@ -136,28 +434,67 @@ func (c *contractState) EvaluateTransaction(name string, args ...string) ([]byte
 		return nil, err
 	}
 
-	return ctx.Reveal(encryptedResponse)
+	result, err := ctx.Reveal(encryptedResponse)
+	if err != nil {
+		c.invalidate()
+		return nil, &ExecutionError{Err: err}
+	}
+	return result, nil
 }
 
+// evaluateTransaction calls __invoke at the enclave peers as selected by the peer selection strategy.
+// If the invocation fails at one enclave, the corresponding peer is blacklisted and the next one is tried. If the
+// chaincode failed rather than the enclave (see internal.ChaincodeError), the error is returned right away.
 func (c *contractState) evaluateTransaction(args ...string) ([]byte, error) {
 	peers, err := c.getPeerEndpoints()
 	if err != nil {
 		return nil, err
 	}
 
-	txn, err := c.contract.CreateTransaction(
-		"__invoke",
-		gateway.WithEndorsingPeers(peers...),
-	)
-	if err != nil {
-		return nil, err
+	candidates := peers
+	if c.health != nil {
+		candidates = c.health.filter(candidates)
+	}
+	if c.strategy != nil {
+		candidates = c.strategy.Order(candidates)
+	}
+	if len(candidates) == 0 {
+		c.invalidate()
+		return nil, &ExecutionError{Err: errors.Errorf("no enclave peers found for chaincode %s", c.Name())}
+	}
+
+	var lastErr error
+	for _, peer := range candidates {
+		txn, err := c.contract.CreateTransaction("__invoke", peer)
+		if err != nil {
+			return nil, &ExecutionError{Err: err}
+		}
+
+		logger.Debugf("calling __invoke at %s!", peer)
+		resp, err := txn.Evaluate(args...)
+		if err == nil {
+			return resp, nil
+		}
+
+		if errors.As(err, new(*internal.ChaincodeError)) {
+			logger.Debugf("__invoke at %s failed in the chaincode: %s", peer, err)
+			return nil, &ExecutionError{Err: err}
+		}
+
+		logger.Warningf("__invoke at %s failed: %s", peer, err)
+		lastErr = err
+		if c.health != nil {
+			c.health.markUnhealthy(peer)
+		}
 	}
 
-	logger.Debugf("calling __invoke!")
-	return txn.Evaluate(args...)
+	c.invalidate()
+	return nil, &ExecutionError{Err: errors.Wrapf(lastErr, "__invoke failed at all %d enclave peers", len(candidates))}
 }
 
 func (c *contractState) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	c.syncSequence()
+
 	ctx, err := c.ep.NewEncryptionContext()
 	if err != nil {
 		return nil, err
@@ -174,13 +511,49 @@ func (c *contractState) SubmitTransaction(name string, args ...string) ([]byte,
 		return nil, err
 	}
 
-	logger.Debugf("calling __endorse!")
-	_, err = c.contract.SubmitTransaction("__endorse", string(encryptedResponse))
+	// the response is decrypted before its commit, so that the result is not lost if the commit fails
+	result, err := ctx.Reveal(encryptedResponse)
 	if err != nil {
+		c.invalidate()
+		return nil, &ExecutionError{Err: err}
+	}
+
+	if err := c.endorse(NewPendingTransaction(encryptedResponse, result)); err != nil {
 		return nil, err
 	}
+	return result, nil
+}
 
-	return ctx.Reveal(encryptedResponse)
+func (c *contractState) ResubmitTransaction(pending *PendingTransaction) error {
+	if pending == nil || len(pending.signedResponse) == 0 {
+		return errors.New("no pending transaction given")
+	}
+	return c.endorse(pending)
+}
+
+// endorse calls `__endorse` with the signed enclave response of the pending transaction. A `__endorse` failing due to
+// a transient error (see internal.TransientError) is retried up to endorseRetries times with exponential backoff; as
+// the response is the same, the chaincode is not executed again. Other failures are returned right away.
+func (c *contractState) endorse(pending *PendingTransaction) error {
+	backoff := c.initialBackoff
+	for attempt := 0; ; attempt++ {
+		logger.Debugf("calling __endorse!")
+		_, err := c.contract.SubmitTransaction("__endorse", string(pending.signedResponse))
+		if err == nil {
+			return nil
+		}
+		if !errors.As(err, new(*internal.TransientError)) || attempt >= c.endorseRetries {
+			return &CommitError{Err: err, Pending: pending}
+		}
+
+		logger.Warningf("__endorse failed (attempt %d of %d): %s; retry in %s", attempt+1, c.endorseRetries+1, err, backoff)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
 }
 
 func (c *contractState) RegisterEvent(eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
@@ -188,5 +561,9 @@ func (c *contractState) RegisterEvent(eventFilter string) (fab.Registration, <-c
 }
 
 func (c *contractState) Unregister(registration fab.Registration) {
+	if r, ok := registration.(*encryptedEventRegistration); ok {
+		r.stop()
+		registration = r.registration
+	}
 	c.contract.Unregister(registration)
 }
@@ -7,12 +7,16 @@ SPDX-License-Identifier: Apache-2.0
 package gateway
 
 import (
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/fakes"
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/internal"
 	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	lb "github.com/hyperledger/fabric-protos-go/peer/lifecycle"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
 	"github.com/stretchr/testify/assert"
 )
@@ -48,11 +52,38 @@ func TestNewContract(t *testing.T) {
 	mockNetwork := &fakes.Network{}
 	mockNetwork.GetContractReturns(&gateway.Contract{})
 
-	// should try to get chaincode and ercc contracts
+	// should try to get chaincode, ercc and lifecycle contracts
 	contract := GetContract(mockNetwork, chaincodeID)
 	assert.NotNil(t, contract)
 	assert.Equal(t, chaincodeID, mockNetwork.GetContractArgsForCall(0))
 	assert.Equal(t, "ercc", mockNetwork.GetContractArgsForCall(1))
+	assert.Equal(t, "_lifecycle", mockNetwork.GetContractArgsForCall(2))
+}
+
+func TestNewContractWithRequestBinding(t *testing.T) {
+	mockNetwork := &fakes.Network{}
+	mockNetwork.GetContractReturns(&gateway.Contract{})
+
+	ep := GetContract(mockNetwork, "myChaincode").(*contractState).ep.(*crypto.CachingEncryptionProvider)
+	assert.Empty(t, ep.ChaincodeId)
+
+	ep = GetContract(mockNetwork, "myChaincode", WithRequestBinding("mychannel")).(*contractState).ep.(*crypto.CachingEncryptionProvider)
+	assert.Equal(t, "mychannel", ep.ChannelId)
+	assert.Equal(t, "myChaincode", ep.ChaincodeId)
+}
+
+func TestNewContractWithPadding(t *testing.T) {
+	mockNetwork := &fakes.Network{}
+	mockNetwork.GetContractReturns(&gateway.Contract{})
+
+	ep := GetContract(mockNetwork, "myChaincode").(*contractState).ep.(*crypto.CachingEncryptionProvider)
+	assert.Nil(t, ep.RequestPadding)
+	assert.Nil(t, ep.ResponsePadding)
+
+	requestPadding := crypto.BucketPadding(1024, 4096)
+	ep = GetContract(mockNetwork, "myChaincode", WithPadding(requestPadding, nil)).(*contractState).ep.(*crypto.CachingEncryptionProvider)
+	assert.Equal(t, requestPadding, ep.RequestPadding)
+	assert.Nil(t, ep.ResponsePadding)
 }
 
 func TestContractName(t *testing.T) {
@@ -114,6 +145,7 @@ func TestContractEvaluateTransactionSuccess(t *testing.T) {
 	name, f := mockContract.CreateTransactionArgsForCall(0)
 	assert.Equal(t, "__invoke", name)
 	assert.NotNil(t, f)
+	assert.Equal(t, []string{"peer1"}, f)
 
 	// check that CreateTransaction was invoked only once
 	assert.Equal(t, 1, mockContract.CreateTransactionCallCount())
@@ -196,15 +228,131 @@ func TestContractEvaluateAndSubmitTransactionFail(t *testing.T) {
 	assert.Nil(t, resp)
 	assert.Error(t, err)
 
-	// see what happens if __endorse fails
-	txn.EvaluateReturnsOnCall(0, expectedResult, nil)
+	// see what happens if __invoke fails
+	mockERCC.EvaluateTransactionReturns([]byte("peer1"), nil)
+	txn.EvaluateReturnsOnCall(0, nil, fmt.Errorf("enclave failed"))
 	mockContract.CreateTransactionReturns(txn, nil)
+
+	// failed, the transaction was not executed
+	resp, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
+	assert.Nil(t, resp)
+	assert.True(t, errors.As(err, new(*ExecutionError)))
+	assert.False(t, errors.As(err, new(*CommitError)))
+	assert.Equal(t, 0, mockContract.SubmitTransactionCallCount())
+
+	// see what happens if __endorse fails
+	txn.EvaluateReturnsOnCall(1, expectedResult, nil)
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		return input, nil
+	})
 	mockContract.SubmitTransactionReturns(nil, fmt.Errorf("endorse failed"))
 
-	// failed
+	// failed, but the transaction was executed
 	resp, err = contract.SubmitTransaction("someFunction", "arg1", "arg2")
 	assert.Nil(t, resp)
-	assert.Error(t, err)
+	assert.EqualError(t, err, "enclave executed the transaction but commit failed: endorse failed")
+	var commitErr *CommitError
+	assert.True(t, errors.As(err, &commitErr))
+	assert.False(t, errors.As(err, new(*ExecutionError)))
+	assert.Equal(t, expectedResult, commitErr.Pending.Result())
+	assert.Equal(t, expectedResult, commitErr.Pending.SignedResponse())
+}
+
+func TestContractResubmitTransaction(t *testing.T) {
+	signedResponse := []byte("signedResponse")
+
+	invokeTx := &fakes.Transaction{}
+	invokeTx.EvaluateReturns(signedResponse, nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(invokeTx, nil)
+	mockContract.SubmitTransactionReturnsOnCall(0, nil, fmt.Errorf("endorse failed"))
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1"), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.RevealReturns([]byte("result"), nil)
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := &contractState{
+		contract: mockContract,
+		ercc:     mockERCC,
+		ep:       mockEncryptionProvider,
+	}
+
+	resp, err := contract.SubmitTransaction("someFunction", "arg1")
+	assert.Nil(t, resp)
+	var commitErr *CommitError
+	assert.True(t, errors.As(err, &commitErr))
+	assert.Equal(t, []byte("result"), commitErr.Pending.Result())
+
+	// resubmission only calls __endorse with the same signed response
+	assert.NoError(t, contract.ResubmitTransaction(commitErr.Pending))
+	assert.Equal(t, 1, invokeTx.EvaluateCallCount())
+	assert.Equal(t, 2, mockContract.SubmitTransactionCallCount())
+	for i := 0; i < 2; i++ {
+		name, args := mockContract.SubmitTransactionArgsForCall(i)
+		assert.Equal(t, "__endorse", name)
+		assert.Equal(t, []string{string(signedResponse)}, args)
+	}
+
+	// a restored pending transaction can be resubmitted as well
+	assert.NoError(t, contract.ResubmitTransaction(NewPendingTransaction(signedResponse)))
+	assert.Equal(t, 3, mockContract.SubmitTransactionCallCount())
+
+	// failed resubmission returns a CommitError again
+	mockContract.SubmitTransactionReturnsOnCall(3, nil, fmt.Errorf("mvcc conflict"))
+	err = contract.ResubmitTransaction(commitErr.Pending)
+	assert.True(t, errors.As(err, &commitErr))
+	assert.Equal(t, []byte("result"), commitErr.Pending.Result())
+
+	assert.Error(t, contract.ResubmitTransaction(nil))
+	assert.Error(t, contract.ResubmitTransaction(NewPendingTransaction(nil)))
+	assert.Equal(t, 4, mockContract.SubmitTransactionCallCount())
+
+	// a transient __endorse failure is retried without executing the transaction again
+	contract.endorseRetries = 2
+	contract.initialBackoff = time.Millisecond
+	contract.maxBackoff = time.Millisecond
+	mockContract.SubmitTransactionReturnsOnCall(4, nil, &internal.TransientError{Err: fmt.Errorf("connection failed")})
+	mockContract.SubmitTransactionReturnsOnCall(5, nil, &internal.TransientError{Err: fmt.Errorf("connection failed")})
+	resp, err = contract.SubmitTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+	assert.Equal(t, 2, invokeTx.EvaluateCallCount())
+	assert.Equal(t, 7, mockContract.SubmitTransactionCallCount())
+
+	// other failures, e.g., an invalid enclave signature or an mvcc conflict, are not retried
+	mockContract.SubmitTransactionReturnsOnCall(7, nil, fmt.Errorf("invalid enclave signature"))
+	err = contract.ResubmitTransaction(commitErr.Pending)
+	assert.True(t, errors.As(err, &commitErr))
+	assert.Equal(t, 8, mockContract.SubmitTransactionCallCount())
+
+	// retries are limited to endorseRetries
+	for i := 8; i < 11; i++ {
+		mockContract.SubmitTransactionReturnsOnCall(i, nil, &internal.TransientError{Err: fmt.Errorf("connection failed")})
+	}
+	err = contract.ResubmitTransaction(commitErr.Pending)
+	assert.True(t, errors.As(err, &commitErr))
+	assert.True(t, errors.As(err, new(*internal.TransientError)))
+	assert.Equal(t, 11, mockContract.SubmitTransactionCallCount())
+}
+
+func TestNewContractWithEndorseRetries(t *testing.T) {
+	mockNetwork := &fakes.Network{}
+	mockNetwork.GetContractReturns(&gateway.Contract{})
+
+	contract := GetContract(mockNetwork, "myChaincode").(*contractState)
+	assert.Equal(t, 0, contract.endorseRetries)
+	assert.Equal(t, DefaultEndorseInitialBackoff, contract.initialBackoff)
+	assert.Equal(t, DefaultEndorseMaxBackoff, contract.maxBackoff)
+
+	contract = GetContract(mockNetwork, "myChaincode", WithEndorseRetries(3, time.Second, time.Minute)).(*contractState)
+	assert.Equal(t, 3, contract.endorseRetries)
+	assert.Equal(t, time.Second, contract.initialBackoff)
+	assert.Equal(t, time.Minute, contract.maxBackoff)
 }
 
 func TestContractSubmitTransaction(t *testing.T) {
@@ -256,6 +404,145 @@ func TestContractSubmitTransaction(t *testing.T) {
 	assert.Equal(t, 1, mockContract.SubmitTransactionCallCount())
 }
 
+func TestContractPeerEndpointsCache(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("result"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1,peer2"), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := &contractState{
+		contract: mockContract,
+		ercc:     mockERCC,
+		ep:       mockEncryptionProvider,
+		cacheTTL: time.Hour,
+	}
+
+	// endpoints are queried only once
+	_, err := contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mockERCC.EvaluateTransactionCallCount())
+
+	// a failing __invoke invalidates the cache
+	txn.EvaluateReturns(nil, fmt.Errorf("enclave failed"))
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.Error(t, err)
+	txn.EvaluateReturns([]byte("result"), nil)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, mockERCC.EvaluateTransactionCallCount())
+
+	// expired endpoints are queried again
+	contract.peerExpiry = time.Now().Add(-time.Second)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, mockERCC.EvaluateTransactionCallCount())
+}
+
+// observingEncryptionProvider records the chaincode sequences and invalidations seen by a caching encryption provider
+type observingEncryptionProvider struct {
+	fakes.EncryptionProvider
+	sequences     []int64
+	invalidations int
+}
+
+func (p *observingEncryptionProvider) ObserveSequence(sequence int64) {
+	p.sequences = append(p.sequences, sequence)
+}
+
+func (p *observingEncryptionProvider) Invalidate() {
+	p.invalidations++
+}
+
+func TestContractSequenceChange(t *testing.T) {
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns([]byte("result"), nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns("myChaincode")
+	mockContract.CreateTransactionReturns(txn, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1,peer2"), nil)
+
+	definition := func(sequence int64) []byte {
+		b, err := proto.Marshal(&lb.QueryChaincodeDefinitionResult{Sequence: sequence})
+		assert.NoError(t, err)
+		return b
+	}
+	mockLifecycle := &fakes.Contract{}
+	mockLifecycle.EvaluateTransactionReturns(definition(1), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	ep := &observingEncryptionProvider{}
+	ep.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := &contractState{
+		contract:  mockContract,
+		ercc:      mockERCC,
+		lifecycle: mockLifecycle,
+		ep:        ep,
+		cacheTTL:  time.Hour,
+	}
+
+	// the committed definition is queried once per cacheTTL
+	_, err := contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	_, err = contract.SubmitTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mockLifecycle.EvaluateTransactionCallCount())
+	name, args := mockLifecycle.EvaluateTransactionArgsForCall(0)
+	assert.Equal(t, "QueryChaincodeDefinition", name)
+	queryArgs := &lb.QueryChaincodeDefinitionArgs{}
+	assert.NoError(t, proto.Unmarshal([]byte(args[0]), queryArgs))
+	assert.Equal(t, "myChaincode", queryArgs.Name)
+	assert.Equal(t, []int64{1}, ep.sequences)
+	assert.Equal(t, 1, mockERCC.EvaluateTransactionCallCount())
+
+	// an unchanged sequence keeps the cached peer endpoints
+	contract.sequenceExpiry = time.Now().Add(-time.Second)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, mockLifecycle.EvaluateTransactionCallCount())
+	assert.Equal(t, 1, mockERCC.EvaluateTransactionCallCount())
+
+	// an upgrade drops the cached peer endpoints and is passed to the encryption provider
+	mockLifecycle.EvaluateTransactionReturns(definition(2), nil)
+	contract.sequenceExpiry = time.Now().Add(-time.Second)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 1, 2}, ep.sequences)
+	assert.Equal(t, 2, mockERCC.EvaluateTransactionCallCount())
+
+	// a failing query is not fatal
+	mockLifecycle.EvaluateTransactionReturns(nil, fmt.Errorf("access denied"))
+	contract.sequenceExpiry = time.Now().Add(-time.Second)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 1, 2}, ep.sequences)
+
+	// a response that cannot be revealed invalidates the cached state
+	mockLifecycle.EvaluateTransactionReturns(definition(2), nil)
+	mockEncryptionContext.RevealReturns(nil, fmt.Errorf("invalid signature"))
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.True(t, errors.As(err, new(*ExecutionError)))
+	assert.Equal(t, 1, ep.invalidations)
+	mockEncryptionContext.RevealReturns([]byte("result"), nil)
+	_, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, mockERCC.EvaluateTransactionCallCount())
+	assert.Equal(t, 5, mockLifecycle.EvaluateTransactionCallCount())
+}
+
 func TestContractRegisterEvent(t *testing.T) {
 	// just check that it is correctly wired
 	mockContract := &fakes.Contract{}
@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+// ExecutionError is returned if the chaincode enclave did not successfully execute a transaction, i.e., `__invoke`
+// failed at all enclave peers or the enclave response could not be verified and decrypted.
+// As nothing has been submitted for commit, the transaction can safely be submitted again.
+type ExecutionError struct {
+	Err error
+}
+
+func (e *ExecutionError) Error() string {
+	return "enclave execution failed: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying error (see github.com/pkg/errors)
+func (e *ExecutionError) Cause() error {
+	return e.Err
+}
+
+// CommitError is returned by SubmitTransaction and SubmitTransactionBatch if the enclave executed the transaction,
+// but `__endorse`, which validates the signed enclave response and commits its read/writeset, failed.
+// The signed enclave response and the results are kept in Pending, so that the transaction can be resubmitted with
+// Contract.ResubmitTransaction without executing the chaincode again. As the commit outcome of a failed `__endorse`
+// may be unknown, e.g., after a timeout, a resubmission may commit the transaction twice; see
+// Contract.ResubmitTransaction for when a resubmission is safe.
+type CommitError struct {
+	Err     error
+	Pending *PendingTransaction
+}
+
+func (e *CommitError) Error() string {
+	return "enclave executed the transaction but commit failed: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying error (see github.com/pkg/errors)
+func (e *CommitError) Cause() error {
+	return e.Err
+}
+
+// PendingTransaction is a transaction which has been executed by the enclave but not committed yet
+type PendingTransaction struct {
+	signedResponse []byte
+	results        [][]byte
+}
+
+// NewPendingTransaction restores a pending transaction from the signed enclave response, e.g., as persisted by an
+// application to resubmit the transaction later
+func NewPendingTransaction(signedResponse []byte, results ...[]byte) *PendingTransaction {
+	return &PendingTransaction{
+		signedResponse: signedResponse,
+		results:        results,
+	}
+}
+
+// SignedResponse returns the (base64-encoded) signed response of the enclave, which is passed to `__endorse`
+func (p *PendingTransaction) SignedResponse() []byte {
+	return p.signedResponse
+}
+
+// Result returns the result of the transaction function or, for a batch, of its first request
+func (p *PendingTransaction) Result() []byte {
+	if len(p.results) == 0 {
+		return nil
+	}
+	return p.results[0]
+}
+
+// Results returns the results of the transaction functions of a batch, in the order of the requests
+func (p *PendingTransaction) Results() [][]byte {
+	return p.results
+}
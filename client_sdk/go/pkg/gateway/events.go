@@ -0,0 +1,114 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"sync"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+)
+
+func (c *contractState) RegisterEncryptedEvent(eventFilter string, eventKey []byte) (fab.Registration, <-chan *fab.CCEvent, error) {
+	registration, events, err := c.contract.RegisterEvent(eventFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decrypter := &crypto.EventDecrypter{
+		CSP:          crypto.GetDefaultCSP(),
+		EventKey:     eventKey,
+		GetEnclaveVk: c.enclaveVkLookup(),
+	}
+
+	encryptedRegistration := &encryptedEventRegistration{registration: registration, done: make(chan struct{})}
+	decryptedEvents := make(chan *fab.CCEvent)
+	go func() {
+		defer close(decryptedEvents)
+		for {
+			select {
+			case <-encryptedRegistration.done:
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				payload, err := decrypter.Decrypt(event.EventName, event.Payload)
+				if err != nil {
+					logger.Warningf("dropping event %s of tx %s: %s", event.EventName, event.TxID, err)
+					continue
+				}
+
+				decrypted := *event
+				decrypted.Payload = payload
+				select {
+				case decryptedEvents <- &decrypted:
+				case <-encryptedRegistration.done:
+					return
+				}
+			}
+		}
+	}()
+
+	return encryptedRegistration, decryptedEvents, nil
+}
+
+// encryptedEventRegistration is the event registration handle returned by RegisterEncryptedEvent. Unregistering it
+// stops the decryption of events, even if the consumer stopped reading the event channel.
+type encryptedEventRegistration struct {
+	registration fab.Registration
+	done         chan struct{}
+	once         sync.Once
+}
+
+func (r *encryptedEventRegistration) stop() {
+	r.once.Do(func() {
+		close(r.done)
+	})
+}
+
+// enclaveVkLookup returns a function which queries ERCC for the enclave_vk of a given enclave.
+// As the enclave_vk of an enclave never changes, the result is cached.
+func (c *contractState) enclaveVkLookup() func(enclaveId string) ([]byte, error) {
+	var mutex sync.Mutex
+	enclaveVks := make(map[string][]byte)
+
+	return func(enclaveId string) ([]byte, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if vk, ok := enclaveVks[enclaveId]; ok {
+			return vk, nil
+		}
+
+		resp, err := c.ercc.EvaluateTransaction("queryEnclaveCredentials", c.Name(), enclaveId)
+		if err != nil {
+			return nil, err
+		}
+
+		credentials, err := utils.UnmarshalCredentials(string(resp))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid credentials")
+		}
+
+		attestedData := &protos.AttestedData{}
+		if err := ptypes.UnmarshalAny(credentials.SerializedAttestedData, attestedData); err != nil {
+			return nil, errors.Wrap(err, "invalid attested data")
+		}
+
+		if utils.GetEnclaveId(attestedData) != enclaveId {
+			return nil, errors.Errorf("credentials do not belong to enclave %s", enclaveId)
+		}
+
+		enclaveVks[enclaveId] = attestedData.EnclaveVk
+		return attestedData.EnclaveVk, nil
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// newEventFixture returns an event key, the credentials and id of an enclave, and a function to create events encrypted
+// with the event key and signed by the enclave
+func newEventFixture(t *testing.T) ([]byte, string, string, func(name string, msg []byte) *fab.CCEvent) {
+	csp := crypto.GetDefaultCSP()
+	enclaveVk, enclaveSk, err := csp.NewECDSAKeys()
+	assert.NoError(t, err)
+	eventKey, err := csp.NewSymmetricKey()
+	assert.NoError(t, err)
+
+	attestedData := &protos.AttestedData{EnclaveVk: enclaveVk}
+	serializedAttestedData, err := ptypes.MarshalAny(attestedData)
+	assert.NoError(t, err)
+	credentials := utils.MarshallProto(&protos.Credentials{SerializedAttestedData: serializedAttestedData})
+
+	newEvent := func(name string, msg []byte) *fab.CCEvent {
+		encryptedPayload, err := csp.EncryptMessage(eventKey, msg)
+		assert.NoError(t, err)
+		responseBytes := protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{
+			EnclaveId:      utils.GetEnclaveId(attestedData),
+			ChaincodeEvent: &protos.EncryptedChaincodeEvent{EventName: name, EncryptedPayload: encryptedPayload},
+		})
+		signature, err := csp.SignMessage(enclaveSk, responseBytes)
+		assert.NoError(t, err)
+		payload := protoutil.MarshalOrPanic(&protos.SignedChaincodeResponseMessage{
+			ChaincodeResponseMessage: responseBytes,
+			Signature:                signature,
+		})
+		return &fab.CCEvent{TxID: "someTx", ChaincodeID: "myChaincode", EventName: name, Payload: payload}
+	}
+
+	return eventKey, credentials, utils.GetEnclaveId(attestedData), newEvent
+}
+
+func TestContractRegisterEncryptedEvent(t *testing.T) {
+	eventKey, credentials, enclaveId, newEvent := newEventFixture(t)
+
+	events := make(chan *fab.CCEvent, 3)
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns("myChaincode")
+	mockContract.RegisterEventReturns(nil, events, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte(credentials), nil)
+
+	contract := &contractState{contract: mockContract, ercc: mockERCC}
+	_, decryptedEvents, err := contract.RegisterEncryptedEvent("someEvent", eventKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "someEvent", mockContract.RegisterEventArgsForCall(0))
+
+	// a valid event, an invalid one that is dropped, and another valid one
+	events <- newEvent("someEvent", []byte("first"))
+	events <- &fab.CCEvent{EventName: "someEvent", Payload: []byte("not an encrypted event")}
+	events <- newEvent("someEvent", []byte("second"))
+	close(events)
+
+	var received []string
+	for e := range decryptedEvents {
+		assert.Equal(t, "someEvent", e.EventName)
+		assert.Equal(t, "someTx", e.TxID)
+		received = append(received, string(e.Payload))
+	}
+	assert.Equal(t, []string{"first", "second"}, received)
+
+	// enclave credentials are only queried once
+	assert.Equal(t, 1, mockERCC.EvaluateTransactionCallCount())
+	f, args := mockERCC.EvaluateTransactionArgsForCall(0)
+	assert.Equal(t, "queryEnclaveCredentials", f)
+	assert.Equal(t, []string{"myChaincode", enclaveId}, args)
+}
+
+func TestContractUnregisterEncryptedEvent(t *testing.T) {
+	eventKey, credentials, _, newEvent := newEventFixture(t)
+
+	type registration struct{}
+	innerRegistration := &registration{}
+	events := make(chan *fab.CCEvent, 1)
+	mockContract := &fakes.Contract{}
+	mockContract.RegisterEventReturns(innerRegistration, events, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte(credentials), nil)
+
+	contract := &contractState{contract: mockContract, ercc: mockERCC}
+	reg, decryptedEvents, err := contract.RegisterEncryptedEvent("someEvent", eventKey)
+	assert.NoError(t, err)
+
+	// the consumer does not read the decrypted event, but unregistering still stops the decryption
+	events <- newEvent("someEvent", []byte("unread"))
+	assert.Eventually(t, func() bool { return len(events) == 0 }, time.Second, time.Millisecond)
+	contract.Unregister(reg)
+	assert.Equal(t, 1, mockContract.UnregisterCallCount())
+	assert.Equal(t, innerRegistration, mockContract.UnregisterArgsForCall(0))
+
+	// the pending event may or may not be delivered, but the channel is closed
+	timeout := time.After(time.Second)
+	for closed := false; !closed; {
+		select {
+		case _, ok := <-decryptedEvents:
+			closed = !ok
+		case <-timeout:
+			assert.FailNow(t, "event channel not closed")
+		}
+	}
+
+	// unregistering twice is fine
+	contract.Unregister(reg)
+}
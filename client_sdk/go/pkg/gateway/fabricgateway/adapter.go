@@ -0,0 +1,205 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabricgateway
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/internal"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/gateway"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chaincodeResponsePattern matches the message of the error details the gateway peer returns for an endorser
+// response with an error status, and captures the status
+var chaincodeResponsePattern = regexp.MustCompile(`^chaincode response (\d+), `)
+
+// Network interface that is needed by the FPC contract implementation when using the Fabric Gateway client
+// (github.com/hyperledger/fabric-gateway). It is implemented by client.Network.
+type Network interface {
+	GetContract(chaincodeName string) *client.Contract
+	ChaincodeEvents(ctx context.Context, chaincodeName string, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error)
+}
+
+// gatewayContract interface that is needed by the contractAdapter. It is implemented by client.Contract.
+type gatewayContract interface {
+	ChaincodeName() string
+	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	SubmitTransaction(name string, args ...string) ([]byte, error)
+	Evaluate(transactionName string, options ...client.ProposalOption) ([]byte, error)
+}
+
+// contractAdapter wraps a client.Contract of the Fabric Gateway client with the internal.Contract interface
+type contractAdapter struct {
+	Contract gatewayContract
+	Network  Network
+
+	// EndpointMSP returns the MSP ID of the organization of the peer with the given endpoint.
+	// The Fabric Gateway does not allow to target individual peers but only organizations. Hence, the endorsing
+	// peers passed to CreateTransaction are translated to their organizations using EndpointMSP. If EndpointMSP is
+	// nil, endorsing peers cannot be targeted and CreateTransaction fails if any are given.
+	EndpointMSP func(endpoint string) string
+}
+
+func (f *contractAdapter) Name() string {
+	return f.Contract.ChaincodeName()
+}
+
+func (f *contractAdapter) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return f.Contract.EvaluateTransaction(name, args...)
+}
+
+func (f *contractAdapter) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	resp, err := f.Contract.SubmitTransaction(name, args...)
+	if err != nil && isTransientError(err) {
+		return nil, &internal.TransientError{Err: err}
+	}
+	return resp, err
+}
+
+func (f *contractAdapter) CreateTransaction(name string, endorsingPeers ...string) (internal.Transaction, error) {
+	txn := &gatewayTransaction{contract: f.Contract, name: name}
+	if len(endorsingPeers) == 0 {
+		return txn, nil
+	}
+	if f.EndpointMSP == nil {
+		// otherwise, the gateway peer would choose the endorsers, and a failover to another peer would be sent to the
+		// same enclave again
+		return nil, errors.Errorf("cannot target peers %v without EndpointMSP", endorsingPeers)
+	}
+
+	seen := make(map[string]bool)
+	for _, peer := range endorsingPeers {
+		mspID := f.EndpointMSP(peer)
+		if mspID == "" {
+			return nil, errors.Errorf("no organization known for peer %s", peer)
+		}
+		if !seen[mspID] {
+			seen[mspID] = true
+			txn.organizations = append(txn.organizations, mspID)
+		}
+	}
+	return txn, nil
+}
+
+// RegisterEvent registers for chaincode events using the chaincode events service of the Fabric Gateway.
+// Only events whose name matches the eventFilter (regular expression) are delivered.
+func (f *contractAdapter) RegisterEvent(eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
+	filter, err := regexp.Compile(eventFilter)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid event filter %s", eventFilter)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := f.Network.ChaincodeEvents(ctx, f.Contract.ChaincodeName())
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	ccEvents := make(chan *fab.CCEvent)
+	go func() {
+		defer close(ccEvents)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if !filter.MatchString(event.EventName) {
+					continue
+				}
+				select {
+				case ccEvents <- &fab.CCEvent{
+					TxID:        event.TransactionID,
+					ChaincodeID: event.ChaincodeName,
+					EventName:   event.EventName,
+					Payload:     event.Payload,
+					BlockNumber: event.BlockNumber,
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return &gatewayRegistration{cancel: cancel}, ccEvents, nil
+}
+
+func (f *contractAdapter) Unregister(registration fab.Registration) {
+	if r, ok := registration.(*gatewayRegistration); ok {
+		r.cancel()
+	}
+}
+
+// gatewayRegistration is the event registration handle returned by contractAdapter.RegisterEvent
+type gatewayRegistration struct {
+	cancel context.CancelFunc
+}
+
+// gatewayTransaction implements the Transaction interface for the Fabric Gateway client
+type gatewayTransaction struct {
+	contract      gatewayContract
+	name          string
+	organizations []string
+}
+
+func (t *gatewayTransaction) Evaluate(args ...string) ([]byte, error) {
+	options := []client.ProposalOption{client.WithArguments(args...)}
+	if len(t.organizations) > 0 {
+		options = append(options, client.WithEndorsingOrganizations(t.organizations...))
+	}
+	resp, err := t.contract.Evaluate(t.name, options...)
+	if err != nil && isChaincodeErrorResponse(err) {
+		return nil, &internal.ChaincodeError{Err: err}
+	}
+	return resp, err
+}
+
+// isChaincodeErrorResponse returns true if the error details of a gateway error report utils.ChaincodeErrorStatus
+// as the response status of an endorser
+func isChaincodeErrorResponse(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	for _, detail := range st.Details() {
+		errorDetail, ok := detail.(*gateway.ErrorDetail)
+		if !ok {
+			continue
+		}
+		match := chaincodeResponsePattern.FindStringSubmatch(errorDetail.GetMessage())
+		if match == nil {
+			continue
+		}
+		if code, err := strconv.ParseInt(match[1], 10, 32); err == nil && int32(code) == utils.ChaincodeErrorStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransientError returns true if the gateway was unavailable or could not reach the endorsers or the orderer, i.e.,
+// the transaction has not been ordered. A failure to obtain the commit status is not transient, as the transaction
+// may have been committed.
+func isTransientError(err error) bool {
+	if errors.As(err, new(*client.CommitStatusError)) || errors.As(err, new(*client.CommitError)) {
+		return false
+	}
+	st, ok := status.FromError(err)
+	return ok && st.Code() == codes.Unavailable
+}
@@ -0,0 +1,335 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabricgateway
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/internal"
+	"github.com/hyperledger/fabric-protos-go/gateway"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type contractStub struct {
+	evaluateName    string
+	evaluateOptions []client.ProposalOption
+	evaluateErr     error
+	submitErr       error
+}
+
+func (c *contractStub) ChaincodeName() string {
+	return "myChaincode"
+}
+
+func (c *contractStub) EvaluateTransaction(name string, args ...string) ([]byte, error) {
+	return []byte("evaluated"), nil
+}
+
+func (c *contractStub) SubmitTransaction(name string, args ...string) ([]byte, error) {
+	if c.submitErr != nil {
+		return nil, c.submitErr
+	}
+	return []byte("submitted"), nil
+}
+
+func (c *contractStub) Evaluate(transactionName string, options ...client.ProposalOption) ([]byte, error) {
+	c.evaluateName = transactionName
+	c.evaluateOptions = options
+	if c.evaluateErr != nil {
+		return nil, c.evaluateErr
+	}
+	return []byte("result"), nil
+}
+
+type networkStub struct {
+	events        chan *client.ChaincodeEvent
+	chaincodeName string
+}
+
+func (n *networkStub) GetContract(chaincodeName string) *client.Contract {
+	return nil
+}
+
+func (n *networkStub) ChaincodeEvents(ctx context.Context, chaincodeName string, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error) {
+	if n.events == nil {
+		return nil, fmt.Errorf("event service not available")
+	}
+	n.chaincodeName = chaincodeName
+	return n.events, nil
+}
+
+func TestContractAdapter(t *testing.T) {
+	contract := &contractStub{}
+	adapter := &contractAdapter{Contract: contract}
+
+	assert.Equal(t, "myChaincode", adapter.Name())
+
+	resp, err := adapter.EvaluateTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("evaluated"), resp)
+
+	resp, err = adapter.SubmitTransaction("someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("submitted"), resp)
+
+	// without peers, the endorsers are chosen by the gateway peer
+	txn, err := adapter.CreateTransaction("__invoke")
+	assert.NoError(t, err)
+	resp, err = txn.Evaluate("encryptedRequest")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+	assert.Equal(t, "__invoke", contract.evaluateName)
+	assert.Len(t, contract.evaluateOptions, 1)
+
+	// without EndpointMSP, peers cannot be targeted
+	txn, err = adapter.CreateTransaction("__invoke", "peer1:7051")
+	assert.Nil(t, txn)
+	assert.EqualError(t, err, "cannot target peers [peer1:7051] without EndpointMSP")
+}
+
+func TestContractAdapterEndpointMSP(t *testing.T) {
+	msps := map[string]string{"peer1:7051": "Org1MSP", "peer2:7051": "Org1MSP", "peer3:7051": "Org2MSP"}
+	contract := &contractStub{}
+	adapter := &contractAdapter{
+		Contract: contract,
+		EndpointMSP: func(endpoint string) string {
+			return msps[endpoint]
+		},
+	}
+
+	txn, err := adapter.CreateTransaction("__invoke", "peer1:7051", "peer2:7051", "peer3:7051")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Org1MSP", "Org2MSP"}, txn.(*gatewayTransaction).organizations)
+
+	_, err = txn.Evaluate("encryptedRequest")
+	assert.NoError(t, err)
+	assert.Len(t, contract.evaluateOptions, 2)
+
+	// unknown peer
+	txn, err = adapter.CreateTransaction("__invoke", "unknown:7051")
+	assert.Nil(t, txn)
+	assert.EqualError(t, err, "no organization known for peer unknown:7051")
+}
+
+func TestContractAdapterEvents(t *testing.T) {
+	network := &networkStub{}
+	adapter := &contractAdapter{Contract: &contractStub{}, Network: network}
+
+	// invalid filter
+	_, _, err := adapter.RegisterEvent("(")
+	assert.Error(t, err)
+
+	// event service not available
+	_, _, err = adapter.RegisterEvent(".*")
+	assert.EqualError(t, err, "event service not available")
+
+	network.events = make(chan *client.ChaincodeEvent, 2)
+	registration, events, err := adapter.RegisterEvent("^my")
+	assert.NoError(t, err)
+	assert.Equal(t, "myChaincode", network.chaincodeName)
+
+	network.events <- &client.ChaincodeEvent{EventName: "otherEvent", Payload: []byte("other")}
+	network.events <- &client.ChaincodeEvent{
+		BlockNumber:   7,
+		TransactionID: "someTxID",
+		ChaincodeName: "myChaincode",
+		EventName:     "myEvent",
+		Payload:       []byte("payload"),
+	}
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "myEvent", event.EventName)
+		assert.Equal(t, "someTxID", event.TxID)
+		assert.Equal(t, "myChaincode", event.ChaincodeID)
+		assert.Equal(t, uint64(7), event.BlockNumber)
+		assert.Equal(t, []byte("payload"), event.Payload)
+	case <-time.After(time.Second):
+		assert.Fail(t, "no event received")
+	}
+
+	// unregister closes the channel
+	adapter.Unregister(registration)
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		assert.Fail(t, "event channel not closed")
+	}
+}
+
+func gatewayError(t *testing.T, message string) error {
+	st, err := status.New(codes.Aborted, "evaluate call to endorser returned error: "+message).
+		WithDetails(&gateway.ErrorDetail{Address: "peer0.org1.example.com:7051", MspId: "Org1MSP", Message: message})
+	require.NoError(t, err)
+	return st.Err()
+}
+
+func TestGatewayTransactionChaincodeError(t *testing.T) {
+	contract := &contractStub{}
+	adapter := &contractAdapter{Contract: contract}
+	txn, err := adapter.CreateTransaction("__invoke")
+	require.NoError(t, err)
+
+	contract.evaluateErr = gatewayError(t, "chaincode response 400, chaincode invocation failed")
+	_, err = txn.Evaluate("encryptedRequest")
+	assert.ErrorAs(t, err, new(*internal.ChaincodeError))
+
+	// other response statuses are failures of the enclave or the peer
+	contract.evaluateErr = gatewayError(t, "chaincode response 500, enclave not initialized")
+	_, err = txn.Evaluate("encryptedRequest")
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, new(*internal.ChaincodeError)))
+
+	// the status in the message alone is not sufficient
+	contract.evaluateErr = fmt.Errorf("chaincode response 400, chaincode invocation failed")
+	_, err = txn.Evaluate("encryptedRequest")
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, new(*internal.ChaincodeError)))
+}
+
+func TestContractAdapterTransientError(t *testing.T) {
+	contract := &contractStub{}
+	adapter := &contractAdapter{Contract: contract}
+
+	contract.submitErr = status.Error(codes.Unavailable, "failed to connect to orderer")
+	_, err := adapter.SubmitTransaction("__endorse", "signedResponse")
+	assert.ErrorAs(t, err, new(*internal.TransientError))
+
+	// rejected transactions and uncertain commits are not transient
+	for _, submitErr := range []error{
+		status.Error(codes.Aborted, "invalid enclave signature"),
+		&client.CommitError{TransactionID: "txID", Code: peer.TxValidationCode_MVCC_READ_CONFLICT},
+		fmt.Errorf("failed to connect to orderer"),
+	} {
+		contract.submitErr = submitErr
+		_, err = adapter.SubmitTransaction("__endorse", "signedResponse")
+		assert.Error(t, err)
+		assert.False(t, errors.As(err, new(*internal.TransientError)))
+	}
+}
+
+type networkContractsStub struct {
+	contracts []string
+}
+
+func (n *networkContractsStub) GetContract(chaincodeName string) *client.Contract {
+	n.contracts = append(n.contracts, chaincodeName)
+	return &client.Contract{}
+}
+
+func (n *networkContractsStub) ChaincodeEvents(ctx context.Context, chaincodeName string, options ...client.ChaincodeEventsOption) (<-chan *client.ChaincodeEvent, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func TestGetContract(t *testing.T) {
+	chaincodeID := "myChaincode"
+
+	// endpointMSP is required
+	network := &networkContractsStub{}
+	contract, err := GetContract(network, chaincodeID, nil)
+	assert.Nil(t, contract)
+	assert.Error(t, err)
+	assert.Empty(t, network.contracts)
+
+	// should try to get chaincode, ercc and lifecycle contracts
+	contract, err = GetContract(network, chaincodeID, func(string) string { return "Org1MSP" })
+	assert.NoError(t, err)
+	assert.NotNil(t, contract)
+	assert.Equal(t, []string{chaincodeID, "ercc", "_lifecycle"}, network.contracts)
+}
+
+// proposalContractStub builds the proposals passed to Evaluate with an unconnected Fabric Gateway client and decodes
+// them again, so that the test sees the endorsing organizations and the arguments as sent by the gateway client.
+type proposalContractStub struct {
+	contractStub
+	proposals *client.Contract
+
+	organizations []string
+	args          []string
+}
+
+func newProposalContractStub(t *testing.T) *proposalContractStub {
+	id, err := identity.NewX509Identity("Org1MSP", &x509.Certificate{})
+	require.NoError(t, err)
+	// the connection is never used as proposals are only built but not sent
+	conn, err := grpc.Dial("localhost:0", grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	gw, err := client.Connect(id, client.WithClientConnection(conn))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = gw.Close() })
+
+	return &proposalContractStub{proposals: gw.GetNetwork("mychannel").GetContract("myChaincode")}
+}
+
+func (c *proposalContractStub) Evaluate(transactionName string, options ...client.ProposalOption) ([]byte, error) {
+	proposal, err := c.proposals.NewProposal(transactionName, options...)
+	if err != nil {
+		return nil, err
+	}
+	proposalBytes, err := proposal.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	proposedTransaction := &gateway.ProposedTransaction{}
+	if err := proto.Unmarshal(proposalBytes, proposedTransaction); err != nil {
+		return nil, err
+	}
+	proposalProto := &peer.Proposal{}
+	if err := proto.Unmarshal(proposedTransaction.GetProposal().GetProposalBytes(), proposalProto); err != nil {
+		return nil, err
+	}
+	payload := &peer.ChaincodeProposalPayload{}
+	if err := proto.Unmarshal(proposalProto.GetPayload(), payload); err != nil {
+		return nil, err
+	}
+	invocationSpec := &peer.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(payload.GetInput(), invocationSpec); err != nil {
+		return nil, err
+	}
+
+	c.organizations = proposedTransaction.GetEndorsingOrganizations()
+	c.args = nil
+	for _, arg := range invocationSpec.GetChaincodeSpec().GetInput().GetArgs() {
+		c.args = append(c.args, string(arg))
+	}
+	return []byte("result"), nil
+}
+
+func TestContractAdapterProposal(t *testing.T) {
+	msps := map[string]string{"peer1:7051": "Org1MSP", "peer2:7051": "Org2MSP"}
+	contract := newProposalContractStub(t)
+	adapter := &contractAdapter{
+		Contract: contract,
+		EndpointMSP: func(endpoint string) string {
+			return msps[endpoint]
+		},
+	}
+
+	txn, err := adapter.CreateTransaction("__invoke", "peer2:7051")
+	require.NoError(t, err)
+	resp, err := txn.Evaluate("encryptedRequest")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), resp)
+	assert.Equal(t, []string{"Org2MSP"}, contract.organizations)
+	assert.Equal(t, []string{"__invoke", "encryptedRequest"}, contract.args)
+}
@@ -0,0 +1,49 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package fabricgateway creates FPC contracts using the Fabric Gateway client
+// (https://pkg.go.dev/github.com/hyperledger/fabric-gateway/pkg/client) as available with Fabric v2.4 or later.
+//
+// The package is a separate Go module, as the Fabric Gateway client requires more recent versions of Go (1.16), of the
+// Fabric protos and of the Fabric Go SDK than the rest of the FPC Client SDK.
+package fabricgateway
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway"
+	"github.com/pkg/errors"
+)
+
+// GetContract is the factory method for creating FPC Contract objects using the Fabric Gateway client.
+//  Parameters:
+//  network is a network object of the Fabric Gateway client, i.e., client.Network
+//  chaincodeID is the ID of the target chaincode
+//  endpointMSP returns the MSP ID of the organization of a peer endpoint. It is required as the Fabric Gateway can
+//  only target organizations but not individual peers; the FPC enclave peers are targeted by their organization.
+//  options are optional settings, such as gateway.WithCacheTTL or gateway.WithPeerSelectionStrategy
+//
+//  Returns:
+//  The contract object, or an error if endpointMSP is nil
+func GetContract(network Network, chaincodeID string, endpointMSP func(endpoint string) string, options ...gateway.ContractOption) (gateway.Contract, error) {
+	if endpointMSP == nil {
+		return nil, errors.New("endpointMSP is required to invoke the enclaves at the selected peers")
+	}
+	return gateway.NewContract(
+		&contractAdapter{
+			Contract:    network.GetContract(chaincodeID),
+			Network:     network,
+			EndpointMSP: endpointMSP,
+		},
+		&contractAdapter{
+			Contract: network.GetContract("ercc"),
+			Network:  network,
+		},
+		&contractAdapter{
+			Contract: network.GetContract("_lifecycle"),
+			Network:  network,
+		},
+		chaincodeID,
+		options...), nil
+}
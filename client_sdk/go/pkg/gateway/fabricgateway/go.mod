@@ -0,0 +1,32 @@
+module github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/fabricgateway
+
+go 1.16
+
+// Note:
+// - this is a separate module such that the versions required by the
+//   Fabric Gateway client are not forced upon all users of the FPC
+//   Client SDK.
+// - the FPC module is used from this repository.
+// - fabric-gateway requires fabric as v2.x.y+incompatible which would
+//   silently replace the release-2.2 based commit used by FPC; hence the
+//   fabric version is pinned with a replace directive below.
+// - fabric-sdk-go v1.0.0 (its latest tag) does not build against the
+//   fabric-protos-go required by fabric-gateway (discovery types moved),
+//   hence the sdk is pinned to the (untagged) main commit which does.
+
+require (
+	github.com/golang/protobuf v1.5.2
+	github.com/hyperledger/fabric-gateway v1.0.0
+	github.com/hyperledger/fabric-private-chaincode v0.0.0
+	github.com/hyperledger/fabric-protos-go v0.0.0-20211118165945-23d738fc3553
+	github.com/hyperledger/fabric-sdk-go v1.0.1-0.20250425082355-315f4e048501
+	github.com/pkg/errors v0.9.1
+	github.com/stretchr/testify v1.7.0
+	google.golang.org/grpc v1.42.0
+)
+
+// see note above
+replace (
+	github.com/hyperledger/fabric => github.com/hyperledger/fabric v1.4.0-rc1.0.20201118191903-ec81f3e74fa1
+	github.com/hyperledger/fabric-private-chaincode => ../../../../..
+)
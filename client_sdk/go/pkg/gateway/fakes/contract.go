@@ -6,15 +6,14 @@ import (
 
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/internal"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
-	gatewaya "github.com/hyperledger/fabric-sdk-go/pkg/gateway"
 )
 
 type Contract struct {
-	CreateTransactionStub        func(string, ...func(*gatewaya.Transaction) error) (internal.Transaction, error)
+	CreateTransactionStub        func(string, ...string) (internal.Transaction, error)
 	createTransactionMutex       sync.RWMutex
 	createTransactionArgsForCall []struct {
 		arg1 string
-		arg2 []func(*gatewaya.Transaction) error
+		arg2 []string
 	}
 	createTransactionReturns struct {
 		result1 internal.Transaction
@@ -86,12 +85,12 @@ type Contract struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *Contract) CreateTransaction(arg1 string, arg2 ...func(*gatewaya.Transaction) error) (internal.Transaction, error) {
+func (fake *Contract) CreateTransaction(arg1 string, arg2 ...string) (internal.Transaction, error) {
 	fake.createTransactionMutex.Lock()
 	ret, specificReturn := fake.createTransactionReturnsOnCall[len(fake.createTransactionArgsForCall)]
 	fake.createTransactionArgsForCall = append(fake.createTransactionArgsForCall, struct {
 		arg1 string
-		arg2 []func(*gatewaya.Transaction) error
+		arg2 []string
 	}{arg1, arg2})
 	stub := fake.CreateTransactionStub
 	fakeReturns := fake.createTransactionReturns
@@ -112,13 +111,13 @@ func (fake *Contract) CreateTransactionCallCount() int {
 	return len(fake.createTransactionArgsForCall)
 }
 
-func (fake *Contract) CreateTransactionCalls(stub func(string, ...func(*gatewaya.Transaction) error) (internal.Transaction, error)) {
+func (fake *Contract) CreateTransactionCalls(stub func(string, ...string) (internal.Transaction, error)) {
 	fake.createTransactionMutex.Lock()
 	defer fake.createTransactionMutex.Unlock()
 	fake.CreateTransactionStub = stub
 }
 
-func (fake *Contract) CreateTransactionArgsForCall(i int) (string, []func(*gatewaya.Transaction) error) {
+func (fake *Contract) CreateTransactionArgsForCall(i int) (string, []string) {
 	fake.createTransactionMutex.RLock()
 	defer fake.createTransactionMutex.RUnlock()
 	argsForCall := fake.createTransactionArgsForCall[i]
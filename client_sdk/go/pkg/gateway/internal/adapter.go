@@ -1,9 +1,14 @@
-// The internal package contains some helper interfaces and adapters to remove the direct dependency to Fabric Go SDK components.
+// The internal package contains some helper interfaces and adapters to remove the direct dependency to Fabric Go SDK
+// and Fabric Gateway client components.
 package internal
 
 import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
 	"github.com/hyperledger/fabric-sdk-go/pkg/common/providers/fab"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
+	"google.golang.org/grpc/codes"
 )
 
 // Network interface that is needed by the FPC contract implementation
@@ -13,20 +18,56 @@ type Network interface {
 
 // Transaction interface that is needed by the FPC contract implementation
 type Transaction interface {
+	// Evaluate returns a ChaincodeError if the peer responded with utils.ChaincodeErrorStatus
 	Evaluate(args ...string) ([]byte, error)
 }
 
+// ChaincodeError is returned by Transaction.Evaluate if the chaincode, rather than the enclave or the peer, failed,
+// i.e., if the response status of the peer is utils.ChaincodeErrorStatus
+type ChaincodeError struct {
+	Err error
+}
+
+func (e *ChaincodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ChaincodeError) Unwrap() error {
+	return e.Err
+}
+
+// TransientError is returned by Contract.SubmitTransaction if the transaction could not be submitted due to a
+// transient failure, e.g., as the endorsing peers or the orderer were not reachable, so that the transaction has not
+// been ordered and can be submitted again
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *TransientError) Unwrap() error {
+	return e.Err
+}
+
 // Contract interface
 type Contract interface {
 	Name() string
 	EvaluateTransaction(name string, args ...string) ([]byte, error)
+	// SubmitTransaction returns a TransientError if the transaction failed due to a transient failure before it
+	// was ordered
 	SubmitTransaction(name string, args ...string) ([]byte, error)
-	CreateTransaction(name string, opts ...gateway.TransactionOption) (Transaction, error)
+	// CreateTransaction creates a transaction which is endorsed by the given peers (`host:port`); if no peers are
+	// given, the endorsers are chosen by the backend.
+	CreateTransaction(name string, endorsingPeers ...string) (Transaction, error)
 	RegisterEvent(eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error)
 	Unregister(registration fab.Registration)
 }
 
-// ContractAdapter wraps a gateway.Contract with the Contract interface
+// ContractAdapter wraps a gateway.Contract of the Fabric Go SDK with the Contract interface
 type ContractAdapter struct {
 	Contract *gateway.Contract
 }
@@ -40,11 +81,23 @@ func (f *ContractAdapter) EvaluateTransaction(name string, args ...string) ([]by
 }
 
 func (f *ContractAdapter) SubmitTransaction(name string, args ...string) ([]byte, error) {
-	return f.Contract.SubmitTransaction(name, args...)
+	resp, err := f.Contract.SubmitTransaction(name, args...)
+	if err != nil && isTransientStatus(err) {
+		return nil, &TransientError{Err: err}
+	}
+	return resp, err
 }
 
-func (f *ContractAdapter) CreateTransaction(name string, opts ...gateway.TransactionOption) (Transaction, error) {
-	return f.Contract.CreateTransaction(name, opts...)
+func (f *ContractAdapter) CreateTransaction(name string, endorsingPeers ...string) (Transaction, error) {
+	var options []gateway.TransactionOption
+	if len(endorsingPeers) > 0 {
+		options = append(options, gateway.WithEndorsingPeers(endorsingPeers...))
+	}
+	txn, err := f.Contract.CreateTransaction(name, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &sdkTransaction{txn: txn}, nil
 }
 
 func (f *ContractAdapter) RegisterEvent(eventFilter string) (fab.Registration, <-chan *fab.CCEvent, error) {
@@ -54,3 +107,73 @@ func (f *ContractAdapter) RegisterEvent(eventFilter string) (fab.Registration, <
 func (f *ContractAdapter) Unregister(registration fab.Registration) {
 	f.Contract.Unregister(registration)
 }
+
+// sdkTransaction wraps a gateway.Transaction of the Fabric Go SDK with the Transaction interface
+type sdkTransaction struct {
+	txn *gateway.Transaction
+}
+
+func (t *sdkTransaction) Evaluate(args ...string) ([]byte, error) {
+	resp, err := t.txn.Evaluate(args...)
+	if err != nil && isChaincodeErrorStatus(err) {
+		return nil, &ChaincodeError{Err: err}
+	}
+	return resp, err
+}
+
+// isChaincodeErrorStatus returns true if an error of the Fabric Go SDK carries utils.ChaincodeErrorStatus as the
+// response status of a peer
+func isChaincodeErrorStatus(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	switch {
+	case s.Group == status.ChaincodeStatus:
+		return s.Code == utils.ChaincodeErrorStatus
+	case s.Group == status.ClientStatus && s.Code == status.MultipleErrors.ToInt32():
+		for _, detail := range s.Details {
+			if e, ok := detail.(error); ok && isChaincodeErrorStatus(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// transientCodes are the status codes of the Fabric Go SDK for failures that occur before a transaction is ordered
+// and that may disappear on their own. Note that failed validations, e.g., of the enclave signature, MVCC conflicts,
+// and timeouts while waiting for the commit, are not transient, as either a retry fails again or the transaction
+// may have been committed.
+var transientCodes = map[status.Group][]status.Code{
+	status.EndorserClientStatus: {status.ConnectionFailed},
+	status.OrdererClientStatus:  {status.ConnectionFailed},
+	status.OrdererServerStatus:  {status.Code(common.Status_SERVICE_UNAVAILABLE)},
+	status.GRPCTransportStatus:  {status.Code(codes.Unavailable)},
+}
+
+// isTransientStatus returns true if an error of the Fabric Go SDK carries a status of transientCodes or, for multiple
+// errors, if all of them do
+func isTransientStatus(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	if s.Group == status.ClientStatus && s.Code == status.MultipleErrors.ToInt32() {
+		if len(s.Details) == 0 {
+			return false
+		}
+		for _, detail := range s.Details {
+			if e, ok := detail.(error); !ok || !isTransientStatus(e) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, code := range transientCodes[s.Group] {
+		if s.Code == code.ToInt32() {
+			return true
+		}
+	}
+	return false
+}
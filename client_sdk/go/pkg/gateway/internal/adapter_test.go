@@ -0,0 +1,50 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package internal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/multi"
+	"github.com/hyperledger/fabric-sdk-go/pkg/common/errors/status"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsChaincodeErrorStatus(t *testing.T) {
+	chaincodeErr := status.New(status.ChaincodeStatus, 400, "chaincode invocation failed", nil)
+	enclaveErr := status.New(status.ChaincodeStatus, 500, "enclave not initialized", nil)
+
+	assert.True(t, isChaincodeErrorStatus(chaincodeErr))
+	assert.True(t, isChaincodeErrorStatus(errors.Wrap(chaincodeErr, "endorsement failed")))
+	assert.False(t, isChaincodeErrorStatus(enclaveErr))
+	assert.False(t, isChaincodeErrorStatus(fmt.Errorf("chaincode invocation failed")))
+
+	assert.True(t, isChaincodeErrorStatus(multi.Errors{enclaveErr, chaincodeErr}))
+	assert.False(t, isChaincodeErrorStatus(multi.Errors{enclaveErr}))
+}
+
+func TestIsTransientStatus(t *testing.T) {
+	endorserErr := status.New(status.EndorserClientStatus, status.ConnectionFailed.ToInt32(), "connection failed", nil)
+	ordererErr := status.New(status.OrdererServerStatus, int32(common.Status_SERVICE_UNAVAILABLE), "service unavailable", nil)
+	signatureErr := status.New(status.EndorserServerStatus, 500, "invalid enclave signature", nil)
+	mvccErr := status.New(status.EventServerStatus, int32(peer.TxValidationCode_MVCC_READ_CONFLICT), "mvcc read conflict", nil)
+	timeoutErr := status.New(status.ClientStatus, status.Timeout.ToInt32(), "timeout waiting for commit", nil)
+
+	assert.True(t, isTransientStatus(endorserErr))
+	assert.True(t, isTransientStatus(errors.Wrap(ordererErr, "submit failed")))
+	assert.False(t, isTransientStatus(signatureErr))
+	assert.False(t, isTransientStatus(mvccErr))
+	assert.False(t, isTransientStatus(timeoutErr))
+	assert.False(t, isTransientStatus(fmt.Errorf("connection failed")))
+
+	assert.True(t, isTransientStatus(multi.Errors{endorserErr, endorserErr}))
+	assert.False(t, isTransientStatus(multi.Errors{endorserErr, signatureErr}))
+}
@@ -0,0 +1,165 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultBlacklistDuration is the default time a peer endpoint is skipped after an invocation of its enclave failed
+const DefaultBlacklistDuration = 30 * time.Second
+
+// PeerSelectionStrategy determines which FPC enclave peers are used to invoke a FPC chaincode.
+// A Contract tries the endpoints one after another in the returned order until an invocation succeeds.
+type PeerSelectionStrategy interface {
+	// Order returns the given peer endpoints in the order in which they should be tried
+	Order(endpoints []string) []string
+}
+
+// FirstHealthyStrategy always tries the peer endpoints in the order as registered at ERCC.
+// That is, all invocations go to the first healthy enclave and the others are only used for failover.
+func FirstHealthyStrategy() PeerSelectionStrategy {
+	return &firstHealthy{}
+}
+
+type firstHealthy struct{}
+
+func (s *firstHealthy) Order(endpoints []string) []string {
+	return endpoints
+}
+
+// RoundRobinStrategy distributes invocations evenly across all enclave peers.
+func RoundRobinStrategy() PeerSelectionStrategy {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	mutex sync.Mutex
+	next  int
+}
+
+func (s *roundRobin) Order(endpoints []string) []string {
+	if len(endpoints) == 0 {
+		return endpoints
+	}
+
+	s.mutex.Lock()
+	start := s.next % len(endpoints)
+	s.next = start + 1
+	s.mutex.Unlock()
+
+	return append(append([]string{}, endpoints[start:]...), endpoints[:start]...)
+}
+
+// RandomStrategy picks the enclave peers in random order.
+func RandomStrategy() PeerSelectionStrategy {
+	return &random{rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+type random struct {
+	mutex sync.Mutex
+	rnd   *rand.Rand
+}
+
+func (s *random) Order(endpoints []string) []string {
+	ordered := append([]string{}, endpoints...)
+
+	s.mutex.Lock()
+	s.rnd.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	s.mutex.Unlock()
+
+	return ordered
+}
+
+// MSPPreferenceStrategy prefers enclave peers of the given organizations, in the order of mspIDs, over all other
+// peers. The MSP ID of a peer endpoint is determined by endpointMSP. Peers of the same preference are ordered by the
+// fallback strategy; if fallback is nil, FirstHealthyStrategy is used. If endpointMSP is nil, the fallback strategy is
+// returned, as no peer can be attributed to an organization.
+func MSPPreferenceStrategy(endpointMSP func(endpoint string) string, fallback PeerSelectionStrategy, mspIDs ...string) PeerSelectionStrategy {
+	if fallback == nil {
+		fallback = FirstHealthyStrategy()
+	}
+	if endpointMSP == nil {
+		return fallback
+	}
+	return &mspPreference{endpointMSP: endpointMSP, fallback: fallback, mspIDs: mspIDs}
+}
+
+type mspPreference struct {
+	endpointMSP func(endpoint string) string
+	fallback    PeerSelectionStrategy
+	mspIDs      []string
+}
+
+func (s *mspPreference) Order(endpoints []string) []string {
+	buckets := make([][]string, len(s.mspIDs)+1)
+	for _, e := range s.fallback.Order(endpoints) {
+		rank := len(s.mspIDs)
+		mspID := s.endpointMSP(e)
+		for i, preferred := range s.mspIDs {
+			if mspID == preferred {
+				rank = i
+				break
+			}
+		}
+		buckets[rank] = append(buckets[rank], e)
+	}
+
+	var ordered []string
+	for _, b := range buckets {
+		ordered = append(ordered, b...)
+	}
+	return ordered
+}
+
+// peerHealth keeps track of peer endpoints whose enclave invocation failed recently
+type peerHealth struct {
+	mutex     sync.Mutex
+	duration  time.Duration
+	unhealthy map[string]time.Time
+}
+
+func newPeerHealth(duration time.Duration) *peerHealth {
+	return &peerHealth{duration: duration, unhealthy: make(map[string]time.Time)}
+}
+
+// markUnhealthy blacklists the endpoint for the configured duration
+func (h *peerHealth) markUnhealthy(endpoint string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	logger.Debugf("blacklisting peer %s for %s", endpoint, h.duration)
+	h.unhealthy[endpoint] = time.Now().Add(h.duration)
+}
+
+// filter returns all endpoints which are currently not blacklisted. If all endpoints are blacklisted, all endpoints
+// are returned, as it is better to retry a possibly unhealthy enclave than to not try at all.
+func (h *peerHealth) filter(endpoints []string) []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := time.Now()
+	var healthy []string
+	for _, e := range endpoints {
+		if until, ok := h.unhealthy[e]; ok {
+			if now.Before(until) {
+				continue
+			}
+			delete(h.unhealthy, e)
+		}
+		healthy = append(healthy, e)
+	}
+
+	if len(healthy) == 0 {
+		return endpoints
+	}
+	return healthy
+}
@@ -0,0 +1,171 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+var endpoints = []string{"peer1", "peer2", "peer3"}
+
+func TestFirstHealthyStrategy(t *testing.T) {
+	s := FirstHealthyStrategy()
+	assert.Equal(t, endpoints, s.Order(endpoints))
+	assert.Equal(t, endpoints, s.Order(endpoints))
+}
+
+func TestRoundRobinStrategy(t *testing.T) {
+	s := RoundRobinStrategy()
+	assert.Equal(t, []string{"peer1", "peer2", "peer3"}, s.Order(endpoints))
+	assert.Equal(t, []string{"peer2", "peer3", "peer1"}, s.Order(endpoints))
+	assert.Equal(t, []string{"peer3", "peer1", "peer2"}, s.Order(endpoints))
+	assert.Equal(t, []string{"peer1", "peer2", "peer3"}, s.Order(endpoints))
+	assert.Empty(t, s.Order(nil))
+
+	// input is not modified
+	assert.Equal(t, []string{"peer1", "peer2", "peer3"}, endpoints)
+}
+
+func TestRandomStrategy(t *testing.T) {
+	s := RandomStrategy()
+	ordered := s.Order(endpoints)
+	assert.ElementsMatch(t, endpoints, ordered)
+	assert.Equal(t, []string{"peer1", "peer2", "peer3"}, endpoints)
+}
+
+func TestMSPPreferenceStrategy(t *testing.T) {
+	msps := map[string]string{"peer1": "Org1MSP", "peer2": "Org2MSP", "peer3": "Org3MSP"}
+	endpointMSP := func(endpoint string) string {
+		return msps[endpoint]
+	}
+
+	s := MSPPreferenceStrategy(endpointMSP, nil, "Org3MSP", "Org2MSP")
+	assert.Equal(t, []string{"peer3", "peer2", "peer1"}, s.Order(endpoints))
+
+	s = MSPPreferenceStrategy(endpointMSP, RoundRobinStrategy(), "Org2MSP")
+	assert.Equal(t, []string{"peer2", "peer1", "peer3"}, s.Order(endpoints))
+	assert.Equal(t, []string{"peer2", "peer3", "peer1"}, s.Order(endpoints))
+
+	// without endpointMSP, the fallback strategy is used
+	s = MSPPreferenceStrategy(nil, nil, "Org3MSP")
+	assert.Equal(t, endpoints, s.Order(endpoints))
+}
+
+func TestPeerHealth(t *testing.T) {
+	h := newPeerHealth(time.Hour)
+	assert.Equal(t, endpoints, h.filter(endpoints))
+
+	h.markUnhealthy("peer2")
+	assert.Equal(t, []string{"peer1", "peer3"}, h.filter(endpoints))
+
+	// if all are blacklisted, all are returned
+	h.markUnhealthy("peer1")
+	h.markUnhealthy("peer3")
+	assert.Equal(t, endpoints, h.filter(endpoints))
+
+	// blacklisting expires
+	h = newPeerHealth(-time.Second)
+	h.markUnhealthy("peer2")
+	assert.Equal(t, endpoints, h.filter(endpoints))
+}
+
+func TestContractFailover(t *testing.T) {
+	expectedResult := []byte("result")
+
+	failingTxn := &fakes.Transaction{}
+	failingTxn.EvaluateReturns(nil, fmt.Errorf("enclave unavailable"))
+	txn := &fakes.Transaction{}
+	txn.EvaluateReturns(expectedResult, nil)
+
+	mockContract := &fakes.Contract{}
+	mockContract.CreateTransactionReturnsOnCall(0, failingTxn, nil)
+	mockContract.CreateTransactionReturnsOnCall(1, txn, nil)
+	mockContract.CreateTransactionReturnsOnCall(2, txn, nil)
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte("peer1,peer2,peer3"), nil)
+
+	mockEncryptionContext := &fakes.EncryptionContext{}
+	mockEncryptionContext.RevealCalls(func(input []byte) ([]byte, error) {
+		return input, nil
+	})
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(mockEncryptionContext, nil)
+
+	contract := &contractState{
+		contract: mockContract,
+		ercc:     mockERCC,
+		ep:       mockEncryptionProvider,
+		strategy: FirstHealthyStrategy(),
+		health:   newPeerHealth(time.Hour),
+	}
+
+	// first peer fails, second one succeeds
+	resp, err := contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResult, resp)
+	assert.Equal(t, 2, mockContract.CreateTransactionCallCount())
+	assert.Equal(t, 1, failingTxn.EvaluateCallCount())
+	assert.Equal(t, 1, txn.EvaluateCallCount())
+
+	// blacklisted first peer is skipped
+	resp, err = contract.EvaluateTransaction("someFunction")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResult, resp)
+	assert.Equal(t, 3, mockContract.CreateTransactionCallCount())
+	assert.Equal(t, 1, failingTxn.EvaluateCallCount())
+
+	// all peers fail
+	mockContract.CreateTransactionReturns(failingTxn, nil)
+	contract.health = newPeerHealth(time.Hour)
+	resp, err = contract.EvaluateTransaction("someFunction")
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	assert.Equal(t, 4, failingTxn.EvaluateCallCount())
+
+	// a failing chaincode is neither retried at other peers nor blacklisted
+	chaincodeErr := &internal.ChaincodeError{Err: fmt.Errorf("chaincode invocation failed")}
+	failingTxn.EvaluateReturns(nil, chaincodeErr)
+	contract.health = newPeerHealth(time.Hour)
+	resp, err = contract.EvaluateTransaction("someFunction")
+	assert.Nil(t, resp)
+	assert.True(t, errors.As(err, new(*ExecutionError)))
+	assert.True(t, errors.Is(err, chaincodeErr))
+	assert.Equal(t, 5, failingTxn.EvaluateCallCount())
+	assert.Equal(t, []string{"peer1", "peer2", "peer3"}, contract.health.filter([]string{"peer1", "peer2", "peer3"}))
+}
+
+func TestContractNoEnclavePeers(t *testing.T) {
+	mockContract := &fakes.Contract{}
+	mockContract.NameReturns("myChaincode")
+
+	mockERCC := &fakes.Contract{}
+	mockERCC.EvaluateTransactionReturns([]byte(""), nil)
+
+	mockEncryptionProvider := &fakes.EncryptionProvider{}
+	mockEncryptionProvider.NewEncryptionContextReturns(&fakes.EncryptionContext{}, nil)
+
+	contract := &contractState{
+		contract: mockContract,
+		ercc:     mockERCC,
+		ep:       mockEncryptionProvider,
+		strategy: MSPPreferenceStrategy(nil, nil),
+		health:   newPeerHealth(time.Hour),
+	}
+
+	resp, err := contract.EvaluateTransaction("someFunction")
+	assert.Nil(t, resp)
+	assert.EqualError(t, err, "enclave execution failed: no enclave peers found for chaincode myChaincode")
+	assert.Equal(t, 0, mockContract.CreateTransactionCallCount())
+}
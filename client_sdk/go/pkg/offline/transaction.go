@@ -0,0 +1,286 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package offline enables the invocation of FPC chaincodes by clients whose signing keys are not available to the SDK,
+// for example, keys kept in a HSM.
+//
+// Unlike the gateway package, which hides the FPC transaction flow completely, a Transaction exposes each step of
+// the two-phase `__invoke`/`__endorse` flow. The SDK produces the encrypted chaincode request and the proposals and
+// transaction payloads to be signed; the caller signs them externally and sends the signed messages to the Fabric
+// network using its own means, e.g., a Fabric gateway or the peer and orderer gRPC services.
+//
+// Example:
+//
+//  tx, err := offline.NewTransaction(offline.Config{
+//  	ChannelID:              "mychannel",
+//  	ChaincodeID:            "my-fpc-chaincode",
+//  	Creator:                serializedIdentity,
+//  	ChaincodeEncryptionKey: ccEncryptionKey, // as returned by ercc's queryChaincodeEncryptionKey
+//  }, "myFunction", "arg1", "arg2")
+//
+//  // phase 1: __invoke
+//  proposalBytes, _ := tx.InvokeProposal()
+//  signedInvoke, err := tx.SignedInvokeProposal(hsm.Sign(proposalBytes))
+//  invokeResponse := send(signedInvoke) // to the FPC enclave peer
+//  err = tx.SetInvokeResponse(invokeResponse)
+//  result, err := tx.Result()
+//
+//  // phase 2: __endorse
+//  proposalBytes, _, err = tx.EndorseProposal()
+//  signedEndorse, err := tx.SignedEndorseProposal(hsm.Sign(proposalBytes))
+//  endorseResponses := send(signedEndorse) // to the endorsing peers
+//  payload, err := tx.TransactionPayload(endorseResponses...)
+//  envelope, err := tx.SignedTransaction(hsm.Sign(payload))
+//  broadcast(envelope) // to the ordering service
+//
+// The signatures must be ECDSA signatures (with low S) of the sha256 hash of the signed bytes. For example, a key kept
+// in a PKCS#11 token can sign with the PKCS#11 based CSP (see the crypto package of the SDK):
+//
+//  csp, err := crypto.NewPKCS11Crypto(crypto.PKCS11Opts{Library: lib, Label: label, Pin: pin})
+//  signature, err := csp.SignMessage(keyReference, proposalBytes) // keyReference as returned by csp.NewECDSAKeys
+//
+package offline
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	invokeCMD  = "__invoke"
+	endorseCMD = "__endorse"
+)
+
+// Config contains the parameters of an offline FPC transaction
+type Config struct {
+	// ChannelID is the channel of the FPC chaincode
+	ChannelID string
+
+	// ChaincodeID is the ID of the FPC chaincode
+	ChaincodeID string
+
+	// Creator is the serialized identity (msp.SerializedIdentity) of the external signer
+	Creator []byte
+
+	// ChaincodeEncryptionKey is the base64-encoded chaincode encryption key as returned by ercc's queryChaincodeEncryptionKey
+	ChaincodeEncryptionKey []byte
+
+	// RequestBinding binds the encryption of the request and the response to ChannelID and ChaincodeID.
+	// This requires that the chaincode enclave supports the request nonce of the key transport message.
+	RequestBinding bool
+
+	// RequestPadding and ResponsePadding, if set, pad the request and the response before their encryption, e.g.,
+	// crypto.PowerOfTwoPadding(), so that the size of the ciphertexts does not reveal the size of the arguments and
+	// the response. This requires that the chaincode enclave supports padding.
+	RequestPadding  *crypto.PaddingPolicy
+	ResponsePadding *crypto.PaddingPolicy
+}
+
+// Transaction assembles a FPC transaction whose proposals and envelope are signed outside of the SDK.
+// A Transaction must only be used for a single invocation.
+type Transaction struct {
+	config Config
+	ctx    crypto.EncryptionContext
+
+	chaincodeRequestMessage string
+
+	// note that we keep the serialized proposals, as these are the bytes signed by the creator
+	invokeProposalBytes []byte
+	invokeTxID          string
+	encryptedResponse   []byte
+
+	endorseProposal      *pb.Proposal
+	endorseProposalBytes []byte
+	endorseTxID          string
+
+	envelope *common.Envelope
+}
+
+// NewTransaction encrypts the invocation of the function with the given args and prepares the `__invoke` proposal.
+func NewTransaction(config Config, function string, args ...string) (*Transaction, error) {
+	ep := &crypto.EncryptionProviderImpl{
+		CSP: crypto.GetDefaultCSP(),
+		GetCcEncryptionKey: func() ([]byte, error) {
+			return config.ChaincodeEncryptionKey, nil
+		},
+		RequestPadding:  config.RequestPadding,
+		ResponsePadding: config.ResponsePadding,
+	}
+	if config.RequestBinding {
+		ep.ChannelId = config.ChannelID
+		ep.ChaincodeId = config.ChaincodeID
+	}
+
+	ctx, err := ep.NewEncryptionContext()
+	if err != nil {
+		return nil, err
+	}
+
+	return newTransaction(config, ctx, function, args...)
+}
+
+func newTransaction(config Config, ctx crypto.EncryptionContext, function string, args ...string) (*Transaction, error) {
+	if len(config.Creator) == 0 {
+		return nil, errors.New("no creator given")
+	}
+
+	encryptedRequest, err := ctx.Conceal(function, args)
+	if err != nil {
+		return nil, err
+	}
+
+	invokeProposal, invokeTxID, err := createProposal(config, invokeCMD, encryptedRequest)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create __invoke proposal")
+	}
+
+	return &Transaction{
+		config:                  config,
+		ctx:                     ctx,
+		chaincodeRequestMessage: encryptedRequest,
+		invokeProposalBytes:     protoutil.MarshalOrPanic(invokeProposal),
+		invokeTxID:              invokeTxID,
+	}, nil
+}
+
+func createProposal(config Config, function string, arg string) (*pb.Proposal, string, error) {
+	cis := &pb.ChaincodeInvocationSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			Type:        pb.ChaincodeSpec_GOLANG,
+			ChaincodeId: &pb.ChaincodeID{Name: config.ChaincodeID},
+			Input:       &pb.ChaincodeInput{Args: [][]byte{[]byte(function), []byte(arg)}},
+		},
+	}
+
+	return protoutil.CreateChaincodeProposal(common.HeaderType_ENDORSER_TRANSACTION, config.ChannelID, cis, config.Creator)
+}
+
+// ChaincodeRequestMessage returns the (base64-encoded) encrypted ChaincodeRequestMessage passed to `__invoke`
+func (t *Transaction) ChaincodeRequestMessage() string {
+	return t.chaincodeRequestMessage
+}
+
+// InvokeProposal returns the serialized `__invoke` proposal, which has to be signed by the creator, and its
+// transaction id.
+func (t *Transaction) InvokeProposal() (proposalBytes []byte, txID string) {
+	return t.invokeProposalBytes, t.invokeTxID
+}
+
+// SignedInvokeProposal returns the `__invoke` proposal with the given signature, ready to be sent to a FPC enclave
+// peer.
+func (t *Transaction) SignedInvokeProposal(signature []byte) (*pb.SignedProposal, error) {
+	return signProposal(t.invokeProposalBytes, signature)
+}
+
+// SetInvokeResponse sets the response to the `__invoke` proposal as received from a FPC enclave peer.
+func (t *Transaction) SetInvokeResponse(response *pb.ProposalResponse) error {
+	if response == nil || response.Response == nil {
+		return errors.New("no __invoke response")
+	}
+	if response.Response.Status < 200 || response.Response.Status >= 400 {
+		return errors.Errorf("__invoke failed, error code %d, msg %s", response.Response.Status, response.Response.Message)
+	}
+	if len(response.Response.Payload) == 0 {
+		return errors.New("__invoke response has no payload")
+	}
+
+	t.encryptedResponse = response.Response.Payload
+	t.endorseProposal = nil
+	t.endorseProposalBytes = nil
+	t.envelope = nil
+	return nil
+}
+
+// Result returns the decrypted result of the chaincode invocation
+func (t *Transaction) Result() ([]byte, error) {
+	if t.encryptedResponse == nil {
+		return nil, errors.New("no __invoke response set")
+	}
+	return t.ctx.Reveal(t.encryptedResponse)
+}
+
+// EndorseProposal returns the serialized `__endorse` proposal for the enclave response set via SetInvokeResponse,
+// which has to be signed by the creator, and its transaction id.
+func (t *Transaction) EndorseProposal() (proposalBytes []byte, txID string, err error) {
+	if t.encryptedResponse == nil {
+		return nil, "", errors.New("no __invoke response set")
+	}
+
+	if t.endorseProposal == nil {
+		t.endorseProposal, t.endorseTxID, err = createProposal(t.config, endorseCMD, string(t.encryptedResponse))
+		if err != nil {
+			return nil, "", errors.Wrap(err, "cannot create __endorse proposal")
+		}
+		t.endorseProposalBytes = protoutil.MarshalOrPanic(t.endorseProposal)
+	}
+
+	return t.endorseProposalBytes, t.endorseTxID, nil
+}
+
+// SignedEndorseProposal returns the `__endorse` proposal with the given signature, ready to be sent to the endorsing
+// peers.
+func (t *Transaction) SignedEndorseProposal(signature []byte) (*pb.SignedProposal, error) {
+	if t.endorseProposal == nil {
+		return nil, errors.New("no __endorse proposal created")
+	}
+	return signProposal(t.endorseProposalBytes, signature)
+}
+
+// TransactionPayload assembles the transaction from the `__endorse` proposal and the given endorsements and returns
+// the payload of the transaction envelope, which has to be signed by the creator.
+func (t *Transaction) TransactionPayload(responses ...*pb.ProposalResponse) ([]byte, error) {
+	if t.endorseProposal == nil {
+		return nil, errors.New("no __endorse proposal created")
+	}
+
+	envelope, err := protoutil.CreateSignedTx(t.endorseProposal, &unsignedSigner{creator: t.config.Creator}, responses...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create transaction")
+	}
+
+	t.envelope = envelope
+	return envelope.Payload, nil
+}
+
+// SignedTransaction returns the transaction envelope with the given signature, ready to be sent to the ordering
+// service.
+func (t *Transaction) SignedTransaction(signature []byte) (*common.Envelope, error) {
+	if t.envelope == nil {
+		return nil, errors.New("no transaction payload created")
+	}
+	if len(signature) == 0 {
+		return nil, errors.New("empty signature")
+	}
+
+	return &common.Envelope{Payload: t.envelope.Payload, Signature: signature}, nil
+}
+
+func signProposal(proposalBytes []byte, signature []byte) (*pb.SignedProposal, error) {
+	if len(signature) == 0 {
+		return nil, errors.New("empty signature")
+	}
+
+	return &pb.SignedProposal{
+		ProposalBytes: proposalBytes,
+		Signature:     signature,
+	}, nil
+}
+
+// unsignedSigner lets protoutil assemble a transaction without signing it; the signature is added later by the caller
+type unsignedSigner struct {
+	creator []byte
+}
+
+func (s *unsignedSigner) Sign(msg []byte) ([]byte, error) {
+	return nil, nil
+}
+
+func (s *unsignedSigner) Serialize() ([]byte, error) {
+	return s.creator, nil
+}
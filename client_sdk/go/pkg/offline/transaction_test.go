@@ -0,0 +1,208 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package offline
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/gateway/fakes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var config = Config{
+	ChannelID:   "mychannel",
+	ChaincodeID: "myChaincode",
+	Creator:     []byte("someCreator"),
+}
+
+func chaincodeArgs(t *testing.T, proposalBytes []byte) [][]byte {
+	proposal, err := protoutil.UnmarshalProposal(proposalBytes)
+	assert.NoError(t, err)
+	payload, err := protoutil.UnmarshalChaincodeProposalPayload(proposal.Payload)
+	assert.NoError(t, err)
+	cis, err := protoutil.UnmarshalChaincodeInvocationSpec(payload.Input)
+	assert.NoError(t, err)
+	assert.Equal(t, "myChaincode", cis.ChaincodeSpec.ChaincodeId.Name)
+	return cis.ChaincodeSpec.Input.Args
+}
+
+func TestNewTransaction(t *testing.T) {
+	pubKey, _, err := crypto.GetDefaultCSP().NewRSAKeys()
+	assert.NoError(t, err)
+
+	c := config
+	c.ChaincodeEncryptionKey = []byte(base64.StdEncoding.EncodeToString(pubKey))
+	tx, err := NewTransaction(c, "someFunction", "arg1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tx.ChaincodeRequestMessage())
+
+	proposalBytes, txID := tx.InvokeProposal()
+	assert.NotEmpty(t, txID)
+	assert.Equal(t, [][]byte{[]byte("__invoke"), []byte(tx.ChaincodeRequestMessage())}, chaincodeArgs(t, proposalBytes))
+
+	// invalid chaincode encryption key
+	c.ChaincodeEncryptionKey = []byte("not base64")
+	tx, err = NewTransaction(c, "someFunction", "arg1")
+	assert.Nil(t, tx)
+	assert.Error(t, err)
+
+	// no creator
+	c.ChaincodeEncryptionKey = []byte(base64.StdEncoding.EncodeToString(pubKey))
+	c.Creator = nil
+	tx, err = NewTransaction(c, "someFunction", "arg1")
+	assert.Nil(t, tx)
+	assert.Error(t, err)
+}
+
+func TestTransactionFlow(t *testing.T) {
+	ctx := &fakes.EncryptionContext{}
+	ctx.ConcealReturns("someEncryptedRequest", nil)
+	ctx.RevealReturns([]byte("result"), nil)
+
+	tx, err := newTransaction(config, ctx, "someFunction", "arg1", "arg2")
+	assert.NoError(t, err)
+	f, args := ctx.ConcealArgsForCall(0)
+	assert.Equal(t, "someFunction", f)
+	assert.Equal(t, []string{"arg1", "arg2"}, args)
+
+	// phase 1: __invoke
+	invokeBytes, invokeTxID := tx.InvokeProposal()
+	signedInvoke, err := tx.SignedInvokeProposal([]byte("invokeSignature"))
+	assert.NoError(t, err)
+	assert.Equal(t, invokeBytes, signedInvoke.ProposalBytes)
+	assert.Equal(t, []byte("invokeSignature"), signedInvoke.Signature)
+
+	_, err = tx.SignedInvokeProposal(nil)
+	assert.Error(t, err)
+
+	// nothing to endorse or reveal yet
+	_, err = tx.Result()
+	assert.Error(t, err)
+	_, _, err = tx.EndorseProposal()
+	assert.Error(t, err)
+	_, err = tx.SignedEndorseProposal([]byte("endorseSignature"))
+	assert.Error(t, err)
+
+	err = tx.SetInvokeResponse(&pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: "enclave failed"}})
+	assert.Error(t, err)
+	err = tx.SetInvokeResponse(&pb.ProposalResponse{Response: &pb.Response{Status: 200}})
+	assert.Error(t, err)
+	err = tx.SetInvokeResponse(&pb.ProposalResponse{Response: &pb.Response{Status: 200, Payload: []byte("someEncryptedResponse")}})
+	assert.NoError(t, err)
+
+	result, err := tx.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("result"), result)
+	assert.Equal(t, []byte("someEncryptedResponse"), ctx.RevealArgsForCall(0))
+
+	// phase 2: __endorse
+	endorseBytes, endorseTxID, err := tx.EndorseProposal()
+	assert.NoError(t, err)
+	assert.NotEqual(t, invokeTxID, endorseTxID)
+	assert.Equal(t, [][]byte{[]byte("__endorse"), []byte("someEncryptedResponse")}, chaincodeArgs(t, endorseBytes))
+
+	// proposal is stable
+	endorseBytes2, endorseTxID2, err := tx.EndorseProposal()
+	assert.NoError(t, err)
+	assert.Equal(t, endorseBytes, endorseBytes2)
+	assert.Equal(t, endorseTxID, endorseTxID2)
+
+	signedEndorse, err := tx.SignedEndorseProposal([]byte("endorseSignature"))
+	assert.NoError(t, err)
+	assert.Equal(t, endorseBytes, signedEndorse.ProposalBytes)
+
+	_, err = tx.SignedTransaction([]byte("txSignature"))
+	assert.Error(t, err)
+
+	// no endorsements
+	_, err = tx.TransactionPayload()
+	assert.Error(t, err)
+
+	endorsement := &pb.ProposalResponse{
+		Response:    &pb.Response{Status: 200, Payload: []byte("OK")},
+		Payload:     []byte("someProposalResponsePayload"),
+		Endorsement: &pb.Endorsement{Endorser: []byte("somePeer"), Signature: []byte("somePeerSignature")},
+	}
+	payloadBytes, err := tx.TransactionPayload(endorsement)
+	assert.NoError(t, err)
+
+	payload, err := protoutil.UnmarshalPayload(payloadBytes)
+	assert.NoError(t, err)
+	chdr, err := protoutil.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, endorseTxID, chdr.TxId)
+	assert.Equal(t, "mychannel", chdr.ChannelId)
+	assert.Equal(t, int32(common.HeaderType_ENDORSER_TRANSACTION), chdr.Type)
+
+	envelope, err := tx.SignedTransaction([]byte("txSignature"))
+	assert.NoError(t, err)
+	assert.Equal(t, payloadBytes, envelope.Payload)
+	assert.Equal(t, []byte("txSignature"), envelope.Signature)
+
+	_, err = tx.SignedTransaction(nil)
+	assert.Error(t, err)
+}
+
+func TestTransactionConcealFails(t *testing.T) {
+	ctx := &fakes.EncryptionContext{}
+	ctx.ConcealReturns("", fmt.Errorf("conceal failed"))
+
+	tx, err := newTransaction(config, ctx, "someFunction")
+	assert.Nil(t, tx)
+	assert.Error(t, err)
+}
+
+// TestBCCSPSigning signs the proposal with a key kept in a BCCSP, as with a PKCS#11 token
+func TestBCCSPSigning(t *testing.T) {
+	path, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+	ks, err := sw.NewFileBasedKeyStore(nil, path, false)
+	require.NoError(t, err)
+	b, err := sw.NewDefaultSecurityLevelWithKeystore(ks)
+	require.NoError(t, err)
+	csp := crypto.NewBCCSPCrypto(b)
+	pubKey, keyReference, err := csp.NewECDSAKeys()
+	require.NoError(t, err)
+
+	ctx := &fakes.EncryptionContext{}
+	ctx.ConcealReturns("someEncryptedRequest", nil)
+	tx, err := newTransaction(config, ctx, "someFunction", "arg1")
+	require.NoError(t, err)
+
+	proposalBytes, _ := tx.InvokeProposal()
+	signature, err := csp.SignMessage(keyReference, proposalBytes)
+	require.NoError(t, err)
+	signedInvoke, err := tx.SignedInvokeProposal(signature)
+	require.NoError(t, err)
+	assert.NoError(t, crypto.GetDefaultCSP().VerifyMessage(pubKey, signedInvoke.ProposalBytes, signedInvoke.Signature))
+
+	// peers only accept signatures with low S
+	block, _ := pem.Decode(pubKey)
+	require.NotNil(t, block)
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+	_, sigS, err := utils.UnmarshalECDSASignature(signature)
+	require.NoError(t, err)
+	lowS, err := utils.IsLowS(pub.(*ecdsa.PublicKey), sigS)
+	require.NoError(t, err)
+	assert.True(t, lowS)
+}
@@ -9,6 +9,7 @@ package sgx
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -23,6 +24,14 @@ const (
 	SGXModeHwType         = "HW"
 	SGXModeSimType        = "SIM"
 	SGXCredentialsPathKey = "SGX_CREDENTIALS_PATH"
+
+	AttestationTypeSimulated      = "simulated"
+	AttestationTypeEpidLinkable   = "epid-linkable"
+	AttestationTypeEpidUnlinkable = "epid-unlinkable"
+	AttestationTypeDcap           = "dcap"
+
+	// hexSpidLen is the length of a hex-encoded SPID (16 bytes)
+	hexSpidLen = 32
 )
 
 // AttestationParams holds additional attestation information that is required to perform LifecycleInitEnclave.
@@ -43,10 +52,30 @@ func (p *AttestationParams) ToBase64EncodedJSON() ([]byte, error) {
 }
 
 // Validate checks that the attestation information are correct.
+// The attestation type must be simulated, epid-linkable, epid-unlinkable, or dcap. EPID attestations require a
+// hex-encoded SPID, whereas simulated and DCAP attestations must neither define a SPID nor a SigRL.
 func (p *AttestationParams) Validate() error {
-	// TODO implement me
-	// check that attestation type is correct (epid, cap, simulated)
-	// check that hexspid has right format? etc ...
+	switch p.AttestationType {
+	case AttestationTypeSimulated, AttestationTypeDcap:
+		if p.HexSpid != "" {
+			return errors.Errorf("SPID must not be set for attestation type %s", p.AttestationType)
+		}
+		if p.SigRL != "" {
+			return errors.Errorf("SigRL must not be set for attestation type %s", p.AttestationType)
+		}
+
+	case AttestationTypeEpidLinkable, AttestationTypeEpidUnlinkable:
+		if len(p.HexSpid) != hexSpidLen {
+			return errors.Errorf("SPID must be %d hex characters but has %d", hexSpidLen, len(p.HexSpid))
+		}
+		if _, err := hex.DecodeString(p.HexSpid); err != nil {
+			return errors.Errorf("SPID '%s' is not hex-encoded", p.HexSpid)
+		}
+
+	default:
+		return errors.Errorf("invalid attestation type '%s', must be one of %s, %s, %s, %s", p.AttestationType,
+			AttestationTypeSimulated, AttestationTypeEpidLinkable, AttestationTypeEpidUnlinkable, AttestationTypeDcap)
+	}
 
 	return nil
 }
@@ -64,7 +93,7 @@ func CreateAttestationParamsFromEnvironment() (*AttestationParams, error) {
 
 	case SGXModeSimType:
 		return &AttestationParams{
-			AttestationType: "simulated",
+			AttestationType: AttestationTypeSimulated,
 		}, nil
 
 	default:
@@ -73,13 +102,20 @@ func CreateAttestationParamsFromEnvironment() (*AttestationParams, error) {
 }
 
 // CreateAttestationParamsFromCredentialsPath reads attestation information from a given path and returns an
-// SGXAttestationParams object.
+// SGXAttestationParams object. The SPID and SigRL are only read for EPID attestations; for DCAP attestations, the
+// credentials path only contains the attestation type dcap in spid_type.txt.
 func CreateAttestationParamsFromCredentialsPath(sgxCredentialsPath string) (*AttestationParams, error) {
 	spidType, err := ReadSPIDType(sgxCredentialsPath)
 	if err != nil {
 		return nil, err
 	}
 
+	if spidType == AttestationTypeDcap {
+		return &AttestationParams{
+			AttestationType: spidType,
+		}, nil
+	}
+
 	hexSpid, err := ReadSPID(sgxCredentialsPath)
 	if err != nil {
 		return nil, err
@@ -111,9 +147,18 @@ func ReadSPID(sgxCredentialsPath string) (string, error) {
 }
 
 // ReadSigRL reads the Signature Revocation List from a credentials path and returns it as string.
+// The SigRL is optional; if the credentials path does not contain a SigRL, an empty string is returned.
 func ReadSigRL(sgxCredentialsPath string) (string, error) {
-	// TODO implement me
-	return "", nil
+	sigRLPath := filepath.Join(sgxCredentialsPath, "sig_rl.txt")
+	content, err := ioutil.ReadFile(sigRLPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "could not read %s", sigRLPath)
+	}
+
+	return strings.TrimSuffix(string(content), "\n"), nil
 }
 
 func readFile(path string) (string, error) {
@@ -10,9 +10,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/hyperledger/fabric-private-chaincode/client_sdk/go/pkg/sgx"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,7 +31,30 @@ func TestAttestationParamsToBase64EncodedJSON(t *testing.T) {
 }
 
 func TestAttestationParamsValidate(t *testing.T) {
-	// TODO implement with validate
+	const hexSpid = "EEEEAAAABBBBAAAAEEEEAAAABBBBAAAA"
+
+	for _, p := range []*sgx.AttestationParams{
+		{AttestationType: sgx.AttestationTypeSimulated},
+		{AttestationType: sgx.AttestationTypeEpidLinkable, HexSpid: hexSpid},
+		{AttestationType: sgx.AttestationTypeEpidUnlinkable, HexSpid: strings.ToLower(hexSpid), SigRL: "SomeSigRL"},
+		{AttestationType: sgx.AttestationTypeDcap},
+	} {
+		assert.NoError(t, p.Validate(), "%v", p)
+	}
+
+	for expectedErr, p := range map[string]*sgx.AttestationParams{
+		"invalid attestation type '', must be one of simulated, epid-linkable, epid-unlinkable, dcap":           {},
+		"invalid attestation type 'simulation', must be one of simulated, epid-linkable, epid-unlinkable, dcap": {AttestationType: "simulation"},
+		"SPID must not be set for attestation type simulated":                                                   {AttestationType: sgx.AttestationTypeSimulated, HexSpid: hexSpid},
+		"SigRL must not be set for attestation type simulated":                                                  {AttestationType: sgx.AttestationTypeSimulated, SigRL: "SomeSigRL"},
+		"SPID must not be set for attestation type dcap":                                                        {AttestationType: sgx.AttestationTypeDcap, HexSpid: hexSpid},
+		"SigRL must not be set for attestation type dcap":                                                       {AttestationType: sgx.AttestationTypeDcap, SigRL: "SomeSigRL"},
+		"SPID must be 32 hex characters but has 0":                                                              {AttestationType: sgx.AttestationTypeEpidLinkable},
+		"SPID must be 32 hex characters but has 31":                                                             {AttestationType: sgx.AttestationTypeEpidLinkable, HexSpid: hexSpid[1:]},
+		"SPID 'XEEEAAAABBBBAAAAEEEEAAAABBBBAAAA' is not hex-encoded":                                            {AttestationType: sgx.AttestationTypeEpidUnlinkable, HexSpid: "X" + hexSpid[1:]},
+	} {
+		assert.EqualError(t, p.Validate(), expectedErr)
+	}
 }
 
 func TestCreateAttestationParamsFromEnvironment(t *testing.T) {
@@ -82,16 +107,32 @@ func TestCreateAttestationParamsFromCredentialsPath(t *testing.T) {
 	err = ioutil.WriteFile(filepath.Join(testPath, "spid.txt"), []byte("EEEEAAAABBBBAAAEEEEAAAABBBBAAAA"), 0644)
 	assert.NoError(t, err)
 
-	// TODO once ReadSigRL is implemented
-	// write recovaction list to file
-
+	// no revocation list available
 	attestationParams, err = sgx.CreateAttestationParamsFromCredentialsPath(testPath)
 	assert.NoError(t, err)
 
 	assert.Equal(t, attestationParams.AttestationType, "simulation")
 	assert.Equal(t, attestationParams.HexSpid, "EEEEAAAABBBBAAAEEEEAAAABBBBAAAA")
-	// TODO once ReadSigRL is implemented
 	assert.Equal(t, attestationParams.SigRL, "")
+
+	err = ioutil.WriteFile(filepath.Join(testPath, "sig_rl.txt"), []byte("SomeSigRL\n"), 0644)
+	assert.NoError(t, err)
+
+	attestationParams, err = sgx.CreateAttestationParamsFromCredentialsPath(testPath)
+	assert.NoError(t, err)
+	assert.Equal(t, attestationParams.SigRL, "SomeSigRL")
+
+	// dcap requires neither spid nor revocation list
+	dcapPath, err := ioutil.TempDir("/tmp/", "attestation")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dcapPath)
+	err = ioutil.WriteFile(filepath.Join(dcapPath, "spid_type.txt"), []byte("dcap\n"), 0644)
+	assert.NoError(t, err)
+
+	attestationParams, err = sgx.CreateAttestationParamsFromCredentialsPath(dcapPath)
+	assert.NoError(t, err)
+	assert.Equal(t, &sgx.AttestationParams{AttestationType: sgx.AttestationTypeDcap}, attestationParams)
+	assert.NoError(t, attestationParams.Validate())
 }
 
 func TestReadSPIDType(t *testing.T) {
@@ -145,8 +186,61 @@ func TestReadSPID(t *testing.T) {
 }
 
 func TestReadSigRL(t *testing.T) {
-	// TODO once ReadSigRL is implemented
-	sigRL, err := sgx.ReadSigRL("TODO")
+	testPath, err := ioutil.TempDir("/tmp/", "attestation")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testPath)
+
+	// does not exists, the SigRL is optional
+	sigRL, err := sgx.ReadSigRL(testPath)
 	assert.Empty(t, sigRL)
 	assert.NoError(t, err)
+
+	// empty sig_rl file
+	err = ioutil.WriteFile(filepath.Join(testPath, "sig_rl.txt"), nil, 0644)
+	assert.NoError(t, err)
+	sigRL, err = sgx.ReadSigRL(testPath)
+	assert.Empty(t, sigRL)
+	assert.NoError(t, err)
+
+	// cannot be read
+	otherPath := filepath.Join(testPath, "other")
+	err = os.MkdirAll(filepath.Join(otherPath, "sig_rl.txt"), 0755)
+	assert.NoError(t, err)
+	sigRL, err = sgx.ReadSigRL(otherPath)
+	assert.Empty(t, sigRL)
+	assert.Error(t, err)
+
+	// success
+	err = ioutil.WriteFile(filepath.Join(testPath, "sig_rl.txt"), []byte("SomeSigRL\n"), 0644)
+	assert.NoError(t, err)
+	sigRL, err = sgx.ReadSigRL(testPath)
+	assert.Equal(t, sigRL, "SomeSigRL")
+	assert.NoError(t, err)
+}
+
+func TestCreateAttestationParamsFromCredentialProvider(t *testing.T) {
+	hexSpid := "EEEEAAAABBBBAAAAEEEEAAAABBBBAAAA"
+	provider := &sgx.CallbackCredentialProvider{
+		SPIDFunc: func() (string, string, error) {
+			return sgx.AttestationTypeEpidLinkable, hexSpid, nil
+		},
+	}
+
+	attestationParams, err := sgx.CreateAttestationParamsFromCredentialProvider(provider)
+	assert.NoError(t, err)
+	assert.Equal(t, sgx.AttestationTypeEpidLinkable, attestationParams.AttestationType)
+	assert.Equal(t, hexSpid, attestationParams.HexSpid)
+	assert.Empty(t, attestationParams.SigRL)
+
+	// invalid spid
+	provider.SPIDFunc = func() (string, string, error) {
+		return sgx.AttestationTypeEpidLinkable, hexSpid[1:], nil
+	}
+	_, err = sgx.CreateAttestationParamsFromCredentialProvider(provider)
+	assert.EqualError(t, err, "SPID must be 32 hex characters but has 31")
+
+	// no spid
+	_, err = sgx.CreateAttestationParamsFromCredentialProvider(sgx.NewChainedCredentialProvider(&sgx.CallbackCredentialProvider{}))
+	assert.Error(t, err)
+	assert.Equal(t, sgx.ErrCredentialNotFound, errors.Cause(err))
 }
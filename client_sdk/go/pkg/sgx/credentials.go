@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sgx
+
+import (
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/pkg/errors"
+)
+
+// CredentialProvider provides the API keys of the attestation services and the SPID of the EPID attestation.
+// Implementations, e.g., backed by a secrets manager, must never log key material.
+type CredentialProvider = attestation.CredentialProvider
+
+// Secret holds key material such as an API key. It is redacted when formatted; use string(secret) to access the key.
+type Secret = attestation.Secret
+
+// CallbackCredentialProvider is a CredentialProvider which calls the given functions
+type CallbackCredentialProvider = attestation.CallbackCredentialProvider
+
+// ErrCredentialNotFound is returned (wrapped) by a CredentialProvider which does not provide the requested credential
+var ErrCredentialNotFound = attestation.ErrCredentialNotFound
+
+// NewEnvCredentialProvider returns a CredentialProvider which reads the credentials from the environment variables
+// IAS_API_KEY, PCCS_API_KEY, IAS_SPID_TYPE and IAS_SPID.
+func NewEnvCredentialProvider() CredentialProvider {
+	return attestation.NewEnvCredentialProvider()
+}
+
+// NewFileCredentialProvider returns a CredentialProvider which reads the credentials from a credentials path, i.e.,
+// the files api_key.txt, pccs_api_key.txt, spid_type.txt and spid.txt.
+func NewFileCredentialProvider(sgxCredentialsPath string) CredentialProvider {
+	return attestation.NewFileCredentialProvider(sgxCredentialsPath)
+}
+
+// NewChainedCredentialProvider returns a CredentialProvider which asks the given providers in order
+func NewChainedCredentialProvider(providers ...CredentialProvider) CredentialProvider {
+	return attestation.NewChainedCredentialProvider(providers...)
+}
+
+// CreateAttestationParamsFromCredentialProvider returns an AttestationParams object with the SPID type and SPID
+// provided by the CredentialProvider. No SigRL is set.
+func CreateAttestationParamsFromCredentialProvider(provider CredentialProvider) (*AttestationParams, error) {
+	spidType, hexSpid, err := provider.GetSPID()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get SPID")
+	}
+
+	params := &AttestationParams{
+		AttestationType: spidType,
+		HexSpid:         hexSpid,
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
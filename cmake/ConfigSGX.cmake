@@ -43,6 +43,7 @@ if (SGX_MODE STREQUAL HW)
     set(SGX_URTS_LIB sgx_urts)
     set(SGX_LNCH_LIB sgx_launch)
     set(SGX_EPID_LIB sgx_epid)
+    set(SGX_DCAP_QL_LIB sgx_dcap_ql)
     set(SGX_TRTS_LIB sgx_trts)
     set(SGX_TSVC_LIB sgx_tservice)
 else ()
@@ -50,6 +51,8 @@ else ()
     set(SGX_URTS_LIB sgx_urts_sim)
     set(SGX_LNCH_LIB sgx_launch_sim)
     set(SGX_EPID_LIB sgx_epid_sim)
+    # the DCAP quote library has no simulation mode
+    set(SGX_DCAP_QL_LIB "")
     set(SGX_TRTS_LIB sgx_trts_sim)
     set(SGX_TSVC_LIB sgx_tservice_sim)
 endif (SGX_MODE STREQUAL HW)
@@ -35,6 +35,12 @@ sgx_status_t ocall_get_quote(uint8_t* spid,
     uint8_t* quote,
     uint32_t max_quote_len,
     uint32_t* actual_quote_len);
+sgx_status_t ocall_init_quote_dcap(uint8_t* target, uint32_t target_len);
+sgx_status_t ocall_get_quote_dcap(uint8_t* report,
+    uint32_t report_len,
+    uint8_t* quote,
+    uint32_t max_quote_len,
+    uint32_t* actual_quote_len);
 #ifdef __cplusplus
 }
 #endif /* __cplusplus */
@@ -73,8 +79,10 @@ bool init_attestation(uint8_t* params, uint32_t params_length)
         COND2LOGERR(p == NULL, "no attestation type provided");
         g_attestation_state.attestation_type.assign(p, p + strlen(p));
 
-        if (g_attestation_state.attestation_type.compare(SIMULATED_TYPE_TAG) == 0)
+        if (g_attestation_state.attestation_type.compare(SIMULATED_TYPE_TAG) == 0 ||
+            g_attestation_state.attestation_type.compare(DCAP_TYPE_TAG) == 0)
         {
+            // neither simulated nor DCAP attestation require SPID and sig_rl
             // terminate init successfully
             goto init_success;
         }
@@ -158,8 +166,17 @@ bool get_attestation(uint8_t* statement,
     }
     else
     {
-        ocall_init_quote(
-            (uint8_t*)&qe_target_info, sizeof(qe_target_info), (uint8_t*)&egid, sizeof(egid));
+        bool dcap = g_attestation_state.attestation_type.compare(DCAP_TYPE_TAG) == 0;
+
+        if (dcap)
+        {
+            ocall_init_quote_dcap((uint8_t*)&qe_target_info, sizeof(qe_target_info));
+        }
+        else
+        {
+            ocall_init_quote(
+                (uint8_t*)&qe_target_info, sizeof(qe_target_info), (uint8_t*)&egid, sizeof(egid));
+        }
 
         ByteArray ba_statement(statement, statement + statement_length);
         ByteArray rd = pdo::crypto::ComputeMessageHash(ba_statement);
@@ -170,13 +187,21 @@ bool get_attestation(uint8_t* statement,
         ret = sgx_create_report(&qe_target_info, &report_data, &report);
         COND2LOGERR(SGX_SUCCESS != ret, "error creating report");
 
-        ocall_get_quote((uint8_t*)&g_attestation_state.spid, (uint32_t)sizeof(sgx_spid_t),
-            g_attestation_state.sig_rl.data(), g_attestation_state.sig_rl.size(),
-            g_attestation_state.sign_type, (uint8_t*)&report, sizeof(report), attestation,
-            attestation_max_length, attestation_length);
+        if (dcap)
+        {
+            ocall_get_quote_dcap((uint8_t*)&report, sizeof(report), attestation,
+                attestation_max_length, attestation_length);
+        }
+        else
+        {
+            ocall_get_quote((uint8_t*)&g_attestation_state.spid, (uint32_t)sizeof(sgx_spid_t),
+                g_attestation_state.sig_rl.data(), g_attestation_state.sig_rl.size(),
+                g_attestation_state.sign_type, (uint8_t*)&report, sizeof(report), attestation,
+                attestation_max_length, attestation_length);
+        }
         COND2LOGERR(*attestation_length == 0, "error get quote");
 
-        // convert to base64 (accepted by IAS)
+        // convert to base64 (accepted by IAS and by the DCAP converter)
         b64attestation = base64_encode((const unsigned char*)attestation, *attestation_length);
         COND2LOGERR(b64attestation.length() > attestation_max_length,
             "not enough space for b64 conversion");
@@ -10,6 +10,7 @@
 #define SIMULATED_TYPE_TAG "simulated"
 #define EPID_LINKABLE_TYPE_TAG "epid-linkable"
 #define EPID_UNLINKABLE_TYPE_TAG "epid-unlinkable"
+#define DCAP_TYPE_TAG "dcap"
 #define ATTESTATION_TAG "attestation"
 #define EVIDENCE_TAG "evidence"
 #define SPID_TAG "hex_spid"
@@ -9,6 +9,9 @@
 #include "fpc-types.h"
 #include "logging.h"
 #include "sgx_quote.h"
+#ifdef SGX_HW_MODE
+#include <sgx_dcap_ql_wrapper.h>
+#endif
 
 void ocall_init_quote(uint8_t* target, uint32_t target_len, uint8_t* egid, uint32_t egid_len)
 {
@@ -52,3 +55,45 @@ err:
     // if anything wrong, no quote
     *actual_quote_len = 0;
 }
+
+// DCAP quotes are only available in hardware mode (there is no simulated quoting enclave)
+
+void ocall_init_quote_dcap(uint8_t* target, uint32_t target_len)
+{
+#ifdef SGX_HW_MODE
+    quote3_error_t ret = sgx_qe_get_target_info((sgx_target_info_t*)target);
+    COND2LOGERR(ret != SGX_QL_SUCCESS, "error init dcap quote");
+#else
+    COND2LOGERR(true, "dcap attestation requires hardware mode");
+#endif
+err:
+    // nothing to do, error will be reported at quote time
+    ;
+}
+
+void ocall_get_quote_dcap(uint8_t* report,
+    uint32_t report_len,
+    uint8_t* quote,
+    uint32_t max_quote_len,
+    uint32_t* actual_quote_len)
+{
+#ifdef SGX_HW_MODE
+    quote3_error_t ret;
+    uint32_t required_quote_size = 0;
+    ret = sgx_qe_get_quote_size(&required_quote_size);
+    COND2LOGERR(ret != SGX_QL_SUCCESS, "cannot get dcap quote size");
+    COND2LOGERR(required_quote_size > max_quote_len, "not enough buffer for quote");
+
+    ret = sgx_qe_get_quote((const sgx_report_t*)report, required_quote_size, quote);
+    COND2LOGERR(ret != SGX_QL_SUCCESS, "error getting dcap quote");
+    *actual_quote_len = required_quote_size;
+
+    return;
+#else
+    COND2LOGERR(true, "dcap attestation requires hardware mode");
+#endif
+
+err:
+    // if anything wrong, no quote
+    *actual_quote_len = 0;
+}
@@ -17,12 +17,52 @@ set -e
 
 DEFINES_FILEPATH="${FPC_PATH}/common/crypto/attestation-api/test/common/test-defines.h"
 TAGS_FILEPATH="${FPC_PATH}/common/crypto/attestation-api/attestation/attestation_tags.h"
+TEST_PATH="${FPC_PATH}/common/crypto/attestation-api/test"
+VECTORS_PATH="${TEST_PATH}/vectors"
+GO_VERIFICATION_CMD="go run ${TEST_PATH}/verify_evidence_app_go/main.go"
 
 function remove_artifacts()
 {
     rm -rf *.txt
 }
 
+function verify_evidence_with_go()
+{
+    # in hw mode, the go-based verification requires the IAS root certificate
+    if [[ ${SGX_MODE} == "HW" && -z "${IAS_ROOT_CA_PATH}" ]]; then
+        say "Skipping Go-based evidence verification (IAS_ROOT_CA_PATH not set)"
+        return
+    fi
+    ${GO_VERIFICATION_CMD}
+}
+
+function verify_evidence_vectors()
+{
+    # cross-check the c-based and the go-based evidence verification with the test vectors,
+    # each in a directory with the input files of verify_evidence_app and the expected result per sgx mode
+    define_to_variable "${DEFINES_FILEPATH}" "EVIDENCE_FILE"
+    define_to_variable "${DEFINES_FILEPATH}" "STATEMENT_FILE"
+    define_to_variable "${DEFINES_FILEPATH}" "CODE_ID_FILE"
+
+    for VECTOR in ${VECTORS_PATH}/*/; do
+        remove_artifacts
+        cp ${VECTOR}/${EVIDENCE_FILE} ${VECTOR}/${STATEMENT_FILE} ${VECTOR}/${CODE_ID_FILE} .
+        EXPECTED_RESULT=$(grep "^${SGX_MODE}=" ${VECTOR}/expected_result.txt | cut -d= -f2)
+        [ -n "${EXPECTED_RESULT}" ] || die "no expected result for ${VECTOR}"
+
+        RESULT=success
+        ./verify_evidence_app || RESULT=failure
+        [ "${RESULT}" = "${EXPECTED_RESULT}" ] || die "c-based verification of ${VECTOR}: ${RESULT} (expected ${EXPECTED_RESULT})"
+
+        RESULT=success
+        ${GO_VERIFICATION_CMD} || RESULT=failure
+        [ "${RESULT}" = "${EXPECTED_RESULT}" ] || die "go-based verification of ${VECTOR}: ${RESULT} (expected ${EXPECTED_RESULT})"
+
+        say "Test vector ${VECTOR}: ${RESULT}"
+    done
+    remove_artifacts
+}
+
 function orchestrate()
 {
     #get attestation
@@ -39,6 +79,7 @@ function orchestrate()
 
     #verify evidence
     ./verify_evidence_app
+    verify_evidence_with_go
 }
 
 function orchestrate_with_go_conversion()
@@ -58,8 +99,16 @@ function orchestrate_with_go_conversion()
 
     #verify evidence
     ./verify_evidence_app
+    verify_evidence_with_go
 }
 
+#######################################
+# test vectors
+#######################################
+say "Testing evidence verification with test vectors"
+verify_evidence_vectors
+say "Test vectors success"
+
 #######################################
 # sim mode test
 #######################################
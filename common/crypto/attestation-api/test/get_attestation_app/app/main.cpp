@@ -49,8 +49,8 @@ int main()
     COND2LOGERR(!b, "init_attestation failed");
 
     LOG_INFO("Testing get attestation\n");
-    get_att(global_eid, &b, (uint8_t*)STATEMENT, strlen(STATEMENT), attestation, 4096,
-        &attestation_length);
+    get_att(global_eid, &b, (uint8_t*)STATEMENT, strlen(STATEMENT), attestation,
+        buffer_length, &attestation_length);
     COND2LOGERR(!b, "get_attestation failed");
 
     COND2LOGERR(false == save_file(GET_ATTESTATION_OUTPUT, (char*)attestation, attestation_length),
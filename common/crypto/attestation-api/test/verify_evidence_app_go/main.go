@@ -0,0 +1,82 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// this tool is meant to be used in `$FPC_PATH/common/crypto/attestation-api/test` to ensure compatibility
+// of the go-based evidence verification in `$FPC_PATH/ercc/attestation` with the C-based `verify_evidence`.
+// It runs the same tests as `verify_evidence_app` on the files in the current directory.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/ercc/attestation"
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+)
+
+// see test-defines.h
+const (
+	evidenceFile  = "verify_evidence_input.txt"
+	statementFile = "statement.txt"
+	codeIdFile    = "code_id.txt"
+)
+
+func main() {
+	evidence := readFile(evidenceFile)
+	statement := readFile(statementFile)
+	codeId := readFile(codeIdFile)
+
+	wrongStatement := []byte("wrong statement")
+	wrongCodeId := "BADBADBADBAD9E317C4F7312A0D644FFC052F7645350564D43586D8102663358"
+
+	var opts []attestation.GoVerifierOption
+	if os.Getenv("SGX_MODE") != "HW" {
+		opts = append(opts, attestation.WithSimulatedAttestation())
+	}
+	if path := os.Getenv(attestation.IASRootCAPathEnvKey); len(path) != 0 {
+		roots, err := fpcattestation.LoadIASRootCertificates(path)
+		exitIfError(err)
+		opts = append(opts, attestation.WithIASVerifier(fpcattestation.NewIASVerifier(roots)))
+	}
+	verifier := attestation.NewGoVerifier(opts...)
+
+	// test normal situation
+	err := verifier.VerifyEvidence(evidence, statement, string(codeId), nil, time.Now())
+	exitIfError(err)
+
+	// these tests succeed for simulated attestations, and fail for real ones
+	expectSuccess := strings.Contains(string(evidence), fpcattestation.SimulatedType)
+
+	// test with wrong statement
+	err = verifier.VerifyEvidence(evidence, wrongStatement, string(codeId), nil, time.Now())
+	if (err == nil) != expectSuccess {
+		exitIfError(fmt.Errorf("unexpected result for evidence with bad statement: %v", err))
+	}
+
+	// test with wrong code id
+	err = verifier.VerifyEvidence(evidence, statement, wrongCodeId, nil, time.Now())
+	if (err == nil) != expectSuccess {
+		exitIfError(fmt.Errorf("unexpected result for evidence with bad code id: %v", err))
+	}
+
+	fmt.Println("Test Successful")
+}
+
+func readFile(name string) []byte {
+	data, err := ioutil.ReadFile(name)
+	exitIfError(err)
+	return data
+}
+
+func exitIfError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -5,6 +5,8 @@
  */
 
 #include <string.h>
+#include <openssl/evp.h>
+#include <openssl/rand.h>
 #include "crypto.h"
 #include "logging.h"
 #include "error.h"
@@ -239,6 +241,104 @@ err:
     return false;
 }
 
+// Note that pdo skenc does not support associated data, so we use openssl directly.
+// The format of the encrypted message is the same as with pdo skenc, i.e., IV|TAG|ciphertext
+bool encrypt_message_with_aad(uint8_t* key,
+        uint32_t key_len,
+        uint8_t* message,
+        uint32_t message_len,
+        uint8_t* associated_data,
+        uint32_t associated_data_len,
+        uint8_t* encrypted_message,
+        uint32_t encrypted_message_len,
+        uint32_t* encrypted_message_actual_len)
+{
+    EVP_CIPHER_CTX* ctx = NULL;
+    uint8_t* iv = encrypted_message;
+    uint8_t* tag = encrypted_message + IV_LEN;
+    uint8_t* ciphertext = encrypted_message + IV_LEN + TAG_LEN;
+    int len;
+
+    COND2LOGERR(key == NULL || key_len != SYM_KEY_LEN, "invalid key");
+    COND2LOGERR(message == NULL && message_len > 0, "invalid message");
+    COND2LOGERR(associated_data == NULL && associated_data_len > 0, "invalid associated data");
+    COND2LOGERR(encrypted_message_len < IV_LEN + TAG_LEN + message_len, "encrypted message buffer too small");
+
+    COND2LOGERR(RAND_bytes(iv, IV_LEN) != 1, "cannot generate iv");
+
+    ctx = EVP_CIPHER_CTX_new();
+    COND2LOGERR(ctx == NULL, "cannot create cipher context");
+    COND2LOGERR(EVP_EncryptInit_ex(ctx, EVP_aes_128_gcm(), NULL, NULL, NULL) != 1, "cannot init encryption");
+    COND2LOGERR(EVP_CIPHER_CTX_ctrl(ctx, EVP_CTRL_GCM_SET_IVLEN, IV_LEN, NULL) != 1, "cannot set iv length");
+    COND2LOGERR(EVP_EncryptInit_ex(ctx, NULL, NULL, key, iv) != 1, "cannot init encryption");
+    if(associated_data_len > 0)
+    {
+        COND2LOGERR(EVP_EncryptUpdate(ctx, NULL, &len, associated_data, associated_data_len) != 1,
+            "cannot process associated data");
+    }
+    if(message_len > 0)
+    {
+        COND2LOGERR(EVP_EncryptUpdate(ctx, ciphertext, &len, message, message_len) != 1, "encryption failed");
+    }
+    COND2LOGERR(EVP_EncryptFinal_ex(ctx, ciphertext + message_len, &len) != 1, "encryption failed");
+    COND2LOGERR(EVP_CIPHER_CTX_ctrl(ctx, EVP_CTRL_GCM_GET_TAG, TAG_LEN, tag) != 1, "cannot get tag");
+
+    EVP_CIPHER_CTX_free(ctx);
+    *encrypted_message_actual_len = IV_LEN + TAG_LEN + message_len;
+    return true;
+
+err:
+    EVP_CIPHER_CTX_free(ctx);
+    return false;
+}
+
+bool decrypt_message_with_aad(uint8_t* key,
+        uint32_t key_len,
+        uint8_t* encrypted_message,
+        uint32_t encrypted_message_len,
+        uint8_t* associated_data,
+        uint32_t associated_data_len,
+        uint8_t* message,
+        uint32_t message_len,
+        uint32_t* message_actual_len)
+{
+    EVP_CIPHER_CTX* ctx = NULL;
+    uint8_t* iv = encrypted_message;
+    uint8_t* tag = encrypted_message + IV_LEN;
+    uint8_t* ciphertext = encrypted_message + IV_LEN + TAG_LEN;
+    uint32_t ciphertext_len;
+    int len;
+
+    COND2LOGERR(key == NULL || key_len != SYM_KEY_LEN, "invalid key");
+    COND2LOGERR(encrypted_message == NULL || encrypted_message_len <= IV_LEN + TAG_LEN, "invalid encrypted message");
+    COND2LOGERR(associated_data == NULL && associated_data_len > 0, "invalid associated data");
+    ciphertext_len = encrypted_message_len - IV_LEN - TAG_LEN;
+    COND2LOGERR(message_len < ciphertext_len, "message buffer too small");
+
+    ctx = EVP_CIPHER_CTX_new();
+    COND2LOGERR(ctx == NULL, "cannot create cipher context");
+    COND2LOGERR(EVP_DecryptInit_ex(ctx, EVP_aes_128_gcm(), NULL, NULL, NULL) != 1, "cannot init decryption");
+    COND2LOGERR(EVP_CIPHER_CTX_ctrl(ctx, EVP_CTRL_GCM_SET_IVLEN, IV_LEN, NULL) != 1, "cannot set iv length");
+    COND2LOGERR(EVP_DecryptInit_ex(ctx, NULL, NULL, key, iv) != 1, "cannot init decryption");
+    if(associated_data_len > 0)
+    {
+        COND2LOGERR(EVP_DecryptUpdate(ctx, NULL, &len, associated_data, associated_data_len) != 1,
+            "cannot process associated data");
+    }
+    COND2LOGERR(EVP_DecryptUpdate(ctx, message, &len, ciphertext, ciphertext_len) != 1, "decryption failed");
+    COND2LOGERR(EVP_CIPHER_CTX_ctrl(ctx, EVP_CTRL_GCM_SET_TAG, TAG_LEN, tag) != 1, "cannot set tag");
+    // the final step checks the tag, i.e., the integrity of the ciphertext and the associated data
+    COND2LOGERR(EVP_DecryptFinal_ex(ctx, message + ciphertext_len, &len) != 1, "decryption failed");
+
+    EVP_CIPHER_CTX_free(ctx);
+    *message_actual_len = ciphertext_len;
+    return true;
+
+err:
+    EVP_CIPHER_CTX_free(ctx);
+    return false;
+}
+
 bool new_rsa_key(uint8_t* public_key,
         uint32_t public_key_len,
         uint32_t* public_key_actual_len,
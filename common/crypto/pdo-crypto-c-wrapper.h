@@ -70,6 +70,30 @@ bool decrypt_message(uint8_t* key,
         uint32_t message_len,
         uint32_t* message_actual_len);
 
+/* like encrypt_message (AES-GCM, output is IV|TAG|ciphertext) but additionally
+ * authenticates the associated data (which is not included in the output) */
+bool encrypt_message_with_aad(uint8_t* key,
+        uint32_t key_len,
+        uint8_t* message,
+        uint32_t message_len,
+        uint8_t* associated_data,
+        uint32_t associated_data_len,
+        uint8_t* encrypted_message,
+        uint32_t encrypted_message_len,
+        uint32_t* encrypted_message_actual_len);
+
+/* decrypts a message encrypted with encrypt_message_with_aad; fails if the
+ * associated data does not match */
+bool decrypt_message_with_aad(uint8_t* key,
+        uint32_t key_len,
+        uint8_t* encrypted_message,
+        uint32_t encrypted_message_len,
+        uint8_t* associated_data,
+        uint32_t associated_data_len,
+        uint8_t* message,
+        uint32_t message_len,
+        uint32_t* message_actual_len);
+
 bool new_rsa_key(uint8_t* public_key,
         uint32_t public_key_len,
         uint32_t* public_key_actual_len,
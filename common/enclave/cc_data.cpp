@@ -129,7 +129,8 @@ bool cc_data::get_credentials(const uint8_t* attestation_parameters,
     ByteArray attested_data;
     ByteArray attestation;
     // NOTE: attestation's max length should be adapted
-    const uint32_t attestation_max_length = 1 << 12;
+    // (a base64 encoded DCAP quote including the PCK certificate chain takes about 6KB)
+    const uint32_t attestation_max_length = 1 << 13;
     uint32_t attestation_length;
     bool b;
     std::string attestation_parameters_b64;
@@ -143,6 +144,9 @@ bool cc_data::get_credentials(const uint8_t* attestation_parameters,
     CATCH(b, cc_parameters_.assign(cc_parameters, cc_parameters + ccp_size));
     COND2ERR(!b);
 
+    b = parse_cc_parameters();
+    COND2LOGERR(!b, "cannot parse cc parameters");
+
     CATCH(b, host_parameters_.assign(host_parameters, host_parameters + hp_size));
     COND2ERR(!b);
 
@@ -217,6 +221,43 @@ err:
     return false;
 }
 
+bool cc_data::parse_cc_parameters()
+{
+    bool b;
+    pb_istream_t istream;
+    fpc_CCParameters cc_params = {};
+
+    istream = pb_istream_from_buffer(
+        (const unsigned char*)cc_parameters_.data(), cc_parameters_.size());
+    b = pb_decode(&istream, fpc_CCParameters_fields, &cc_params);
+    COND2LOGERR(!b, PB_GET_ERROR(&istream));
+    COND2LOGERR(cc_params.chaincode_id == NULL, "no chaincode id");
+    COND2LOGERR(cc_params.channel_id == NULL, "no channel id");
+
+    CATCH(b, {
+        chaincode_id_ = std::string(cc_params.chaincode_id);
+        channel_id_ = std::string(cc_params.channel_id);
+    });
+    COND2ERR(!b);
+
+    pb_release(fpc_CCParameters_fields, &cc_params);
+    return true;
+
+err:
+    pb_release(fpc_CCParameters_fields, &cc_params);
+    return false;
+}
+
+std::string cc_data::get_chaincode_id() const
+{
+    return chaincode_id_;
+}
+
+std::string cc_data::get_channel_id() const
+{
+    return channel_id_;
+}
+
 std::string cc_data::get_enclave_id()
 {
     // get enclave vk
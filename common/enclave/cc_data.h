@@ -25,8 +25,14 @@ private:
     ByteArray cc_parameters_;
     ByteArray host_parameters_;
 
+    // parsed from cc_parameters_
+    std::string chaincode_id_;
+    std::string channel_id_;
+
     bool generate_keys();
 
+    bool parse_cc_parameters();
+
     bool build_attested_data(ByteArray& attested_data);
 
 public:
@@ -43,6 +49,8 @@ public:
         uint32_t* credentials_size);
 
     std::string get_enclave_id();
+    std::string get_chaincode_id() const;
+    std::string get_channel_id() const;
     bool sign_message(const ByteArray& message, ByteArray& signature) const;
     bool decrypt_key_transport_message(
         const ByteArray& encrypted_key_transport_message, ByteArray& key_transport_message) const;
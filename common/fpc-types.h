@@ -35,4 +35,11 @@ typedef struct ec256_signature_t
     uint32_t y[8];
 } ec256_signature_t;
 
+// return codes of the chaincode invocation (see ecall_cc_invoke) in addition to 0 (success) and 1
+// (failure)
+// - a request of a batch failed, hence, the whole batch is aborted
+#define FPC_ERROR_CHAINCODE_FAILED 0x10001
+// - the response does not fit into the response buffer, e.g., as a batch has too many requests
+#define FPC_ERROR_RESPONSE_TOO_LARGE 0x10002
+
 #endif
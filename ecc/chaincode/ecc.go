@@ -8,7 +8,9 @@ package chaincode
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/hyperledger/fabric/protoutil"
 
@@ -24,9 +26,15 @@ import (
 
 var logger = flogging.MustGetLogger("ecc")
 
+const sgxModeEnvKey = "SGX_MODE"
+
 // EnclaveChaincode struct
 type EnclaveChaincode struct {
 	enclave enclave.StubInterface
+
+	// credentials of the enclave, set by __initEnclave
+	attestedData    *protos.AttestedData
+	attestationType string
 }
 
 func NewChaincodeEnclave() shim.Chaincode {
@@ -52,6 +60,8 @@ func (t *EnclaveChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response
 		return t.invoke(stub)
 	case "__endorse":
 		return t.endorse(stub)
+	case "__getEnclaveStatus":
+		return t.getEnclaveStatus(stub)
 	default:
 		return shim.Error("invalid invocation")
 	}
@@ -95,10 +105,61 @@ func (t *EnclaveChaincode) initEnclave(stub shim.ChaincodeStubInterface) pb.Resp
 		return shim.Error(errMsg)
 	}
 
+	// remember credentials for status queries
+	attestedData, attestationType, err := extractCredentialsInfo(credentialsBytes)
+	if err != nil {
+		errMsg := fmt.Sprintf("credentials extraction failed: %s", err.Error())
+		return shim.Error(errMsg)
+	}
+	t.attestedData = attestedData
+	t.attestationType = attestationType
+
 	// return credentials
 	return shim.Success([]byte(base64.StdEncoding.EncodeToString(credentialsBytes)))
 }
 
+// getEnclaveStatus returns the status of the enclave hosted by this peer as base64-encoded EnclaveStatus message.
+// The registration and provisioning state is taken from ERCC.
+func (t *EnclaveChaincode) getEnclaveStatus(stub shim.ChaincodeStubInterface) pb.Response {
+	status := &protos.EnclaveStatus{
+		SgxMode: os.Getenv(sgxModeEnvKey),
+	}
+
+	enclaveId, err := t.enclave.GetEnclaveId()
+	if err != nil || t.attestedData == nil {
+		// no enclave initialized yet
+		logger.Debugf("enclave not initialized")
+		return shim.Success([]byte(utils.MarshallProto(status)))
+	}
+
+	status.Initialized = true
+	status.EnclaveId = enclaveId
+	status.CcParams = t.attestedData.CcParams
+	status.AttestationType = t.attestationType
+
+	channelId := stub.GetChannelID()
+	chaincodeId := t.attestedData.CcParams.GetChaincodeId()
+
+	credentials, err := ercc.QueryEnclaveCredentials(stub, channelId, chaincodeId, enclaveId)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("cannot query enclave credentials: %s", err.Error()))
+	}
+	status.Registered = credentials.GetSerializedAttestedData() != nil
+
+	provisionedEnclaves, err := ercc.QueryListProvisionedEnclaves(stub, channelId, chaincodeId)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("cannot query provisioned enclaves: %s", err.Error()))
+	}
+	for _, id := range provisionedEnclaves {
+		if id == enclaveId {
+			status.Provisioned = true
+			break
+		}
+	}
+
+	return shim.Success([]byte(utils.MarshallProto(status)))
+}
+
 func (t *EnclaveChaincode) invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	// call enclave
 	var errMsg string
@@ -116,6 +177,14 @@ func (t *EnclaveChaincode) invoke(stub shim.ChaincodeStubInterface) pb.Response
 	}
 
 	signedChaincodeResponseMessage, errInvoke := t.enclave.ChaincodeInvoke(stub, chaincodeRequestMessage)
+	if errors.Is(errInvoke, enclave.ErrChaincodeFailed) || errors.Is(errInvoke, enclave.ErrResponseTooLarge) {
+		// the enclave is fine but the request cannot be processed, so let clients know not to try other enclaves
+		logger.Errorf("t.enclave.Invoke failed: %s", errInvoke)
+		return pb.Response{
+			Status:  utils.ChaincodeErrorStatus,
+			Message: errInvoke.Error(),
+		}
+	}
 	if errInvoke != nil {
 		errMsg = fmt.Sprintf("t.enclave.Invoke failed: %s", errInvoke)
 		logger.Errorf(errMsg)
@@ -198,6 +267,19 @@ func (t *EnclaveChaincode) endorse(stub shim.ChaincodeStubInterface) pb.Response
 		return shim.Error(err.Error())
 	}
 
+	// emit encrypted event, if any; the event payload is the signed response message, so subscribers can verify
+	// the event against the enclave credentials before decrypting it
+	if event := responseMsg.GetChaincodeEvent(); event != nil {
+		logger.Debugf("Setting encrypted event %s", event.EventName)
+		eventPayload, err := protoutil.Marshal(signedResponseMsg)
+		if err != nil {
+			return shim.Error(fmt.Sprintf("cannot marshal event payload: %s", err.Error()))
+		}
+		if err := stub.SetEvent(event.EventName, eventPayload); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
 	logger.Debugf("Endorsement successful")
 	return shim.Success([]byte("OK")) // make sure we have a non-empty return on success so we can distinguish success from failure in cli ...
 }
@@ -15,7 +15,10 @@ import (
 	"unsafe"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -32,6 +35,7 @@ type EnclaveStub struct {
 	eid           C.enclave_id_t
 	sem           *semaphore.Weighted
 	isInitialized bool
+	enclaveId     string
 }
 
 // NewEnclave starts a new enclave
@@ -84,8 +88,21 @@ func (e *EnclaveStub) Init(chaincodeParams, hostParams, attestationParams []byte
 
 	e.isInitialized = true
 
+	credentialsBytes := C.GoBytes(credentialsBuffer, C.int(credentialsSize))
+
+	// remember enclave id
+	credentials := &protos.Credentials{}
+	if err := proto.Unmarshal(credentialsBytes, credentials); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal credentials: %s", err)
+	}
+	attestedData := &protos.AttestedData{}
+	if err := ptypes.UnmarshalAny(credentials.SerializedAttestedData, attestedData); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal attested data: %s", err)
+	}
+	e.enclaveId = utils.GetEnclaveId(attestedData)
+
 	// return credential bytes from sgx call
-	return C.GoBytes(credentialsBuffer, C.int(credentialsSize)), nil
+	return credentialsBytes, nil
 }
 
 func (e *EnclaveStub) GenerateCCKeys() ([]byte, error) {
@@ -101,14 +118,14 @@ func (e *EnclaveStub) ImportCCKeys() ([]byte, error) {
 }
 
 func (e *EnclaveStub) GetEnclaveId() (string, error) {
-	panic("implement me")
+	if !e.isInitialized {
+		return "", fmt.Errorf("enclave not yet initialized")
+	}
+	return e.enclaveId, nil
 }
 
 // ChaincodeInvoke calls the enclave for transaction processing
 func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, crmProtoBytes []byte) ([]byte, error) {
-	// Estimate of the buffer length where the enclave will write the response.
-	const scresmProtoBytesMaxLen = 1024 * 100 // Let's be really conservative ...
-
 	if !e.isInitialized {
 		return nil, fmt.Errorf("enclave not yet initialized")
 	}
@@ -132,7 +149,7 @@ func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, crmProto
 
 	// prep response
 	scresmProtoBytesLenOut := C.uint32_t(0) // We pass maximal length separately; set to zero so we can detect valid responses
-	scresmProtoBytesPtr := C.malloc(scresmProtoBytesMaxLen)
+	scresmProtoBytesPtr := C.malloc(MaxSignedResponseSize)
 	defer C.free(scresmProtoBytesPtr)
 
 	crmProtoBytesPtr := C.CBytes(crmProtoBytes)
@@ -149,12 +166,17 @@ func (e *EnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, crmProto
 		(C.uint32_t)(len(signedProposalBytes)),
 		(*C.uint8_t)(crmProtoBytesPtr),
 		(C.uint32_t)(len(crmProtoBytes)),
-		(*C.uint8_t)(scresmProtoBytesPtr), (C.uint32_t)(scresmProtoBytesMaxLen), &scresmProtoBytesLenOut,
+		(*C.uint8_t)(scresmProtoBytesPtr), (C.uint32_t)(MaxSignedResponseSize), &scresmProtoBytesLenOut,
 		ctx)
 	e.sem.Release(1)
-	if invokeRet != 0 {
+	switch invokeRet {
+	case 0:
+		return C.GoBytes(scresmProtoBytesPtr, C.int(scresmProtoBytesLenOut)), nil
+	case C.FPC_ERROR_CHAINCODE_FAILED:
+		return nil, ErrChaincodeFailed
+	case C.FPC_ERROR_RESPONSE_TOO_LARGE:
+		return nil, fmt.Errorf("%w (response buffer of %d bytes)", ErrResponseTooLarge, MaxSignedResponseSize)
+	default:
 		return nil, fmt.Errorf("invoke failed. Reason: %d", int(invokeRet))
 	}
-
-	return C.GoBytes(scresmProtoBytesPtr, C.int(scresmProtoBytesLenOut)), nil
 }
@@ -8,12 +8,27 @@ SPDX-License-Identifier: Apache-2.0
 package enclave
 
 import (
+	"errors"
+
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric/common/flogging"
 )
 
 var logger = flogging.MustGetLogger("enclave")
 
+// ErrChaincodeFailed is returned by ChaincodeInvoke if a request of a batch failed. The whole batch is aborted, i.e.,
+// none of its requests takes effect.
+var ErrChaincodeFailed = errors.New("chaincode invocation failed")
+
+// ErrResponseTooLarge is returned by ChaincodeInvoke if the response exceeds the response buffer, e.g., as a batch has
+// too many requests
+var ErrResponseTooLarge = errors.New("response exceeds the maximum response size")
+
+// MaxSignedResponseSize is the size of the buffer where the enclave writes the signed response of ChaincodeInvoke.
+// Besides the encrypted, base64 encoded and padded chaincode response, the signed response contains the read/writeset,
+// the event and the signature; the enclave reserves about a quarter of the buffer for these and the base64 encoding.
+const MaxSignedResponseSize = 1024 * 100
+
 type StubInterface interface {
 
 	// Init initializes the chaincode enclave.
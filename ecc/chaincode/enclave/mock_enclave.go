@@ -31,6 +31,7 @@ type MockEnclaveStub struct {
 	publicKey    []byte
 	enclaveId    string
 	ccPrivateKey []byte
+	ccParams     *protos.CCParameters
 }
 
 // NewEnclave starts a new enclave
@@ -66,6 +67,7 @@ func (m *MockEnclaveStub) Init(serializedChaincodeParams, serializedHostParamsBy
 		return nil, err
 	}
 	m.ccPrivateKey = ccPrivateKey
+	m.ccParams = chaincodeParams
 
 	// calculate enclave id
 	m.enclaveId, _ = m.GetEnclaveId()
@@ -104,6 +106,9 @@ func (m MockEnclaveStub) ImportCCKeys() ([]byte, error) {
 }
 
 func (m *MockEnclaveStub) GetEnclaveId() (string, error) {
+	if m.publicKey == nil {
+		return "", fmt.Errorf("enclave not yet initialized")
+	}
 	hash := sha256.Sum256(m.publicKey)
 	return strings.ToUpper(hex.EncodeToString(hash[:])), nil
 }
@@ -152,11 +157,24 @@ func (m *MockEnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, chai
 		return nil, fmt.Errorf("no response encryption key")
 	}
 
+	// if the client sent a request nonce, request and response are bound to channel, chaincode and nonce
+	var requestAssociatedData, responseAssociatedData []byte
+	if nonce := keyTransportMessage.GetRequestNonce(); len(nonce) > 0 {
+		requestAssociatedData = crypto.RequestAssociatedData(m.ccParams.GetChannelId(), m.ccParams.GetChaincodeId(), nonce)
+		responseAssociatedData = crypto.ResponseAssociatedData(m.ccParams.GetChannelId(), m.ccParams.GetChaincodeId(), nonce)
+	}
+
 	// decrypt request
-	clearChaincodeRequestBytes, err := crypto.DecryptMessage(keyTransportMessage.GetRequestEncryptionKey(), chaincodeRequestMessage.GetEncryptedRequest())
+	clearChaincodeRequestBytes, err := crypto.DecryptMessageWithAAD(keyTransportMessage.GetRequestEncryptionKey(), chaincodeRequestMessage.GetEncryptedRequest(), requestAssociatedData)
 	if err != nil {
 		return nil, errors.Wrap(err, "decryption of request failed")
 	}
+	if keyTransportMessage.GetRequestPadding() != nil {
+		clearChaincodeRequestBytes, err = crypto.UnpadMessage(clearChaincodeRequestBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid request padding")
+		}
+	}
 
 	cleartextChaincodeRequest := &protos.CleartextChaincodeRequest{}
 	err = proto.Unmarshal(clearChaincodeRequestBytes, cleartextChaincodeRequest)
@@ -193,11 +211,26 @@ func (m *MockEnclaveStub) ChaincodeInvoke(stub shim.ChaincodeStubInterface, chai
 	//create dummy response
 	responseData := []byte("some response")
 
+	// a batch request gets a dummy response for each request of the batch
+	if len(cleartextChaincodeRequest.GetBatchInputs()) > 0 {
+		batchResponse := &protos.CleartextChaincodeBatchResponse{}
+		for range cleartextChaincodeRequest.GetBatchInputs() {
+			batchResponse.Responses = append(batchResponse.Responses, responseData)
+		}
+		responseData, err = proto.Marshal(batchResponse)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	//response must be encoded
-	b64ResponseData := base64.StdEncoding.EncodeToString(responseData)
+	b64ResponseData := []byte(base64.StdEncoding.EncodeToString(responseData))
+	if keyTransportMessage.GetResponsePadding() != nil {
+		b64ResponseData = crypto.PadMessage(b64ResponseData, keyTransportMessage.GetResponsePadding())
+	}
 
 	//encrypt response
-	encryptedResponse, err := crypto.EncryptMessage(keyTransportMessage.GetResponseEncryptionKey(), []byte(b64ResponseData))
+	encryptedResponse, err := crypto.EncryptMessageWithAAD(keyTransportMessage.GetResponseEncryptionKey(), b64ResponseData, responseAssociatedData)
 	if err != nil {
 		return nil, err
 	}
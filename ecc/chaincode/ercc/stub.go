@@ -8,6 +8,7 @@ SPDX-License-Identifier: Apache-2.0
 package ercc
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/hyperledger/fabric-chaincode-go/shim"
@@ -26,3 +27,23 @@ func QueryEnclaveCredentials(stub shim.ChaincodeStubInterface, channelId, chainc
 
 	return utils.UnmarshalCredentials(string(resp.Payload))
 }
+
+func QueryListProvisionedEnclaves(stub shim.ChaincodeStubInterface, channelId, chaincodeId string) ([]string, error) {
+	args := [][]byte{[]byte("queryListProvisionedEnclaves"), []byte(chaincodeId)}
+
+	resp := stub.InvokeChaincode("ercc", args, channelId)
+	if resp.Status != shim.OK {
+		return nil, fmt.Errorf("error: %s", resp.Message)
+	}
+
+	var enclaveIds []string
+	if len(resp.Payload) == 0 {
+		return enclaveIds, nil
+	}
+
+	if err := json.Unmarshal(resp.Payload, &enclaveIds); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal provisioned enclaves: %s", err)
+	}
+
+	return enclaveIds, nil
+}
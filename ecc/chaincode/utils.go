@@ -9,8 +9,10 @@ package chaincode
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
@@ -109,3 +111,28 @@ func extractChaincodeResponseMessages(stub shim.ChaincodeStubInterface) (*protos
 
 	return signedResponseMsg, responseMsg, err
 }
+
+// extractCredentialsInfo returns the attested data and the attestation type of serialized credentials
+func extractCredentialsInfo(credentialsBytes []byte) (*protos.AttestedData, string, error) {
+	credentials := &protos.Credentials{}
+	err := proto.Unmarshal(credentialsBytes, credentials)
+	if err != nil {
+		return nil, "", err
+	}
+
+	attestedData := &protos.AttestedData{}
+	err = ptypes.UnmarshalAny(credentials.SerializedAttestedData, attestedData)
+	if err != nil {
+		return nil, "", err
+	}
+
+	attestation := struct {
+		Type string `json:"attestation_type"`
+	}{}
+	err = json.Unmarshal(credentials.Attestation, &attestation)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot unmarshal attestation: %s", err)
+	}
+
+	return attestedData, attestation.Type, nil
+}
@@ -6,6 +6,7 @@
  */
 
 #include "crypto.h"
+#include "crypto/pdo-crypto-c-wrapper.h"
 #include "error.h"
 #include "logging.h"
 #include "pdo/common/crypto/crypto.h"
@@ -54,6 +55,86 @@ err:
     return false;
 }
 
+static void append_length_prefixed(ByteArray& out, const uint8_t* value, uint32_t length)
+{
+    out.push_back((uint8_t)(length >> 24));
+    out.push_back((uint8_t)(length >> 16));
+    out.push_back((uint8_t)(length >> 8));
+    out.push_back((uint8_t)length);
+    out.insert(out.end(), value, value + length);
+}
+
+bool build_associated_data(const std::string& label,
+    const std::string& channel_id,
+    const std::string& chaincode_id,
+    const ByteArray& request_nonce,
+    ByteArray& associated_data)
+{
+    bool b;
+    CATCH(b, {
+        associated_data.clear();
+        append_length_prefixed(associated_data, (const uint8_t*)label.c_str(), label.length());
+        append_length_prefixed(
+            associated_data, (const uint8_t*)channel_id.c_str(), channel_id.length());
+        append_length_prefixed(
+            associated_data, (const uint8_t*)chaincode_id.c_str(), chaincode_id.length());
+        append_length_prefixed(associated_data, request_nonce.data(), request_nonce.size());
+    });
+    COND2LOGERR(!b, "cannot build associated data");
+
+    return true;
+
+err:
+    return false;
+}
+
+bool decrypt_message_with_aad(const ByteArray key,
+    const ByteArray& encrypted_message,
+    const ByteArray& associated_data,
+    ByteArray& message)
+{
+    bool b;
+    uint32_t message_len = 0;
+    COND2LOGERR(!validate_key_length(key), "invalid decryption key length");
+    CATCH(b, message.resize(encrypted_message.size()));
+    COND2LOGERR(!b, "cannot allocate message");
+
+    b = ::decrypt_message_with_aad((uint8_t*)key.data(), key.size(),
+        (uint8_t*)encrypted_message.data(), encrypted_message.size(),
+        (uint8_t*)associated_data.data(), associated_data.size(), message.data(), message.size(),
+        &message_len);
+    COND2LOGERR(!b, "message decryption failed");
+    message.resize(message_len);
+
+    return true;
+
+err:
+    return false;
+}
+
+bool encrypt_message_with_aad(const ByteArray key,
+    const ByteArray& message,
+    const ByteArray& associated_data,
+    ByteArray& encrypted_message)
+{
+    bool b;
+    uint32_t encrypted_message_len = 0;
+    COND2LOGERR(!validate_key_length(key), "invalid encryption key length");
+    CATCH(b, encrypted_message.resize(message.size() + IV_LEN + TAG_LEN));
+    COND2LOGERR(!b, "cannot allocate encrypted message");
+
+    b = ::encrypt_message_with_aad((uint8_t*)key.data(), key.size(), (uint8_t*)message.data(),
+        message.size(), (uint8_t*)associated_data.data(), associated_data.size(),
+        encrypted_message.data(), encrypted_message.size(), &encrypted_message_len);
+    COND2LOGERR(!b, "message encryption failed");
+    encrypted_message.resize(encrypted_message_len);
+
+    return true;
+
+err:
+    return false;
+}
+
 bool compute_message_hash(const ByteArray message, ByteArray& message_hash)
 {
     bool b;
@@ -65,3 +146,83 @@ bool compute_message_hash(const ByteArray message, ByteArray& message_hash)
 err:
     return false;
 }
+
+bool padded_size(
+    size_t size, const uint32_t* bucket_sizes, size_t bucket_sizes_count, size_t& padded)
+{
+    uint64_t min_size = (uint64_t)size + 1;
+    uint64_t best = 0;
+    uint64_t largest = 0;
+    uint64_t result;
+
+    for (size_t i = 0; i < bucket_sizes_count; i++)
+    {
+        uint64_t bucket = bucket_sizes[i];
+        if (bucket >= min_size && (best == 0 || bucket < best))
+        {
+            best = bucket;
+        }
+        if (bucket > largest)
+        {
+            largest = bucket;
+        }
+    }
+
+    if (best != 0)
+    {
+        result = best;
+    }
+    else if (largest != 0)
+    {
+        result = (min_size + largest - 1) / largest * largest;
+    }
+    else
+    {
+        result = 1;
+        while (result < min_size)
+        {
+            result <<= 1;
+        }
+    }
+    COND2LOGERR(result > MAX_PADDED_SIZE, "padded size too large");
+
+    padded = (size_t)result;
+    return true;
+
+err:
+    return false;
+}
+
+bool pad_message(ByteArray& message, const uint32_t* bucket_sizes, size_t bucket_sizes_count)
+{
+    bool b;
+    size_t padded;
+    size_t size = message.size();
+
+    b = padded_size(size, bucket_sizes, bucket_sizes_count, padded);
+    COND2LOGERR(!b, "cannot compute padded size");
+    CATCH(b, message.resize(padded, 0x00));
+    COND2LOGERR(!b, "cannot allocate padded message");
+    message[size] = 0x80;
+
+    return true;
+
+err:
+    return false;
+}
+
+bool unpad_message(ByteArray& message)
+{
+    size_t i = message.size();
+    while (i > 0 && message[i - 1] == 0x00)
+    {
+        i--;
+    }
+    COND2LOGERR(i == 0 || message[i - 1] != 0x80, "invalid padding");
+    message.resize(i - 1);
+
+    return true;
+
+err:
+    return false;
+}
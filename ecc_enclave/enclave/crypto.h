@@ -7,6 +7,7 @@
 
 #pragma once
 
+#include <string>
 #include <vector>
 #include "fpc-types.h"
 
@@ -20,4 +21,40 @@ bool decrypt_message(const ByteArray key, const ByteArray& encrypted_message, By
 
 bool encrypt_message(const ByteArray key, const ByteArray& message, ByteArray& encrypted_message);
 
+// associated data labels, see crypto.RequestAssociatedData and crypto.ResponseAssociatedData in go
+#define REQUEST_ASSOCIATED_DATA_LABEL "fpc-request"
+#define RESPONSE_ASSOCIATED_DATA_LABEL "fpc-response"
+
+// builds the associated data binding a request (or response) ciphertext to the channel, the chaincode and the
+// request nonce of the client. Each field is encoded as 4 bytes big-endian length followed by the value
+bool build_associated_data(const std::string& label,
+    const std::string& channel_id,
+    const std::string& chaincode_id,
+    const ByteArray& request_nonce,
+    ByteArray& associated_data);
+
+bool decrypt_message_with_aad(const ByteArray key,
+    const ByteArray& encrypted_message,
+    const ByteArray& associated_data,
+    ByteArray& message);
+
+bool encrypt_message_with_aad(const ByteArray key,
+    const ByteArray& message,
+    const ByteArray& associated_data,
+    ByteArray& encrypted_message);
+
 bool compute_message_hash(const ByteArray message, ByteArray& message_hash);
+
+// upper bound of the padded size of requests and responses
+#define MAX_PADDED_SIZE (1 << 24)
+
+// computes the padded size of a message as crypto.PaddedSize in go, i.e., the smallest bucket size larger than the
+// message, the next multiple of the largest bucket size, or, if there are no bucket sizes, the next power of two
+bool padded_size(
+    size_t size, const uint32_t* bucket_sizes, size_t bucket_sizes_count, size_t& padded);
+
+// pads a message with 0x80 followed by zero bytes up to the padded size (ISO/IEC 7816-4)
+bool pad_message(ByteArray& message, const uint32_t* bucket_sizes, size_t bucket_sizes_count);
+
+// removes the padding added by pad_message (or crypto.PadMessage in go)
+bool unpad_message(ByteArray& message);
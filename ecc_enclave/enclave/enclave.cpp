@@ -38,16 +38,26 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
     t_shim_ctx_t ctx;
     int ret;
     int invoke_ret;
+    // returned on failure; distinguishes failed batches and too large responses from other failures
+    int error_code = 1;
     // estimate max response len (take into account other fields and b64 encoding)
     uint32_t response_len = signed_cc_response_message_bytes_len_in / 4 * 3 - 1024;
     uint8_t response[signed_cc_response_message_bytes_len_in / 4 * 3];
     uint32_t response_len_out = 0;
+    std::vector<std::vector<std::string>> inputs;
+    std::vector<ByteArray> responses;
+    uint32_t responses_len = 0;
+    bool is_batch = false;
     std::string b64_response;
     ByteArray cc_response_message;
     size_t cc_response_message_estimated_size;
     ByteArray response_encryption_key;
+    // if the client sent a request nonce, request and response are bound to channel, chaincode and nonce
+    bool is_bound = false;
+    ByteArray request_nonce;
 
     ctx.u_shim_ctx = u_shim_ctx;
+    ctx.has_event = false;
 
     {
         pb_istream_t istream;
@@ -85,6 +95,15 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
             key_transport_message.response_encryption_key->bytes +
                 key_transport_message.response_encryption_key->size);
 
+        if (key_transport_message.request_nonce != NULL &&
+            key_transport_message.request_nonce->size > 0)
+        {
+            is_bound = true;
+            request_nonce = ByteArray(key_transport_message.request_nonce->bytes,
+                key_transport_message.request_nonce->bytes +
+                    key_transport_message.request_nonce->size);
+        }
+
         {  // decrypt request
             ByteArray request_encryption_key =
                 ByteArray(key_transport_message.request_encryption_key->bytes,
@@ -93,37 +112,148 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
             ByteArray encrypted_request = ByteArray(cc_request_message.encrypted_request->bytes,
                 cc_request_message.encrypted_request->bytes +
                     cc_request_message.encrypted_request->size);
-            b = decrypt_message(request_encryption_key, encrypted_request, clear_request);
+            if (is_bound)
+            {
+                ByteArray associated_data;
+                b = build_associated_data(REQUEST_ASSOCIATED_DATA_LABEL,
+                    g_cc_data->get_channel_id(), g_cc_data->get_chaincode_id(), request_nonce,
+                    associated_data);
+                COND2LOGERR(!b, "cannot build request associated data");
+                b = decrypt_message_with_aad(
+                    request_encryption_key, encrypted_request, associated_data, clear_request);
+            }
+            else
+            {
+                b = decrypt_message(request_encryption_key, encrypted_request, clear_request);
+            }
             COND2LOGERR(!b, "message decryption failed");
         }
 
+        if (key_transport_message.has_request_padding)
+        {
+            b = unpad_message(clear_request);
+            COND2LOGERR(!b, "invalid request padding");
+        }
+
         // set stream for CleartextChaincodeRequestMessage
         istream = pb_istream_from_buffer(
             (const unsigned char*)clear_request.data(), clear_request.size());
         b = pb_decode(&istream, fpc_CleartextChaincodeRequest_fields, &cleartext_cc_request);
         COND2LOGERR(!b, PB_GET_ERROR(&istream));
-        COND2LOGERR(!cleartext_cc_request.has_input, "no input in cleartext request");
 
-        // prepare input arguments
-        for (int i = 0; i < cleartext_cc_request.input.args_count; i++)
+        // prepare input arguments; a batch request carries one input per invocation
+        is_batch = cleartext_cc_request.batch_inputs_count > 0;
+        if (is_batch)
+        {
+            for (int i = 0; i < cleartext_cc_request.batch_inputs_count; i++)
+            {
+                protos_ChaincodeInput* input = &cleartext_cc_request.batch_inputs[i];
+                std::vector<std::string> args;
+                for (int j = 0; j < input->args_count; j++)
+                {
+                    args.push_back(
+                        std::string((const char*)input->args[j]->bytes, input->args[j]->size));
+                }
+                inputs.push_back(args);
+            }
+        }
+        else
         {
-            ctx.string_args.push_back(
-                std::string((const char*)cleartext_cc_request.input.args[i]->bytes,
+            COND2LOGERR(!cleartext_cc_request.has_input, "no input in cleartext request");
+            std::vector<std::string> args;
+            for (int i = 0; i < cleartext_cc_request.input.args_count; i++)
+            {
+                args.push_back(std::string((const char*)cleartext_cc_request.input.args[i]->bytes,
                     cleartext_cc_request.input.args[i]->size));
+            }
+            inputs.push_back(args);
         }
 
         // the dynamic memory in the message is released at the end
     }
 
-    invoke_ret = invoke(response, response_len, &response_len_out, &ctx);
-    // invoke_ret is not checked
+    // invoke the chaincode once per input; all invocations share the read/writeset in ctx
+    for (int i = 0; i < inputs.size(); i++)
+    {
+        LOG_DEBUG("invoking chaincode with input %d of %d", i + 1, (int)inputs.size());
+        ctx.string_args = inputs[i];
+        response_len_out = 0;
+        invoke_ret = invoke(response, response_len, &response_len_out, &ctx);
+        if (is_batch && invoke_ret != 0)
+        {
+            // as all requests of a batch share the read/writeset, either all or none take effect
+            LOG_ERROR("request %d of %d of the batch failed", i + 1, (int)inputs.size());
+            error_code = FPC_ERROR_CHAINCODE_FAILED;
+            goto err;
+        }
+        // note that invoke_ret is not checked for single requests, their response is forwarded
+        responses.push_back(ByteArray(response, response + response_len_out));
+
+        // all responses of a batch must fit into the single response buffer
+        responses_len += response_len_out;
+        if (responses_len > response_len)
+        {
+            LOG_ERROR("responses of %d requests exceed the maximum response size of %u bytes",
+                i + 1, response_len);
+            error_code = FPC_ERROR_RESPONSE_TOO_LARGE;
+            goto err;
+        }
+    }
 
     // TODO double check or rethink if it is appropriate for a chaincode
     // to return an error and still forward the response
     // in particular: should the enclave sign a response? and the rwset? could the tx be committed
     // though it failed?
 
-    b64_response = base64_encode((const unsigned char*)response, response_len_out);
+    if (is_batch)
+    {  // encode batch response
+        fpc_CleartextChaincodeBatchResponse batch_response = {};
+        ByteArray batch_response_bytes;
+        size_t batch_response_size;
+        pb_ostream_t ostream;
+
+        batch_response.responses =
+            (pb_bytes_array_t**)pb_realloc(NULL, responses.size() * sizeof(pb_bytes_array_t*));
+        COND2LOGERR(batch_response.responses == NULL, "cannot allocate batch response");
+        for (int i = 0; i < responses.size(); i++)
+        {
+            batch_response.responses[i] = (pb_bytes_array_t*)pb_realloc(
+                NULL, PB_BYTES_ARRAY_T_ALLOCSIZE(responses[i].size()));
+            COND2LOGERR(batch_response.responses[i] == NULL, "cannot allocate batch response");
+            batch_response.responses_count = i + 1;
+            batch_response.responses[i]->size = responses[i].size();
+            ret = memcpy_s(batch_response.responses[i]->bytes, batch_response.responses[i]->size,
+                responses[i].data(), responses[i].size());
+            COND2LOGERR(ret != 0, "cannot encode batch response");
+        }
+
+        b = pb_get_encoded_size(
+            &batch_response_size, fpc_CleartextChaincodeBatchResponse_fields, &batch_response);
+        COND2LOGERR(!b, "cannot estimate batch response size");
+        if (batch_response_size > response_len)
+        {
+            pb_release(fpc_CleartextChaincodeBatchResponse_fields, &batch_response);
+            LOG_ERROR("batch response of %u bytes exceeds the maximum response size of %u bytes",
+                (uint32_t)batch_response_size, response_len);
+            error_code = FPC_ERROR_RESPONSE_TOO_LARGE;
+            goto err;
+        }
+        CATCH(b, batch_response_bytes.resize(batch_response_size));
+        COND2LOGERR(!b, "cannot allocate batch response buffer");
+        ostream = pb_ostream_from_buffer(batch_response_bytes.data(), batch_response_bytes.size());
+        b = pb_encode(&ostream, fpc_CleartextChaincodeBatchResponse_fields, &batch_response);
+        COND2LOGERR(!b, "error encoding batch response");
+
+        pb_release(fpc_CleartextChaincodeBatchResponse_fields, &batch_response);
+
+        b64_response = base64_encode(
+            (const unsigned char*)batch_response_bytes.data(), batch_response_bytes.size());
+    }
+    else
+    {
+        b64_response =
+            base64_encode((const unsigned char*)responses[0].data(), responses[0].size());
+    }
 
     {
         // TODO put response in protobuf and encode it
@@ -141,7 +271,34 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
         {  // encrypt response
             ByteArray response =
                 ByteArray(b64_response.c_str(), b64_response.c_str() + b64_response.length());
-            b = encrypt_message(response_encryption_key, response, encrypted_response);
+            if (key_transport_message.has_response_padding)
+            {
+                b = pad_message(response, key_transport_message.response_padding.bucket_sizes,
+                    key_transport_message.response_padding.bucket_sizes_count);
+                COND2LOGERR(!b, "cannot pad response");
+            }
+            // the padding may grow the response beyond the maximum response size checked above
+            if (response.size() > (response_len + 2) / 3 * 4)
+            {
+                LOG_ERROR("padded response of %u bytes exceeds the maximum of %u bytes",
+                    (uint32_t)response.size(), (response_len + 2) / 3 * 4);
+                error_code = FPC_ERROR_RESPONSE_TOO_LARGE;
+                goto err;
+            }
+            if (is_bound)
+            {
+                ByteArray associated_data;
+                b = build_associated_data(RESPONSE_ASSOCIATED_DATA_LABEL,
+                    g_cc_data->get_channel_id(), g_cc_data->get_chaincode_id(), request_nonce,
+                    associated_data);
+                COND2LOGERR(!b, "cannot build response associated data");
+                b = encrypt_message_with_aad(
+                    response_encryption_key, response, associated_data, encrypted_response);
+            }
+            else
+            {
+                b = encrypt_message(response_encryption_key, response, encrypted_response);
+            }
             COND2LOGERR(!b, "cannot encrypt response message");
         }
 
@@ -206,6 +363,27 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
             rwset_to_proto(&ctx, &crm.fpc_rw_set);
         }
 
+        if (ctx.has_event)
+        {  // fill encrypted event
+            crm.has_chaincode_event = true;
+            crm.chaincode_event.event_name = (char*)pb_realloc(NULL, ctx.event_name.length() + 1);
+            COND2LOGERR(crm.chaincode_event.event_name == NULL, "cannot allocate event name");
+            ret = memcpy_s(crm.chaincode_event.event_name, ctx.event_name.length(),
+                ctx.event_name.c_str(), ctx.event_name.length());
+            crm.chaincode_event.event_name[ctx.event_name.length()] = '\0';
+            COND2LOGERR(ret != 0, "cannot encode event name");
+
+            crm.chaincode_event.encrypted_payload = (pb_bytes_array_t*)pb_realloc(
+                NULL, PB_BYTES_ARRAY_T_ALLOCSIZE(ctx.encrypted_event_payload.size()));
+            COND2LOGERR(
+                crm.chaincode_event.encrypted_payload == NULL, "cannot allocate event payload");
+            crm.chaincode_event.encrypted_payload->size = ctx.encrypted_event_payload.size();
+            ret = memcpy_s(crm.chaincode_event.encrypted_payload->bytes,
+                crm.chaincode_event.encrypted_payload->size, ctx.encrypted_event_payload.data(),
+                ctx.encrypted_event_payload.size());
+            COND2LOGERR(ret != 0, "cannot encode event payload");
+        }
+
         // estimate response message size
         b = pb_get_encoded_size(
             &cc_response_message_estimated_size, fpc_ChaincodeResponseMessage_fields, &crm);
@@ -255,6 +433,20 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
             signature.size());
         COND2LOGERR(ret != 0, "cannot encode field");
 
+        // the read/writeset and the event may still exceed the space left in the response buffer
+        size_t signed_crm_size;
+        b = pb_get_encoded_size(
+            &signed_crm_size, fpc_SignedChaincodeResponseMessage_fields, &signed_crm);
+        COND2LOGERR(!b, "cannot estimate signed response message size");
+        if (signed_crm_size > signed_cc_response_message_bytes_len_in)
+        {
+            pb_release(fpc_SignedChaincodeResponseMessage_fields, &signed_crm);
+            LOG_ERROR("signed response message of %u bytes exceeds the response buffer of %u bytes",
+                (uint32_t)signed_crm_size, signed_cc_response_message_bytes_len_in);
+            error_code = FPC_ERROR_RESPONSE_TOO_LARGE;
+            goto err;
+        }
+
         // encode proto
         ostream = pb_ostream_from_buffer(
             signed_cc_response_message_bytes, signed_cc_response_message_bytes_len_in);
@@ -268,6 +460,8 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
 
     // release dynamic allocations (TODO:release in case of error)
     pb_release(fpc_ChaincodeRequestMessage_fields, &cc_request_message);
+    pb_release(fpc_CleartextChaincodeRequest_fields, &cleartext_cc_request);
+    pb_release(fpc_KeyTransportMessage_fields, &key_transport_message);
 
     // TODO: generate signature (as short-cut for now over proposal _and_ args with consistency of
     // proposal and args verified in "__endorse" rather than enclave)
@@ -276,5 +470,5 @@ int ecall_cc_invoke(const uint8_t* signed_proposal_proto_bytes,
 
 err:
     *signed_cc_response_message_bytes_len_out = 0;
-    return 1;
+    return error_code;
 }
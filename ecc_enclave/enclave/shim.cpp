@@ -19,6 +19,7 @@
 #include "sgx_thread.h"
 
 #include <mbusafecrt.h> /* for memcpy_s etc */
+#include <string.h>
 #include "cc_data.h"
 #include "error.h"
 
@@ -255,6 +256,38 @@ void get_public_state_by_partial_composite_key(
     unmarshal_values(values, (const char*)json, len);
 }
 
+int set_encrypted_event(const char* event_name,
+    const uint8_t* payload,
+    uint32_t payload_len,
+    const uint8_t* event_key,
+    uint32_t event_key_len,
+    shim_ctx_ptr_t ctx)
+{
+    bool b;
+    ByteArray encrypted_payload;
+
+    COND2LOGERR(event_name == NULL || strlen(event_name) == 0, "empty event name");
+    COND2LOGERR(payload == NULL && payload_len > 0, "invalid event payload");
+    COND2LOGERR(event_key == NULL, "no event key");
+
+    {
+        ByteArray key(event_key, event_key + event_key_len);
+        COND2LOGERR(!validate_key_length(key), "invalid event key length");
+
+        ByteArray message(payload, payload + payload_len);
+        b = encrypt_message(key, message, encrypted_payload);
+        COND2LOGERR(!b, "cannot encrypt event payload");
+    }
+
+    ctx->has_event = true;
+    ctx->event_name = std::string(event_name);
+    ctx->encrypted_event_payload = encrypted_payload;
+    return 1;
+
+err:
+    return -1;
+}
+
 int get_string_args(std::vector<std::string>& argss, shim_ctx_ptr_t ctx)
 {
     argss = ctx->string_args;
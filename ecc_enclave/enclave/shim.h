@@ -50,6 +50,12 @@ typedef struct t_shim_ctx* shim_ctx_ptr_t;
 // Function which FPC chaincode has to implement
 // ==================================================
 // - invoke, called when a transaction query or invocation is executed
+//   Note:
+//   - for a batch invocation, invoke is called once per request of the batch, in order,
+//     with the same ctx. That is, all requests of a batch form a single transaction with a
+//     common read/writeset. As in Fabric, get_state does not return the values written
+//     by put_state in the same transaction, i.e., also not those written by earlier requests
+//     of the same batch.
 int invoke(uint8_t* response,
     uint32_t max_response_len,
     uint32_t* actual_response_len,
@@ -165,6 +171,28 @@ void get_public_state_by_partial_composite_key(
 //
 // - other functions: {get,set}StateValidationParameter, getHistoryForKey. Can/should we ignore?
 
+// events
+//-------------------------------------------------
+// - set an event with name event_name which is emitted once the transaction is committed.
+//   The payload located at payload of size payload_len is encrypted with the (symmetric)
+//   event_key of size event_key_len. The chaincode is responsible to choose a key the intended
+//   subscribers can derive, e.g., a per-subscriber key registered in the chaincode state or
+//   a chaincode-level event key shared with authorized clients.
+//   The encrypted event is part of the signed chaincode response and hence can be verified by
+//   clients against the enclave's credentials registered at ERCC.
+//   Note:
+//   - as with Fabric, only a single event per transaction is supported; calling this function
+//     again overrides a previously set event.
+//   - the event name remains in clear text, so care has to be taken by the programmer that
+//     it doesn't leak anything sensitive!
+//   Returns -1 if the event cannot be set, e.g., due to an invalid key, 1 otherwise.
+int set_encrypted_event(const char* event_name,
+    const uint8_t* payload,
+    uint32_t payload_len,
+    const uint8_t* event_key,
+    uint32_t event_key_len,
+    shim_ctx_ptr_t ctx);
+
 // retrieval for arguments
 //-------------------------------------------------
 // - retrieve the list of invocation parameters
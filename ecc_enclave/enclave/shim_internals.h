@@ -24,6 +24,9 @@ typedef struct t_shim_ctx
     read_set_t read_set;
     write_set_t write_set;
     std::vector<std::string> string_args;
+    bool has_event;
+    std::string event_name;
+    ByteArray encrypted_event_payload;
 } t_shim_ctx_t;
 
 #include "fpc.pb.h"
@@ -0,0 +1,103 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/pkg/errors"
+)
+
+// GoVerifier is a pure go implementation of the VerifierInterface which, as the C verify_evidence, supports
+// simulated and EPID (IAS report) evidence; furthermore, it supports DCAP evidence.
+type GoVerifier struct {
+	allowSimulated bool
+	ias            *fpcattestation.IASVerifier
+	dcap           *fpcattestation.DcapVerifier
+}
+
+type GoVerifierOption func(*GoVerifier)
+
+// WithSimulatedAttestation option accepts simulated evidence. This must only be used in SGX simulation mode.
+func WithSimulatedAttestation() GoVerifierOption {
+	return func(v *GoVerifier) {
+		v.allowSimulated = true
+	}
+}
+
+// WithIASVerifier option sets the verifier for EPID evidence. Without it, EPID evidence is rejected.
+func WithIASVerifier(ias *fpcattestation.IASVerifier) GoVerifierOption {
+	return func(v *GoVerifier) {
+		v.ias = ias
+	}
+}
+
+// WithDcapVerifier option allows to override the default verifier for DCAP evidence
+func WithDcapVerifier(dcap *fpcattestation.DcapVerifier) GoVerifierOption {
+	return func(v *GoVerifier) {
+		v.dcap = dcap
+	}
+}
+
+// NewGoVerifier returns a new GoVerifier. By default, only DCAP evidence is accepted.
+// Optionally, GoVerifierOption can be provided to change the behavior of the GoVerifier.
+func NewGoVerifier(opts ...GoVerifierOption) *GoVerifier {
+	verifier := &GoVerifier{}
+
+	// apply options
+	for _, opt := range opts {
+		opt(verifier)
+	}
+
+	if verifier.dcap == nil {
+		verifier.dcap = fpcattestation.NewDcapVerifier()
+	}
+
+	return verifier
+}
+
+func (v *GoVerifier) VerifyEvidence(evidenceBytes []byte, expectedStatementBytes []byte, expectedMrEnclave string, policy *fpcattestation.VerificationPolicy, now time.Time) error {
+	evidence := &struct {
+		Type *string `json:"attestation_type"`
+		Data *string `json:"evidence"`
+	}{}
+	if err := json.Unmarshal(evidenceBytes, evidence); err != nil {
+		return errors.Wrap(err, "invalid input")
+	}
+	if evidence.Type == nil {
+		return errors.New("no attestation type")
+	}
+	if evidence.Data == nil {
+		return errors.New("no evidence field")
+	}
+
+	var err error
+	switch *evidence.Type {
+	case fpcattestation.SimulatedType:
+		if !v.allowSimulated {
+			err = errors.New("simulated attestation is not accepted")
+		}
+	case fpcattestation.EpidLinkableType, fpcattestation.EpidUnlinkableType:
+		if v.ias == nil {
+			err = errors.New("no IAS root certificates configured")
+		} else {
+			err = v.ias.At(now).VerifyEvidence([]byte(*evidence.Data), expectedStatementBytes, expectedMrEnclave, policy)
+		}
+	case fpcattestation.DcapType:
+		err = v.dcap.At(now).VerifyEvidence([]byte(*evidence.Data), expectedStatementBytes, expectedMrEnclave, policy)
+	default:
+		err = fmt.Errorf("bad attestation type '%s'", *evidence.Type)
+	}
+	if err != nil {
+		return errors.Wrap(err, "evidence verification failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the recorded fixtures of the attestation conversion
+const fixturesPath = "../../internal/attestation/testdata"
+
+// the test vectors of the C verify_evidence, see attested_evidence_test.sh
+const verifyEvidenceVectorsPath = "../../common/crypto/attestation-api/test/vectors"
+
+func wrapEvidence(t *testing.T, attestationType string, evidence []byte) []byte {
+	wrapped, err := json.Marshal(map[string]string{
+		"attestation_type": attestationType,
+		"evidence":         string(evidence),
+	})
+	require.NoError(t, err)
+	return wrapped
+}
+
+func hashHex(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func TestGoVerifierSimulated(t *testing.T) {
+	evidence := []byte(`{"attestation_type":"simulated","evidence":"MA=="}`)
+
+	// as with verify_evidence, statement and mrenclave are not checked for simulated evidence
+	verifier := NewGoVerifier(WithSimulatedAttestation())
+	assert.NoError(t, verifier.VerifyEvidence(evidence, []byte("also ignored"), "this is ignored", nil, time.Now()))
+
+	verifier = NewGoVerifier()
+	assert.EqualError(t, verifier.VerifyEvidence(evidence, []byte("also ignored"), "this is ignored", nil, time.Now()),
+		"evidence verification failed: simulated attestation is not accepted")
+}
+
+func TestGoVerifierEpid(t *testing.T) {
+	const statement = "1234567890"
+	mrenclave := hashHex("test ias enclave")
+
+	iasReport, err := ioutil.ReadFile(filepath.Join(fixturesPath, "ias", "evidence_ok.json"))
+	require.NoError(t, err)
+	roots, err := fpcattestation.LoadIASRootCertificates(filepath.Join(fixturesPath, "ias", "root_ca.pem"))
+	require.NoError(t, err)
+
+	verifier := NewGoVerifier(WithIASVerifier(fpcattestation.NewIASVerifier(roots)))
+	for _, attestationType := range []string{fpcattestation.EpidLinkableType, fpcattestation.EpidUnlinkableType} {
+		evidence := wrapEvidence(t, attestationType, iasReport)
+		assert.NoError(t, verifier.VerifyEvidence(evidence, []byte(statement), mrenclave, nil, time.Now()))
+		assert.Error(t, verifier.VerifyEvidence(evidence, []byte("wrong statement"), mrenclave, nil, time.Now()))
+		assert.Error(t, verifier.VerifyEvidence(evidence, []byte(statement), "BADBADBADBAD9E317C4F7312A0D644FFC052F7645350564D43586D8102663358", nil, time.Now()))
+	}
+
+	// EPID evidence is rejected without IAS root certificates
+	evidence := wrapEvidence(t, fpcattestation.EpidLinkableType, iasReport)
+	assert.EqualError(t, NewGoVerifier().VerifyEvidence(evidence, []byte(statement), mrenclave, nil, time.Now()),
+		"evidence verification failed: no IAS root certificates configured")
+}
+
+func TestGoVerifierDcap(t *testing.T) {
+	const statement = "test statement"
+	mrenclave := hashHex("test enclave")
+
+	dcapPath := filepath.Join(fixturesPath, "dcap")
+	quote, err := ioutil.ReadFile(filepath.Join(dcapPath, "quote.txt"))
+	require.NoError(t, err)
+	dcapEvidence, err := fpcattestation.NewDcapConverter(fpcattestation.NewLocalCollateralProvider(dcapPath)).Converter(quote)
+	require.NoError(t, err)
+	rootCA, err := ioutil.ReadFile(filepath.Join(dcapPath, "root_ca.pem"))
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(rootCA))
+
+	// the collateral is checked at the given time
+	now := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	verifier := NewGoVerifier(WithDcapVerifier(fpcattestation.NewDcapVerifier(fpcattestation.WithRootCertificates(roots))))
+	evidence := wrapEvidence(t, fpcattestation.DcapType, dcapEvidence)
+	assert.NoError(t, verifier.VerifyEvidence(evidence, []byte(statement), mrenclave, nil, now))
+	assert.Error(t, verifier.VerifyEvidence(evidence, []byte("wrong statement"), mrenclave, nil, now))
+	assert.Error(t, verifier.VerifyEvidence(evidence, []byte(statement), mrenclave, nil, now.AddDate(0, 1, 0)))
+
+	// the fixtures are not issued by Intel
+	assert.Error(t, NewGoVerifier().VerifyEvidence(evidence, []byte(statement), mrenclave, nil, now))
+}
+
+// TestGoVerifierVerifyEvidenceVectors checks that the GoVerifier accepts the same test vectors as the C verify_evidence
+// in simulation (SIM) and hardware (HW) mode
+func TestGoVerifierVerifyEvidenceVectors(t *testing.T) {
+	verifiers := map[string]*GoVerifier{
+		"SIM": NewGoVerifier(WithSimulatedAttestation()),
+		"HW":  NewGoVerifier(),
+	}
+
+	vectors, err := ioutil.ReadDir(verifyEvidenceVectorsPath)
+	require.NoError(t, err)
+	require.NotEmpty(t, vectors)
+	for _, vector := range vectors {
+		readFile := func(name string) []byte {
+			data, err := ioutil.ReadFile(filepath.Join(verifyEvidenceVectorsPath, vector.Name(), name))
+			require.NoError(t, err)
+			return data
+		}
+		evidence := readFile("verify_evidence_input.txt")
+		statement := readFile("statement.txt")
+		codeId := string(readFile("code_id.txt"))
+
+		for _, line := range strings.Split(strings.TrimSpace(string(readFile("expected_result.txt"))), "\n") {
+			mode := strings.SplitN(line, "=", 2)
+			require.Len(t, mode, 2)
+			verifier, ok := verifiers[mode[0]]
+			require.True(t, ok, "unknown mode %s", mode[0])
+
+			err := verifier.VerifyEvidence(evidence, statement, codeId, nil, time.Now())
+			switch mode[1] {
+			case "success":
+				assert.NoError(t, err, "%s in %s mode", vector.Name(), mode[0])
+			case "failure":
+				assert.Error(t, err, "%s in %s mode", vector.Name(), mode[0])
+			default:
+				t.Fatalf("unknown expected result %s", mode[1])
+			}
+		}
+	}
+}
+
+func TestGoVerifierInvalidInput(t *testing.T) {
+	verifier := NewGoVerifier(WithSimulatedAttestation())
+
+	for evidence, expectedErr := range map[string]string{
+		"not json":                                   "invalid input",
+		`{"evidence":"MA=="}`:                        "no attestation type",
+		`{"attestation_type":"simulated"}`:           "no evidence field",
+		`{"attestation_type":"other","evidence":""}`: "evidence verification failed: bad attestation type 'other'",
+	} {
+		err := verifier.VerifyEvidence([]byte(evidence), nil, "", nil, time.Now())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), expectedErr)
+		}
+	}
+}
@@ -6,6 +6,16 @@ SPDX-License-Identifier: Apache-2.0
 
 package attestation
 
+import (
+	"time"
+
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+)
+
 type VerifierInterface interface {
-	VerifyEvidence(evidenceBytes, expectedStatementBytes []byte, expectedMrEnclave string) error
+	// VerifyEvidence verifies the evidence against the expected statement and mrenclave; furthermore, the evidence must
+	// comply with the policy. If policy is nil, the default verification policy is applied.
+	// The validity of the evidence, e.g., its age, is checked at the given time. All endorsers must use the same time,
+	// i.e., the transaction timestamp, to reach the same result.
+	VerifyEvidence(evidenceBytes, expectedStatementBytes []byte, expectedMrEnclave string, policy *fpcattestation.VerificationPolicy, now time.Time) error
 }
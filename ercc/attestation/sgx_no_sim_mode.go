@@ -0,0 +1,11 @@
+// +build !sgx_sim_mode
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+const sgxSimMode = false
@@ -0,0 +1,13 @@
+// +build sgx_sim_mode
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+// sgxSimMode is set with the sgx_sim_mode build tag; as with SGX_SIM_MODE of the C verify_evidence, simulated
+// evidence is only accepted in SGX simulation mode
+const sgxSimMode = true
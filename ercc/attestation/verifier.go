@@ -9,7 +9,13 @@ SPDX-License-Identifier: Apache-2.0
 
 package attestation
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+)
 
 // #cgo CFLAGS: -I${SRCDIR}/../../common/crypto
 // #cgo LDFLAGS: -L${SRCDIR}/../../common/crypto/_build -L${SRCDIR}/../../common/logging/_build -Wl,--start-group -lupdo-crypto-adapt -lupdo-crypto -Wl,--end-group -lcrypto -lulogging -lstdc++ -lgcov
@@ -27,7 +33,16 @@ func NewVerifier() VerifierInterface {
 type VerifierImpl struct {
 }
 
-func (v *VerifierImpl) VerifyEvidence(evidenceBytes []byte, expectedStatementBytes []byte, expectedMrEnclave string) error {
+func (v *VerifierImpl) VerifyEvidence(evidenceBytes []byte, expectedStatementBytes []byte, expectedMrEnclave string, policy *fpcattestation.VerificationPolicy, now time.Time) error {
+	// DCAP evidence is verified in go
+	evidence := &struct {
+		Type string `json:"attestation_type"`
+		Data string `json:"evidence"`
+	}{}
+	if err := json.Unmarshal(evidenceBytes, evidence); err == nil && evidence.Type == fpcattestation.DcapType {
+		return fpcattestation.NewDcapVerifier().At(now).VerifyEvidence([]byte(evidence.Data), expectedStatementBytes, expectedMrEnclave, policy)
+	}
+
 	evidencePtr := C.CBytes(evidenceBytes)
 	defer C.free(evidencePtr)
 	evidenceLen := len(evidenceBytes)
@@ -48,5 +63,19 @@ func (v *VerifierImpl) VerifyEvidence(evidenceBytes []byte, expectedStatementByt
 		return fmt.Errorf("evidence verification failed")
 	}
 
+	// verify_evidence only accepts IAS reports with status OK or GROUP_OUT_OF_DATE, the remaining policy is checked here
+	if evidence.Type == fpcattestation.EpidLinkableType || evidence.Type == fpcattestation.EpidUnlinkableType {
+		if policy == nil {
+			policy = fpcattestation.DefaultVerificationPolicy()
+		}
+		report, err := fpcattestation.ParseEpidReport([]byte(evidence.Data))
+		if err != nil {
+			return fmt.Errorf("evidence verification failed: %s", err)
+		}
+		if err := policy.CheckEpidReport(report, now); err != nil {
+			return fmt.Errorf("evidence verification failed: %s", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,41 @@
+// +build !WITH_PDO_CRYPTO
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"os"
+
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric/common/flogging"
+)
+
+// IASRootCAPathEnvKey is the environment variable with the path to the (PEM encoded) Intel SGX Attestation Report
+// Signing CA certificate, which is needed to verify EPID evidence
+const IASRootCAPathEnvKey = "IAS_ROOT_CA_PATH"
+
+var logger = flogging.MustGetLogger("ercc-attestation")
+
+// NewVerifier returns a GoVerifier when built without WITH_PDO_CRYPTO. Simulated evidence is only accepted if built
+// with the sgx_sim_mode tag; EPID evidence is accepted if IAS_ROOT_CA_PATH is set.
+func NewVerifier() VerifierInterface {
+	var opts []GoVerifierOption
+	if sgxSimMode {
+		opts = append(opts, WithSimulatedAttestation())
+	}
+
+	if path := os.Getenv(IASRootCAPathEnvKey); len(path) != 0 {
+		roots, err := fpcattestation.LoadIASRootCertificates(path)
+		if err != nil {
+			logger.Panicf("cannot load IAS root certificates: %s", err)
+		}
+		opts = append(opts, WithIASVerifier(fpcattestation.NewIASVerifier(roots)))
+	}
+
+	return NewGoVerifier(opts...)
+}
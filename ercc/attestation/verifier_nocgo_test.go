@@ -0,0 +1,34 @@
+// +build !WITH_PDO_CRYPTO
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewVerifier(t *testing.T) {
+	defer os.Setenv(IASRootCAPathEnvKey, os.Getenv(IASRootCAPathEnvKey))
+	os.Setenv(IASRootCAPathEnvKey, "")
+
+	// simulated evidence is only accepted with the sgx_sim_mode build tag
+	err := NewVerifier().VerifyEvidence([]byte(`{"attestation_type":"simulated","evidence":"MA=="}`), []byte("also ignored"), "this is ignored", nil, time.Now())
+	if sgxSimMode {
+		assert.NoError(t, err)
+	} else {
+		assert.EqualError(t, err, "evidence verification failed: simulated attestation is not accepted")
+	}
+
+	// EPID evidence requires the IAS root certificates
+	err = NewVerifier().VerifyEvidence([]byte(`{"attestation_type":"epid-linkable","evidence":"{}"}`), []byte("1234567890"), "this is ignored", nil, time.Now())
+	assert.EqualError(t, err, "evidence verification failed: no IAS root certificates configured")
+}
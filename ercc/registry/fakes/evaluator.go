@@ -6,6 +6,17 @@ import (
 )
 
 type IdentityEvaluator struct {
+	EvaluateAdminIdentityStub        func([]byte) error
+	evaluateAdminIdentityMutex       sync.RWMutex
+	evaluateAdminIdentityArgsForCall []struct {
+		arg1 []byte
+	}
+	evaluateAdminIdentityReturns struct {
+		result1 error
+	}
+	evaluateAdminIdentityReturnsOnCall map[int]struct {
+		result1 error
+	}
 	EvaluateCreatorIdentityStub        func([]byte, string) error
 	evaluateCreatorIdentityMutex       sync.RWMutex
 	evaluateCreatorIdentityArgsForCall []struct {
@@ -22,6 +33,72 @@ type IdentityEvaluator struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *IdentityEvaluator) EvaluateAdminIdentity(arg1 []byte) error {
+	var arg1Copy []byte
+	if arg1 != nil {
+		arg1Copy = make([]byte, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.evaluateAdminIdentityMutex.Lock()
+	ret, specificReturn := fake.evaluateAdminIdentityReturnsOnCall[len(fake.evaluateAdminIdentityArgsForCall)]
+	fake.evaluateAdminIdentityArgsForCall = append(fake.evaluateAdminIdentityArgsForCall, struct {
+		arg1 []byte
+	}{arg1Copy})
+	stub := fake.EvaluateAdminIdentityStub
+	fakeReturns := fake.evaluateAdminIdentityReturns
+	fake.recordInvocation("EvaluateAdminIdentity", []interface{}{arg1Copy})
+	fake.evaluateAdminIdentityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *IdentityEvaluator) EvaluateAdminIdentityCallCount() int {
+	fake.evaluateAdminIdentityMutex.RLock()
+	defer fake.evaluateAdminIdentityMutex.RUnlock()
+	return len(fake.evaluateAdminIdentityArgsForCall)
+}
+
+func (fake *IdentityEvaluator) EvaluateAdminIdentityCalls(stub func([]byte) error) {
+	fake.evaluateAdminIdentityMutex.Lock()
+	defer fake.evaluateAdminIdentityMutex.Unlock()
+	fake.EvaluateAdminIdentityStub = stub
+}
+
+func (fake *IdentityEvaluator) EvaluateAdminIdentityArgsForCall(i int) []byte {
+	fake.evaluateAdminIdentityMutex.RLock()
+	defer fake.evaluateAdminIdentityMutex.RUnlock()
+	argsForCall := fake.evaluateAdminIdentityArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *IdentityEvaluator) EvaluateAdminIdentityReturns(result1 error) {
+	fake.evaluateAdminIdentityMutex.Lock()
+	defer fake.evaluateAdminIdentityMutex.Unlock()
+	fake.EvaluateAdminIdentityStub = nil
+	fake.evaluateAdminIdentityReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *IdentityEvaluator) EvaluateAdminIdentityReturnsOnCall(i int, result1 error) {
+	fake.evaluateAdminIdentityMutex.Lock()
+	defer fake.evaluateAdminIdentityMutex.Unlock()
+	fake.EvaluateAdminIdentityStub = nil
+	if fake.evaluateAdminIdentityReturnsOnCall == nil {
+		fake.evaluateAdminIdentityReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.evaluateAdminIdentityReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *IdentityEvaluator) EvaluateCreatorIdentity(arg1 []byte, arg2 string) error {
 	var arg1Copy []byte
 	if arg1 != nil {
@@ -92,6 +169,8 @@ func (fake *IdentityEvaluator) EvaluateCreatorIdentityReturnsOnCall(i int, resul
 func (fake *IdentityEvaluator) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.evaluateAdminIdentityMutex.RLock()
+	defer fake.evaluateAdminIdentityMutex.RUnlock()
 	fake.evaluateCreatorIdentityMutex.RLock()
 	defer fake.evaluateCreatorIdentityMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
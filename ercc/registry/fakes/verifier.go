@@ -3,15 +3,20 @@ package fakes
 
 import (
 	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
 )
 
 type AttestationVerifier struct {
-	VerifyEvidenceStub        func([]byte, []byte, string) error
+	VerifyEvidenceStub        func([]byte, []byte, string, *attestation.VerificationPolicy, time.Time) error
 	verifyEvidenceMutex       sync.RWMutex
 	verifyEvidenceArgsForCall []struct {
 		arg1 []byte
 		arg2 []byte
 		arg3 string
+		arg4 *attestation.VerificationPolicy
+		arg5 time.Time
 	}
 	verifyEvidenceReturns struct {
 		result1 error
@@ -23,7 +28,7 @@ type AttestationVerifier struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *AttestationVerifier) VerifyEvidence(arg1 []byte, arg2 []byte, arg3 string) error {
+func (fake *AttestationVerifier) VerifyEvidence(arg1 []byte, arg2 []byte, arg3 string, arg4 *attestation.VerificationPolicy, arg5 time.Time) error {
 	var arg1Copy []byte
 	if arg1 != nil {
 		arg1Copy = make([]byte, len(arg1))
@@ -40,13 +45,15 @@ func (fake *AttestationVerifier) VerifyEvidence(arg1 []byte, arg2 []byte, arg3 s
 		arg1 []byte
 		arg2 []byte
 		arg3 string
-	}{arg1Copy, arg2Copy, arg3})
+		arg4 *attestation.VerificationPolicy
+		arg5 time.Time
+	}{arg1Copy, arg2Copy, arg3, arg4, arg5})
 	stub := fake.VerifyEvidenceStub
 	fakeReturns := fake.verifyEvidenceReturns
-	fake.recordInvocation("VerifyEvidence", []interface{}{arg1Copy, arg2Copy, arg3})
+	fake.recordInvocation("VerifyEvidence", []interface{}{arg1Copy, arg2Copy, arg3, arg4, arg5})
 	fake.verifyEvidenceMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -60,17 +67,17 @@ func (fake *AttestationVerifier) VerifyEvidenceCallCount() int {
 	return len(fake.verifyEvidenceArgsForCall)
 }
 
-func (fake *AttestationVerifier) VerifyEvidenceCalls(stub func([]byte, []byte, string) error) {
+func (fake *AttestationVerifier) VerifyEvidenceCalls(stub func([]byte, []byte, string, *attestation.VerificationPolicy, time.Time) error) {
 	fake.verifyEvidenceMutex.Lock()
 	defer fake.verifyEvidenceMutex.Unlock()
 	fake.VerifyEvidenceStub = stub
 }
 
-func (fake *AttestationVerifier) VerifyEvidenceArgsForCall(i int) ([]byte, []byte, string) {
+func (fake *AttestationVerifier) VerifyEvidenceArgsForCall(i int) ([]byte, []byte, string, *attestation.VerificationPolicy, time.Time) {
 	fake.verifyEvidenceMutex.RLock()
 	defer fake.verifyEvidenceMutex.RUnlock()
 	argsForCall := fake.verifyEvidenceArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *AttestationVerifier) VerifyEvidenceReturns(result1 error) {
@@ -10,20 +10,33 @@ package registry
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"github.com/hyperledger/fabric-private-chaincode/ercc/attestation"
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/pkg/errors"
 )
 
 var logger = flogging.MustGetLogger("ercc")
 
+// the state key of the attestation verification policy of the channel
+const verificationPolicyKey = "namespaces/verification_policy"
+
+// the chaincode id of ERCC
+const erccId = "ercc"
+
+// the channel endorsement policy, i.e., MAJORITY Endorsement by default, which must be the endorsement policy of ERCC
+// to change the verification policy
+const channelEndorsementPolicy = "/Channel/Application/Endorsement"
+
 type Contract struct {
 	contractapi.Contract
 
@@ -336,8 +349,23 @@ func checkAttestedData(ctx contractapi.TransactionContextInterface, v attestatio
 		return fmt.Errorf("sequence does not match chaincode definition")
 	}
 
+	policy, err := getVerificationPolicy(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get verification policy: %s", err)
+	}
+
+	// the evidence is checked at the transaction time, which is the same for all endorsers
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("cannot get transaction timestamp: %s", err)
+	}
+	txTime, err := ptypes.Timestamp(txTimestamp)
+	if err != nil {
+		return fmt.Errorf("invalid transaction timestamp: %s", err)
+	}
+
 	// check that attestation evidence contains expectedMrEnclave as defined in chaincode definition
-	if err := v.VerifyEvidence(credentials.Evidence, credentials.SerializedAttestedData.Value, expectedMrEnclave); err != nil {
+	if err := v.VerifyEvidence(credentials.Evidence, credentials.SerializedAttestedData.Value, expectedMrEnclave, policy, txTime); err != nil {
 		return fmt.Errorf("evidence verification failed: %s", err)
 	}
 
@@ -364,6 +392,94 @@ func checkAttestedData(ctx contractapi.TransactionContextInterface, v attestatio
 	return nil
 }
 
+// SetVerificationPolicy sets the (json encoded) attestation verification policy of this channel which is applied to
+// subsequent enclave registrations; enclaves already registered are not affected. Fields not set in the json keep
+// their default value, e.g., `{"allow_debug_enclaves": false}` only rejects debug enclaves.
+// Only organization admins can change the policy, i.e., every endorsing peer checks that the creator is an admin.
+// Additionally, the policy change must be endorsed according to the channel endorsement policy, i.e., by a majority of
+// the channel organizations by default; hence, the change is rejected unless this is the endorsement policy of ERCC.
+func (rs *Contract) SetVerificationPolicy(ctx contractapi.TransactionContextInterface, policyJson string) error {
+	creatorIdentityBytes, err := ctx.GetStub().GetCreator()
+	if err != nil {
+		return err
+	}
+
+	if err := rs.IEvaluator.EvaluateAdminIdentity(creatorIdentityBytes); err != nil {
+		return fmt.Errorf("creator identity evaluation failed: %s", err)
+	}
+
+	if err := checkChannelEndorsementPolicy(ctx); err != nil {
+		return err
+	}
+
+	policy, err := fpcattestation.ParseVerificationPolicy([]byte(policyJson))
+	if err != nil {
+		return err
+	}
+
+	policyBytes, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(verificationPolicyKey, policyBytes); err != nil {
+		return fmt.Errorf("cannot store verification policy: %s", err)
+	}
+
+	logger.Debugf("SetVerificationPolicy successful: %s", string(policyBytes))
+
+	return nil
+}
+
+// QueryVerificationPolicy returns the (json encoded) attestation verification policy of this channel
+func (rs *Contract) QueryVerificationPolicy(ctx contractapi.TransactionContextInterface) (string, error) {
+	policy, err := getVerificationPolicy(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	policyBytes, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+
+	return string(policyBytes), nil
+}
+
+// checkChannelEndorsementPolicy checks that the endorsement policy of ERCC, as defined in its chaincode definition,
+// refers to the channel endorsement policy
+func checkChannelEndorsementPolicy(ctx contractapi.TransactionContextInterface) error {
+	ccDef, err := utils.GetChaincodeDefinition(erccId, ctx.GetStub())
+	if err != nil {
+		return fmt.Errorf("cannot get chaincode definition: %s", err)
+	}
+
+	endorsementPolicy := &peer.ApplicationPolicy{}
+	if err := proto.Unmarshal(ccDef.ValidationParameter, endorsementPolicy); err != nil {
+		return errors.Wrap(err, "invalid endorsement policy")
+	}
+
+	if endorsementPolicy.GetChannelConfigPolicyReference() != channelEndorsementPolicy {
+		return fmt.Errorf("endorsement policy of %s must be the channel endorsement policy %s", erccId, channelEndorsementPolicy)
+	}
+
+	return nil
+}
+
+// getVerificationPolicy returns the verification policy stored in the state, or the default policy if none is set
+func getVerificationPolicy(ctx contractapi.TransactionContextInterface) (*fpcattestation.VerificationPolicy, error) {
+	policyBytes, err := ctx.GetStub().GetState(verificationPolicyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(policyBytes) == 0 {
+		return fpcattestation.DefaultVerificationPolicy(), nil
+	}
+
+	return fpcattestation.ParseVerificationPolicy(policyBytes)
+}
+
 // RegisterCCKeys  registers a CCKeyRegistration message that confirms that an enclave is provisioned with the chaincode encryption key.
 // This method is used during the key generation and key distribution protocol. In particular, during key generation,
 // this call sets the chaincode_ek for a chaincode if no chaincode_ek is set yet.
@@ -11,6 +11,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes/any"
@@ -19,12 +20,16 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/ercc/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/ercc/registry"
 	"github.com/hyperledger/fabric-private-chaincode/ercc/registry/fakes"
+	fpcattestation "github.com/hyperledger/fabric-private-chaincode/internal/attestation"
 	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 //go:generate counterfeiter -o fakes/transaction.go -fake-name TransactionContext . transactionContext
@@ -70,6 +75,8 @@ func TestRegisterEnclave(t *testing.T) {
 	chaincodeStub := &fakes.ChaincodeStub{}
 	transactionContext := &fakes.TransactionContext{}
 	transactionContext.GetStubReturns(chaincodeStub)
+	txTime := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(txTime), nil)
 	verifier := &fakes.AttestationVerifier{}
 	verifier.VerifyEvidenceReturns(nil)
 
@@ -208,6 +215,27 @@ func TestRegisterEnclave(t *testing.T) {
 	})
 	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
 	require.EqualError(t, err, "evidence verification failed: evidence invalid")
+	_, _, _, policy, now := verifier.VerifyEvidenceArgsForCall(verifier.VerifyEvidenceCallCount() - 1)
+	require.Equal(t, fpcattestation.DefaultVerificationPolicy(), policy)
+	// the evidence is checked at the transaction time
+	require.True(t, txTime.Equal(now))
+
+	// the verification policy of the channel is passed to the verifier
+	chaincodeStub.GetStateReturns([]byte(`{"allow_debug_enclaves": true}`), nil)
+	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
+	require.EqualError(t, err, "evidence verification failed: evidence invalid")
+	_, _, _, policy, _ = verifier.VerifyEvidenceArgsForCall(verifier.VerifyEvidenceCallCount() - 1)
+	require.True(t, policy.AllowDebugEnclaves)
+
+	chaincodeStub.GetStateReturns(nil, fmt.Errorf("some get state error"))
+	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
+	require.EqualError(t, err, "cannot get verification policy: some get state error")
+	chaincodeStub.GetStateReturns(nil, nil)
+
+	chaincodeStub.GetTxTimestampReturns(nil, fmt.Errorf("some timestamp error"))
+	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
+	require.EqualError(t, err, "cannot get transaction timestamp: some timestamp error")
+	chaincodeStub.GetTxTimestampReturns(timestamppb.New(txTime), nil)
 
 	verifier.VerifyEvidenceReturns(nil)
 	err = ercc.RegisterEnclave(transactionContext, credentialBase64)
@@ -260,6 +288,86 @@ func TestRegisterEnclave(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestVerificationPolicy(t *testing.T) {
+	chaincodeStub := &fakes.ChaincodeStub{}
+	transactionContext := &fakes.TransactionContext{}
+	transactionContext.GetStubReturns(chaincodeStub)
+
+	id := &fakes.IdentityEvaluator{}
+	ercc := registry.Contract{}
+	ercc.IEvaluator = id
+
+	// default policy if none is set
+	resp, err := ercc.QueryVerificationPolicy(transactionContext)
+	require.NoError(t, err)
+	policy, err := fpcattestation.ParseVerificationPolicy([]byte(resp))
+	require.NoError(t, err)
+	require.Equal(t, fpcattestation.DefaultVerificationPolicy(), policy)
+
+	chaincodeStub.GetStateReturns(nil, fmt.Errorf("some get state error"))
+	_, err = ercc.QueryVerificationPolicy(transactionContext)
+	require.EqualError(t, err, "some get state error")
+
+	// only admins can set the policy
+	chaincodeStub.GetCreatorReturns(nil, fmt.Errorf("some creator error"))
+	err = ercc.SetVerificationPolicy(transactionContext, `{"allow_debug_enclaves": false}`)
+	require.EqualError(t, err, "some creator error")
+
+	chaincodeStub.GetCreatorReturns([]byte("some creator"), nil)
+	id.EvaluateAdminIdentityReturns(fmt.Errorf("creator is not an admin of some org"))
+	err = ercc.SetVerificationPolicy(transactionContext, `{"allow_debug_enclaves": false}`)
+	require.EqualError(t, err, "creator identity evaluation failed: creator is not an admin of some org")
+	require.Equal(t, []byte("some creator"), id.EvaluateAdminIdentityArgsForCall(0))
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+
+	// the endorsement policy of ERCC must be the channel endorsement policy
+	id.EvaluateAdminIdentityReturns(nil)
+	chaincodeStub.InvokeChaincodeReturns(shim.Success(nil))
+	err = ercc.SetVerificationPolicy(transactionContext, `{"allow_debug_enclaves": false}`)
+	require.EqualError(t, err, "cannot get chaincode definition: no chaincode definition found for chaincode='ercc'")
+
+	chaincodeStub.InvokeChaincodeReturns(shim.Success(protoutil.MarshalOrPanic(
+		&lifecycle.QueryChaincodeDefinitionResult{
+			ValidationParameter: protoutil.MarshalOrPanic(&peer.ApplicationPolicy{
+				Type: &peer.ApplicationPolicy_SignaturePolicy{SignaturePolicy: &common.SignaturePolicyEnvelope{}},
+			}),
+		})))
+	err = ercc.SetVerificationPolicy(transactionContext, `{"allow_debug_enclaves": false}`)
+	require.EqualError(t, err, "endorsement policy of ercc must be the channel endorsement policy /Channel/Application/Endorsement")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+
+	chaincodeStub.InvokeChaincodeReturns(shim.Success(protoutil.MarshalOrPanic(
+		&lifecycle.QueryChaincodeDefinitionResult{
+			ValidationParameter: protoutil.MarshalOrPanic(&peer.ApplicationPolicy{
+				Type: &peer.ApplicationPolicy_ChannelConfigPolicyReference{ChannelConfigPolicyReference: "/Channel/Application/Endorsement"},
+			}),
+		})))
+	err = ercc.SetVerificationPolicy(transactionContext, `{"allowed_quote_statuses": ["SOME_STATUS"]}`)
+	require.EqualError(t, err, "unknown quote status 'SOME_STATUS'")
+	require.Equal(t, 0, chaincodeStub.PutStateCallCount())
+
+	chaincodeStub.PutStateReturns(fmt.Errorf("some put state error"))
+	err = ercc.SetVerificationPolicy(transactionContext, `{"allow_debug_enclaves": false}`)
+	require.EqualError(t, err, "cannot store verification policy: some put state error")
+
+	chaincodeStub.PutStateReturns(nil)
+	err = ercc.SetVerificationPolicy(transactionContext, `{"allow_debug_enclaves": true, "min_isv_svn": 2}`)
+	require.NoError(t, err)
+
+	// the stored policy contains all fields
+	_, policyBytes := chaincodeStub.PutStateArgsForCall(chaincodeStub.PutStateCallCount() - 1)
+	policy, err = fpcattestation.ParseVerificationPolicy(policyBytes)
+	require.NoError(t, err)
+	require.True(t, policy.AllowDebugEnclaves)
+	require.Equal(t, uint16(2), policy.MinIsvSvn)
+	require.Equal(t, fpcattestation.DefaultVerificationPolicy().AllowedQuoteStatuses, policy.AllowedQuoteStatuses)
+
+	chaincodeStub.GetStateReturns(policyBytes, nil)
+	resp, err = ercc.QueryVerificationPolicy(transactionContext)
+	require.NoError(t, err)
+	require.JSONEq(t, string(policyBytes), resp)
+}
+
 func TestQueryListEnclaveCredentials(t *testing.T) {
 	chaincodeStub := &fakes.ChaincodeStub{}
 	transactionContext := &fakes.TransactionContext{}
@@ -314,7 +314,6 @@ func installChaincode(client *fpcmgmt.Client, cc *chaincodeDetails, nw *networkD
 		}
 
 		initTxId, err := client.LifecycleInitEnclave(nw.ChannelID, initReq,
-			// Note that these options are currently ignored by our implementation
 			resmgmt.WithRetry(retry.DefaultResMgmtOpts),
 			resmgmt.WithTargetEndpoints(nw.Peers...), // peers that are responsible for enclave registration
 			resmgmt.WithOrdererEndpoint(nw.Orderers[0]),
@@ -96,18 +96,36 @@ type CredentialConverter struct {
 }
 
 func NewCredentialConverter() *CredentialConverter {
+	converter, err := NewCredentialConverterWithConverters()
+	if err != nil {
+		// ouch this should never happen
+		logger.Panicf("cannot create new credential converter! Reason: %s", err.Error())
+	}
+
+	return converter
+}
+
+// NewCredentialConverterWithConverters returns a CredentialConverter which supports the default attestation types
+// (simulation, EPID, and DCAP) as well as the attestation types of the given converters.
+// An error is returned if a converter is given for an attestation type that is already supported.
+func NewCredentialConverterWithConverters(converters ...*Converter) (*CredentialConverter, error) {
 	dispatcher := NewConverterDispatcher()
 	err := dispatcher.Register(
 		NewSimulationConverter(),
 		NewEpidLinkableConverter(),
 		NewEpidUnlinkableConverter(),
+		NewDcapConverter(NewPCCSClient(WithPCCSUrl(loadPCCSUrl()), WithPCCSCredentialProvider(DefaultCredentialProvider()))),
 	)
 	if err != nil {
-		// ouch this should never happen
-		logger.Panicf("cannot create new credential converter! Reason: %s", err.Error())
+		return nil, err
+	}
+
+	err = dispatcher.Register(converters...)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot register converter")
 	}
 
-	return &CredentialConverter{dispatcher: dispatcher}
+	return &CredentialConverter{dispatcher: dispatcher}, nil
 }
 
 // ConvertCredentials perform attestation evidence conversion (transformation) for a given credentials message (encoded as base64 string)
@@ -79,3 +79,33 @@ func TestCredentialConverterWithSimulation(t *testing.T) {
 	assert.Equal(t, att, updatedCredentials.Attestation)
 	assert.Equal(t, expectedEvidence, updatedCredentials.Evidence)
 }
+
+func TestCredentialConverterWithConverters(t *testing.T) {
+
+	att := []byte(`{"attestation_type":"dummy","attestation":"MA=="}`)
+	expectedEvidence := []byte(`{"attestation_type":"dummy","evidence":"dummy evidence"}`)
+
+	credentials := &protos.Credentials{
+		Attestation: att,
+	}
+	serializedCredentials := utils.MarshallProto(credentials)
+
+	// the default converter does not know dummy
+	_, err := NewCredentialConverter().ConvertCredentials(serializedCredentials)
+	assert.Error(t, err)
+
+	cv, err := NewCredentialConverterWithConverters(NewDummyConverter())
+	assert.NoError(t, err)
+
+	updatedSerializedCredentials, err := cv.ConvertCredentials(serializedCredentials)
+	assert.NoError(t, err)
+
+	updatedCredentials, err := utils.UnmarshalCredentials(updatedSerializedCredentials)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedEvidence, updatedCredentials.Evidence)
+
+	// default types cannot be overridden
+	cv, err = NewCredentialConverterWithConverters(NewSimulationConverter())
+	assert.Error(t, err)
+	assert.Nil(t, cv)
+}
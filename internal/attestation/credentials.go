@@ -0,0 +1,247 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// attestation services which require an API key
+const (
+	IASService  = "ias"
+	PCCSService = "pccs"
+)
+
+// environment variables read by the env credential provider
+const (
+	IASApiKeyEnvKey  = "IAS_API_KEY"
+	PCCSApiKeyEnvKey = "PCCS_API_KEY"
+	SPIDEnvKey       = "IAS_SPID"
+	SPIDTypeEnvKey   = "IAS_SPID_TYPE"
+)
+
+// file names read by the file credential provider
+const (
+	IASApiKeyFile  = "api_key.txt"
+	PCCSApiKeyFile = "pccs_api_key.txt"
+	SPIDFile       = "spid.txt"
+	SPIDTypeFile   = "spid_type.txt"
+)
+
+// ErrCredentialNotFound is returned (wrapped) by a CredentialProvider which does not provide the requested credential
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// Secret holds key material such as an API key. It is redacted when formatted, so it does not leak into logs or error
+// messages by accident; use string(secret) to access the key.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+func (s Secret) String() string {
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return redacted
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// CredentialProvider provides the credentials to use the attestation services, i.e., API keys, and the SPID of the
+// EPID attestation.
+type CredentialProvider interface {
+	// GetAPIKey returns the API key of an attestation service (IASService or PCCSService)
+	GetAPIKey(service string) (Secret, error)
+	// GetSPID returns the SPID type (epid-linkable or epid-unlinkable) and the hex-encoded SPID
+	GetSPID() (spidType string, hexSpid string, err error)
+}
+
+type defaultCredentialProvider struct{}
+
+// DefaultCredentialProvider returns the provider used if no other provider is configured. It reads the credentials
+// from environment variables, $SGX_CREDENTIALS_PATH, and $FPC_PATH/config/ias, in this order.
+// The environment is read whenever a credential is requested.
+func DefaultCredentialProvider() CredentialProvider {
+	return &defaultCredentialProvider{}
+}
+
+func (p *defaultCredentialProvider) chain() CredentialProvider {
+	providers := []CredentialProvider{NewEnvCredentialProvider()}
+	if path := os.Getenv("SGX_CREDENTIALS_PATH"); len(path) != 0 {
+		providers = append(providers, NewFileCredentialProvider(path))
+	}
+	if fpcPath := os.Getenv("FPC_PATH"); len(fpcPath) != 0 {
+		providers = append(providers, NewFileCredentialProvider(filepath.Join(fpcPath, "config", "ias")))
+	}
+	return NewChainedCredentialProvider(providers...)
+}
+
+func (p *defaultCredentialProvider) GetAPIKey(service string) (Secret, error) {
+	return p.chain().GetAPIKey(service)
+}
+
+func (p *defaultCredentialProvider) GetSPID() (string, string, error) {
+	return p.chain().GetSPID()
+}
+
+type envCredentialProvider struct{}
+
+// NewEnvCredentialProvider returns a provider which reads the credentials from the environment variables
+// IAS_API_KEY, PCCS_API_KEY, IAS_SPID_TYPE and IAS_SPID.
+func NewEnvCredentialProvider() CredentialProvider {
+	return &envCredentialProvider{}
+}
+
+func (p *envCredentialProvider) GetAPIKey(service string) (Secret, error) {
+	var envKey string
+	switch service {
+	case IASService:
+		envKey = IASApiKeyEnvKey
+	case PCCSService:
+		envKey = PCCSApiKeyEnvKey
+	default:
+		return "", fmt.Errorf("unknown attestation service '%s'", service)
+	}
+
+	apiKey := os.Getenv(envKey)
+	if len(apiKey) == 0 {
+		return "", errors.Wrapf(ErrCredentialNotFound, "$%s not set", envKey)
+	}
+	return Secret(apiKey), nil
+}
+
+func (p *envCredentialProvider) GetSPID() (string, string, error) {
+	spidType, hexSpid := os.Getenv(SPIDTypeEnvKey), os.Getenv(SPIDEnvKey)
+	if len(spidType) == 0 || len(hexSpid) == 0 {
+		return "", "", errors.Wrapf(ErrCredentialNotFound, "$%s or $%s not set", SPIDTypeEnvKey, SPIDEnvKey)
+	}
+	return spidType, hexSpid, nil
+}
+
+type fileCredentialProvider struct {
+	path string
+}
+
+// NewFileCredentialProvider returns a provider which reads the credentials from the files api_key.txt,
+// pccs_api_key.txt, spid_type.txt and spid.txt in path.
+func NewFileCredentialProvider(path string) CredentialProvider {
+	return &fileCredentialProvider{path: path}
+}
+
+func (p *fileCredentialProvider) GetAPIKey(service string) (Secret, error) {
+	var file string
+	switch service {
+	case IASService:
+		file = IASApiKeyFile
+	case PCCSService:
+		file = PCCSApiKeyFile
+	default:
+		return "", fmt.Errorf("unknown attestation service '%s'", service)
+	}
+
+	apiKey, err := p.readFile(file)
+	return Secret(apiKey), err
+}
+
+func (p *fileCredentialProvider) GetSPID() (string, string, error) {
+	spidType, err := p.readFile(SPIDTypeFile)
+	if err != nil {
+		return "", "", err
+	}
+	hexSpid, err := p.readFile(SPIDFile)
+	if err != nil {
+		return "", "", err
+	}
+	return spidType, hexSpid, nil
+}
+
+func (p *fileCredentialProvider) readFile(name string) (string, error) {
+	path := filepath.Join(p.path, name)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", errors.Wrapf(ErrCredentialNotFound, "no file %s", path)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "could not read %s", path)
+	}
+
+	if len(data) == 0 {
+		return "", errors.Errorf("empty file %s", path)
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// CallbackCredentialProvider is a provider which calls the given functions, e.g., to fetch the credentials from a
+// secrets manager. If a function is nil, the credential is not found.
+type CallbackCredentialProvider struct {
+	APIKeyFunc func(service string) (Secret, error)
+	SPIDFunc   func() (spidType string, hexSpid string, err error)
+}
+
+func (p *CallbackCredentialProvider) GetAPIKey(service string) (Secret, error) {
+	if p.APIKeyFunc == nil {
+		return "", errors.Wrap(ErrCredentialNotFound, "no api key callback")
+	}
+	return p.APIKeyFunc(service)
+}
+
+func (p *CallbackCredentialProvider) GetSPID() (string, string, error) {
+	if p.SPIDFunc == nil {
+		return "", "", errors.Wrap(ErrCredentialNotFound, "no spid callback")
+	}
+	return p.SPIDFunc()
+}
+
+type chainedCredentialProvider struct {
+	providers []CredentialProvider
+}
+
+// NewChainedCredentialProvider returns a provider which asks the given providers in order. A provider is skipped if
+// it does not find the credential; any other error is returned.
+func NewChainedCredentialProvider(providers ...CredentialProvider) CredentialProvider {
+	return &chainedCredentialProvider{providers: providers}
+}
+
+func (p *chainedCredentialProvider) GetAPIKey(service string) (Secret, error) {
+	var notFound []string
+	for _, provider := range p.providers {
+		apiKey, err := provider.GetAPIKey(service)
+		if err == nil {
+			return apiKey, nil
+		}
+		if errors.Cause(err) != ErrCredentialNotFound {
+			return "", err
+		}
+		notFound = append(notFound, err.Error())
+	}
+	return "", errors.Wrapf(ErrCredentialNotFound, "no %s api key (%s)", service, strings.Join(notFound, "; "))
+}
+
+func (p *chainedCredentialProvider) GetSPID() (string, string, error) {
+	var notFound []string
+	for _, provider := range p.providers {
+		spidType, hexSpid, err := provider.GetSPID()
+		if err == nil {
+			return spidType, hexSpid, nil
+		}
+		if errors.Cause(err) != ErrCredentialNotFound {
+			return "", "", err
+		}
+		notFound = append(notFound, err.Error())
+	}
+	return "", "", errors.Wrapf(ErrCredentialNotFound, "no spid (%s)", strings.Join(notFound, "; "))
+}
@@ -0,0 +1,174 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setEnv sets the environment variables and returns a function restoring them
+func setEnv(env map[string]string) func() {
+	old := make(map[string]string)
+	for k, v := range env {
+		old[k] = os.Getenv(k)
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k, v := range old {
+			os.Setenv(k, v)
+		}
+	}
+}
+
+func writeCredentials(t *testing.T, path string, files map[string]string) {
+	for name, content := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(path, name), []byte(content), 0600))
+	}
+}
+
+func TestSecret(t *testing.T) {
+	secret := Secret("some_key")
+	assert.Equal(t, "some_key", string(secret))
+
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x"} {
+		assert.NotContains(t, fmt.Sprintf(format, secret), "some_key", format)
+	}
+	assert.NotContains(t, fmt.Sprintf("%v", struct{ Key Secret }{secret}), "some_key")
+
+	data, err := json.Marshal(map[string]Secret{"key": secret})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "some_key")
+}
+
+func TestEnvCredentialProvider(t *testing.T) {
+	defer setEnv(map[string]string{IASApiKeyEnvKey: "", PCCSApiKeyEnvKey: "", SPIDTypeEnvKey: "", SPIDEnvKey: ""})()
+	provider := NewEnvCredentialProvider()
+
+	_, err := provider.GetAPIKey(IASService)
+	assert.Equal(t, ErrCredentialNotFound, errors.Cause(err))
+	_, _, err = provider.GetSPID()
+	assert.Equal(t, ErrCredentialNotFound, errors.Cause(err))
+	_, err = provider.GetAPIKey("other")
+	assert.EqualError(t, err, "unknown attestation service 'other'")
+
+	setEnv(map[string]string{IASApiKeyEnvKey: "ias_key", PCCSApiKeyEnvKey: "pccs_key", SPIDTypeEnvKey: EpidLinkableType, SPIDEnvKey: "some_spid"})
+	apiKey, err := provider.GetAPIKey(IASService)
+	assert.NoError(t, err)
+	assert.Equal(t, Secret("ias_key"), apiKey)
+	apiKey, err = provider.GetAPIKey(PCCSService)
+	assert.NoError(t, err)
+	assert.Equal(t, Secret("pccs_key"), apiKey)
+	spidType, hexSpid, err := provider.GetSPID()
+	assert.NoError(t, err)
+	assert.Equal(t, EpidLinkableType, spidType)
+	assert.Equal(t, "some_spid", hexSpid)
+}
+
+func TestFileCredentialProvider(t *testing.T) {
+	path, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+	provider := NewFileCredentialProvider(path)
+
+	_, err = provider.GetAPIKey(IASService)
+	assert.Equal(t, ErrCredentialNotFound, errors.Cause(err))
+	_, _, err = provider.GetSPID()
+	assert.Equal(t, ErrCredentialNotFound, errors.Cause(err))
+
+	writeCredentials(t, path, map[string]string{IASApiKeyFile: "", SPIDTypeFile: EpidUnlinkableType + "\n", SPIDFile: "some_spid\n"})
+	_, err = provider.GetAPIKey(IASService)
+	assert.Contains(t, err.Error(), "empty file")
+	assert.NotEqual(t, ErrCredentialNotFound, errors.Cause(err))
+	spidType, hexSpid, err := provider.GetSPID()
+	assert.NoError(t, err)
+	assert.Equal(t, EpidUnlinkableType, spidType)
+	assert.Equal(t, "some_spid", hexSpid)
+
+	writeCredentials(t, path, map[string]string{IASApiKeyFile: "ias_key\n", PCCSApiKeyFile: "pccs_key"})
+	apiKey, err := provider.GetAPIKey(IASService)
+	assert.NoError(t, err)
+	assert.Equal(t, Secret("ias_key"), apiKey)
+	apiKey, err = provider.GetAPIKey(PCCSService)
+	assert.NoError(t, err)
+	assert.Equal(t, Secret("pccs_key"), apiKey)
+}
+
+func TestChainedCredentialProvider(t *testing.T) {
+	notFound := &CallbackCredentialProvider{}
+	failing := &CallbackCredentialProvider{
+		APIKeyFunc: func(string) (Secret, error) { return "", errors.New("secrets manager unavailable") },
+	}
+	found := &CallbackCredentialProvider{
+		APIKeyFunc: func(service string) (Secret, error) { return Secret(service + "_key"), nil },
+		SPIDFunc:   func() (string, string, error) { return EpidLinkableType, "some_spid", nil },
+	}
+
+	apiKey, err := NewChainedCredentialProvider(notFound, found, failing).GetAPIKey(IASService)
+	assert.NoError(t, err)
+	assert.Equal(t, Secret("ias_key"), apiKey)
+	spidType, _, err := NewChainedCredentialProvider(notFound, found).GetSPID()
+	assert.NoError(t, err)
+	assert.Equal(t, EpidLinkableType, spidType)
+
+	_, err = NewChainedCredentialProvider(notFound, failing, found).GetAPIKey(IASService)
+	assert.EqualError(t, err, "secrets manager unavailable")
+
+	_, err = NewChainedCredentialProvider(notFound, notFound).GetAPIKey(IASService)
+	assert.Equal(t, ErrCredentialNotFound, errors.Cause(err))
+	_, _, err = NewChainedCredentialProvider().GetSPID()
+	assert.Equal(t, ErrCredentialNotFound, errors.Cause(err))
+}
+
+func TestDefaultCredentialProvider(t *testing.T) {
+	credentialsPath, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(credentialsPath)
+	fpcPath, err := ioutil.TempDir("", "fpc")
+	require.NoError(t, err)
+	defer os.RemoveAll(fpcPath)
+	require.NoError(t, os.MkdirAll(filepath.Join(fpcPath, "config", "ias"), 0700))
+
+	defer setEnv(map[string]string{IASApiKeyEnvKey: "", "SGX_CREDENTIALS_PATH": credentialsPath, "FPC_PATH": fpcPath})()
+	provider := DefaultCredentialProvider()
+
+	_, err = provider.GetAPIKey(IASService)
+	assert.Equal(t, ErrCredentialNotFound, errors.Cause(err))
+
+	// fallback to $FPC_PATH/config/ias
+	writeCredentials(t, filepath.Join(fpcPath, "config", "ias"), map[string]string{IASApiKeyFile: "fpc_key"})
+	apiKey, err := provider.GetAPIKey(IASService)
+	assert.NoError(t, err)
+	assert.Equal(t, Secret("fpc_key"), apiKey)
+
+	// $SGX_CREDENTIALS_PATH takes precedence
+	writeCredentials(t, credentialsPath, map[string]string{IASApiKeyFile: "credentials_key"})
+	apiKey, err = provider.GetAPIKey(IASService)
+	assert.NoError(t, err)
+	assert.Equal(t, Secret("credentials_key"), apiKey)
+
+	// the environment variable takes precedence
+	setEnv(map[string]string{IASApiKeyEnvKey: "env_key"})
+	apiKey, err = provider.GetAPIKey(IASService)
+	assert.NoError(t, err)
+	assert.Equal(t, Secret("env_key"), apiKey)
+}
+
+func TestEpidConverterCredentialProvider(t *testing.T) {
+	converter := NewEpidLinkableConverter(WithCredentialProvider(&CallbackCredentialProvider{}))
+	_, err := converter.Converter([]byte("some quote"))
+	assert.Contains(t, err.Error(), "cannot load IAS API key")
+	assert.Equal(t, ErrCredentialNotFound, errors.Cause(err))
+}
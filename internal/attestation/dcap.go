@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DcapType is the attestation type of Intel SGX DCAP (ECDSA) attestation
+const DcapType = "dcap"
+
+// DcapEvidence is the evidence of an Intel SGX DCAP attestation. It contains the (base64 encoded) quote and the
+// collateral needed to verify the quote.
+type DcapEvidence struct {
+	Quote      string          `json:"quote"`
+	Collateral *DcapCollateral `json:"collateral"`
+}
+
+// NewDcapConverter creates a new attestation converter for Intel SGX DCAP (ECDSA) attestation.
+// The attestation is a base64 encoded quote; the collateral to verify the quote is fetched from the given provider.
+func NewDcapConverter(provider CollateralProvider) *Converter {
+	return &Converter{
+		Type:      DcapType,
+		Converter: newDcapConverter(provider),
+	}
+}
+
+func newDcapConverter(provider CollateralProvider) ConvertFunction {
+	return func(attestationBytes []byte) (evidenceBytes []byte, err error) {
+		quoteBytes, err := base64.StdEncoding.DecodeString(string(attestationBytes))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot decode quote")
+		}
+
+		quote, err := ParseDcapQuote(quoteBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse quote")
+		}
+
+		pckCertChain, err := quote.PckCertChain()
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get PCK certificate chain")
+		}
+
+		pckCerts, err := parseCertChain(pckCertChain)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid PCK certificate chain")
+		}
+
+		pck, err := parsePckInfo(pckCerts[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid PCK certificate")
+		}
+
+		ca, err := pckCA(pckCerts[0])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid PCK certificate")
+		}
+
+		collateral, err := provider.GetCollateral(pck.Fmspc, ca)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot get collateral")
+		}
+		collateral.PckCertChain = pckCertChain
+
+		evidence, err := json.Marshal(&DcapEvidence{
+			Quote:      string(attestationBytes),
+			Collateral: collateral,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot marshal dcap evidence")
+		}
+
+		return evidence, nil
+	}
+}
+
+// pckCA returns the PCK CA (PckProcessorCA or PckPlatformCA) which issued the PCK certificate, as named by the common
+// name of the issuer, e.g., "Intel SGX PCK Platform CA"
+func pckCA(pckCert *x509.Certificate) (string, error) {
+	issuer := strings.ToLower(pckCert.Issuer.CommonName)
+	for _, ca := range []string{PckProcessorCA, PckPlatformCA} {
+		if strings.HasSuffix(issuer, " pck "+ca+" ca") {
+			return ca, nil
+		}
+	}
+	return "", fmt.Errorf("unknown PCK CA '%s'", pckCert.Issuer.CommonName)
+}
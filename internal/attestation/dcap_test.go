@@ -0,0 +1,532 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the fixtures in testdata are generated with test PKIs mimicking the Intel services; regenerate them with
+// go test -run TestGenerate -update-fixtures
+var updateFixtures = flag.Bool("update-fixtures", false, "regenerate the test fixtures")
+
+const (
+	dcapFixturesPath   = "testdata/dcap"
+	dcapQuoteFile      = "quote.txt"
+	dcapRootCAFile     = "root_ca.pem"
+	dcapRevokedCrlFile = "pck_crl_revoked.pem"
+
+	dcapTestStatement = "test statement"
+	dcapTestFmspc     = "00906ed50000"
+)
+
+var (
+	// fixtures are valid between issue date and next update of the collateral
+	dcapTestIssueDate = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	dcapTestTime      = time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+)
+
+func dcapTestMrEnclave() string {
+	h := sha256.Sum256([]byte("test enclave"))
+	return hex.EncodeToString(h[:])
+}
+
+func readDcapFixture(t *testing.T, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dcapFixturesPath, name))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func newTestDcapVerifier(t *testing.T) *DcapVerifier {
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM([]byte(readDcapFixture(t, dcapRootCAFile))))
+	return NewDcapVerifier(WithRootCertificates(roots), WithCurrentTime(func() time.Time { return dcapTestTime }))
+}
+
+func convertDcapFixture(t *testing.T) []byte {
+	converter := NewDcapConverter(NewLocalCollateralProvider(dcapFixturesPath))
+	evidence, err := converter.Converter([]byte(readDcapFixture(t, dcapQuoteFile)))
+	require.NoError(t, err)
+	return evidence
+}
+
+type collateralProviderFunc func(fmspc, ca string) (*DcapCollateral, error)
+
+func (f collateralProviderFunc) GetCollateral(fmspc, ca string) (*DcapCollateral, error) {
+	return f(fmspc, ca)
+}
+
+func TestDcapConverter(t *testing.T) {
+	var requestedFmspc, requestedCA string
+	provider := collateralProviderFunc(func(fmspc, ca string) (*DcapCollateral, error) {
+		requestedFmspc, requestedCA = fmspc, ca
+		return NewLocalCollateralProvider(dcapFixturesPath).GetCollateral(fmspc, ca)
+	})
+	converter := NewDcapConverter(provider)
+	assert.Equal(t, DcapType, converter.Type)
+
+	quote := readDcapFixture(t, dcapQuoteFile)
+	evidenceBytes, err := converter.Converter([]byte(quote))
+	assert.NoError(t, err)
+	assert.Equal(t, dcapTestFmspc, requestedFmspc)
+	assert.Equal(t, PckPlatformCA, requestedCA)
+
+	evidence := &DcapEvidence{}
+	assert.NoError(t, json.Unmarshal(evidenceBytes, evidence))
+	assert.Equal(t, quote, evidence.Quote)
+	assert.Contains(t, evidence.Collateral.PckCertChain, "-----BEGIN CERTIFICATE-----")
+	assert.Equal(t, readDcapFixture(t, TcbInfoFile), evidence.Collateral.TcbInfo)
+	assert.Equal(t, readDcapFixture(t, QeIdentityIssuerChainFile), evidence.Collateral.QeIdentityIssuerChain)
+	assert.Equal(t, readDcapFixture(t, PckCrlFile), evidence.Collateral.PckCrl)
+
+	// invalid quotes
+	for _, attestation := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("too short"))} {
+		evidenceBytes, err = converter.Converter([]byte(attestation))
+		assert.Error(t, err)
+		assert.Nil(t, evidenceBytes)
+	}
+
+	// collateral not available
+	converter = NewDcapConverter(collateralProviderFunc(func(fmspc, ca string) (*DcapCollateral, error) {
+		return nil, fmt.Errorf("pccs not available")
+	}))
+	evidenceBytes, err = converter.Converter([]byte(quote))
+	assert.Contains(t, err.Error(), "pccs not available")
+	assert.Nil(t, evidenceBytes)
+}
+
+func TestCredentialConverterWithDcap(t *testing.T) {
+	// dcap is supported by default and cannot be registered again
+	cv, err := NewCredentialConverterWithConverters(NewDcapConverter(NewLocalCollateralProvider(dcapFixturesPath)))
+	assert.Error(t, err)
+	assert.Nil(t, cv)
+}
+
+func TestDcapVerifier(t *testing.T) {
+	evidence := convertDcapFixture(t)
+	verifier := newTestDcapVerifier(t)
+
+	report, err := verifier.Verify(evidence)
+	assert.NoError(t, err)
+	assert.Equal(t, TcbStatusUpToDate, report.TcbStatus)
+	assert.Equal(t, TcbStatusUpToDate, report.QeTcbStatus)
+	assert.Equal(t, dcapTestMrEnclave(), hex.EncodeToString(report.ReportBody.MrEnclave))
+	assert.False(t, report.ReportBody.Debug())
+
+	assert.NoError(t, verifier.VerifyEvidence(evidence, []byte(dcapTestStatement), dcapTestMrEnclave(), nil))
+	assert.NoError(t, verifier.VerifyEvidence(evidence, []byte(dcapTestStatement), strings.ToUpper(dcapTestMrEnclave()), nil))
+
+	err = verifier.VerifyEvidence(evidence, []byte("other statement"), dcapTestMrEnclave(), nil)
+	assert.EqualError(t, err, "expected statement mismatch")
+
+	err = verifier.VerifyEvidence(evidence, []byte(dcapTestStatement), strings.Repeat("00", 32), nil)
+	assert.Contains(t, err.Error(), "expected mrenclave mismatch")
+
+	// the fixtures are not issued by Intel
+	err = NewDcapVerifier(WithCurrentTime(func() time.Time { return dcapTestTime })).VerifyEvidence(evidence, []byte(dcapTestStatement), dcapTestMrEnclave(), nil)
+	assert.Contains(t, err.Error(), "invalid PCK certificate chain")
+
+	// the collateral has expired
+	expired := NewDcapVerifier(WithRootCertificates(verifier.roots), WithCurrentTime(func() time.Time { return dcapTestTime.AddDate(0, 1, 0) }))
+	_, err = expired.Verify(evidence)
+	assert.Contains(t, err.Error(), "PCK CRL expired")
+	_, err = verifier.At(dcapTestTime.AddDate(0, 1, 0)).Verify(evidence)
+	assert.Contains(t, err.Error(), "PCK CRL expired")
+	_, err = expired.At(dcapTestTime).Verify(evidence)
+	assert.NoError(t, err)
+}
+
+func TestPckCA(t *testing.T) {
+	for cn, expectedCA := range map[string]string{
+		"Intel SGX PCK Processor CA": PckProcessorCA,
+		"Intel SGX PCK Platform CA":  PckPlatformCA,
+		"Test SGX PCK Platform CA":   PckPlatformCA,
+		"Intel SGX Root CA":          "",
+	} {
+		ca, err := pckCA(&x509.Certificate{Issuer: pkix.Name{CommonName: cn}})
+		if expectedCA == "" {
+			assert.EqualError(t, err, "unknown PCK CA 'Intel SGX Root CA'")
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, expectedCA, ca)
+	}
+}
+
+func TestDcapVerifierTampered(t *testing.T) {
+	verifier := newTestDcapVerifier(t)
+
+	tamper := func(f func(evidence *DcapEvidence, quote []byte)) []byte {
+		evidence := &DcapEvidence{}
+		require.NoError(t, json.Unmarshal(convertDcapFixture(t), evidence))
+		quote, err := base64.StdEncoding.DecodeString(evidence.Quote)
+		require.NoError(t, err)
+		f(evidence, quote)
+		evidence.Quote = base64.StdEncoding.EncodeToString(quote)
+		evidenceBytes, err := json.Marshal(evidence)
+		require.NoError(t, err)
+		return evidenceBytes
+	}
+
+	for name, testCase := range map[string]struct {
+		evidence    []byte
+		expectedErr string
+	}{
+		"invalid json": {[]byte("{"), "cannot unmarshal dcap evidence"},
+		"no collateral": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral = nil
+		}), "no collateral"},
+		"report body": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			quote[quoteHeaderLen+reportMrEnclaveOffset] ^= 1
+		}), "invalid quote signature"},
+		"qe report": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			quote[quoteSignedLen+quoteSignatureLenLen+ecdsaSignatureLen+ecdsaPublicKeyLen+reportIsvSvnOffset] ^= 1
+		}), "invalid QE report signature"},
+		"attestation key": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			quote[quoteSignedLen+quoteSignatureLenLen+ecdsaSignatureLen] ^= 1
+		}), "attestation key not bound by QE report"},
+		"pck cert chain": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral.PckCertChain = evidence.Collateral.TcbInfoIssuerChain
+		}), "invalid PCK certificate"},
+		"tcb info": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral.TcbInfo = strings.Replace(evidence.Collateral.TcbInfo, "UpToDate", "OutOfDate", 1)
+		}), "invalid TCB info: invalid signature"},
+		"qe identity": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral.QeIdentity = strings.Replace(evidence.Collateral.QeIdentity, `"isvprodid":1`, `"isvprodid":2`, 1)
+		}), "invalid QE identity: invalid signature"},
+		"qe identity issuer chain": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral.QeIdentityIssuerChain = evidence.Collateral.PckCertChain
+		}), "invalid QE identity: invalid signature"},
+		"revoked pck": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral.PckCrl = readDcapFixture(t, dcapRevokedCrlFile)
+		}), "PCK certificate is revoked"},
+		"no pck crl": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral.PckCrl = ""
+		}), "invalid PCK CRL"},
+		"pck crl signature": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			block, _ := pem.Decode([]byte(evidence.Collateral.PckCrl))
+			block.Bytes[len(block.Bytes)-1] ^= 1
+			evidence.Collateral.PckCrl = string(pem.EncodeToMemory(block))
+		}), "invalid PCK CRL signature"},
+		"pck crl issuer chain": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral.PckCrlIssuerChain = evidence.Collateral.TcbInfoIssuerChain
+		}), "PCK CRL is not issued by the issuer of the PCK certificate"},
+		"no pck crl issuer chain": {tamper(func(evidence *DcapEvidence, quote []byte) {
+			evidence.Collateral.PckCrlIssuerChain = ""
+		}), "invalid PCK CRL issuer chain"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(testCase.evidence)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), testCase.expectedErr)
+			}
+		})
+	}
+}
+
+func TestTcbInfoMatch(t *testing.T) {
+	newLevel := func(svn, pceSvn int, status string) tcbLevel {
+		l := tcbLevel{TcbStatus: status}
+		for i := 0; i < sgxTcbComponents; i++ {
+			l.Tcb.SgxTcbComponents = append(l.Tcb.SgxTcbComponents, struct {
+				Svn int `json:"svn"`
+			}{svn})
+		}
+		l.Tcb.PceSvn = pceSvn
+		return l
+	}
+	info := &tcbInfo{TcbLevels: []tcbLevel{
+		newLevel(5, 13, TcbStatusUpToDate),
+		newLevel(2, 10, "OutOfDate"),
+		newLevel(1, 5, TcbStatusRevoked),
+	}}
+	pck := func(svn, pceSvn int) *pckInfo {
+		p := &pckInfo{PceSvn: pceSvn, TcbSvns: make([]int, sgxTcbComponents)}
+		for i := range p.TcbSvns {
+			p.TcbSvns[i] = svn
+		}
+		return p
+	}
+
+	for _, testCase := range []struct {
+		pck            *pckInfo
+		expectedStatus string
+	}{
+		{pck(6, 13), TcbStatusUpToDate},
+		{pck(5, 12), "OutOfDate"},
+		{pck(4, 13), "OutOfDate"},
+		{pck(1, 13), TcbStatusRevoked},
+		{pck(0, 13), ""},
+	} {
+		level, err := info.match(testCase.pck)
+		if testCase.expectedStatus == "" {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expectedStatus, level.TcbStatus)
+	}
+
+	// a single lower component selects a lower level
+	p := pck(5, 13)
+	p.TcbSvns[7] = 4
+	level, err := info.match(p)
+	assert.NoError(t, err)
+	assert.Equal(t, "OutOfDate", level.TcbStatus)
+}
+
+// TestGenerateDcapFixtures records a quote and its collateral issued by a test PKI in testdata/dcap
+func TestGenerateDcapFixtures(t *testing.T) {
+	if !*updateFixtures {
+		t.Skip("fixtures are only regenerated with -update-fixtures")
+	}
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		return key
+	}
+	serial := int64(0)
+	newCert := func(cn string, isCA bool, key *ecdsa.PrivateKey, issuer *x509.Certificate, issuerKey *ecdsa.PrivateKey, extensions ...pkix.Extension) (*x509.Certificate, string) {
+		serial++
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn, Organization: []string{"FPC Test"}},
+			NotBefore:             dcapTestIssueDate.AddDate(-1, 0, 0),
+			NotAfter:              dcapTestIssueDate.AddDate(30, 0, 0),
+			KeyUsage:              x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  isCA,
+			ExtraExtensions:       extensions,
+		}
+		if isCA {
+			template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		}
+		if issuer == nil {
+			issuer, issuerKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), issuerKey)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+	sign := func(key *ecdsa.PrivateKey, message []byte) []byte {
+		hash := sha256.Sum256(message)
+		r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+		require.NoError(t, err)
+		signature := make([]byte, ecdsaSignatureLen)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+		return signature
+	}
+	marshal := func(value interface{}) []byte {
+		data, err := asn1.Marshal(value)
+		require.NoError(t, err)
+		return data
+	}
+	entry := func(oid asn1.ObjectIdentifier, value interface{}) sgxExtensionEntry {
+		return sgxExtensionEntry{Id: oid, Value: asn1.RawValue{FullBytes: marshal(value)}}
+	}
+	signedJson := func(field string, body interface{}, key *ecdsa.PrivateKey) string {
+		bodyBytes, err := json.Marshal(body)
+		require.NoError(t, err)
+		document, err := json.Marshal(map[string]interface{}{
+			field:       json.RawMessage(bodyBytes),
+			"signature": hex.EncodeToString(sign(key, bodyBytes)),
+		})
+		require.NoError(t, err)
+		return string(document)
+	}
+
+	// test PKI
+	rootKey, pckCAKey, pckKey, tcbSigningKey := newKey(), newKey(), newKey(), newKey()
+	rootCert, rootPem := newCert("Test SGX Root CA", true, rootKey, nil, nil)
+	pckCACert, pckCAPem := newCert("Test SGX PCK Platform CA", true, pckCAKey, rootCert, rootKey)
+	_, tcbSigningPem := newCert("Test SGX TCB Signing", false, tcbSigningKey, rootCert, rootKey)
+
+	// PCK certificate of a platform with all TCB components at svn 5 and pce svn 13
+	fmspc, err := hex.DecodeString(dcapTestFmspc)
+	require.NoError(t, err)
+	tcb := []sgxExtensionEntry{}
+	for i := 1; i <= sgxTcbComponents; i++ {
+		tcb = append(tcb, entry(append(append(asn1.ObjectIdentifier{}, oidSgxTcb...), i), 5))
+	}
+	tcb = append(tcb,
+		entry(append(append(asn1.ObjectIdentifier{}, oidSgxTcb...), sgxTcbPceSvn), 13),
+		entry(append(append(asn1.ObjectIdentifier{}, oidSgxTcb...), sgxTcbPceSvn+1), make([]byte, 16)))
+	sgxExtension := marshal([]sgxExtensionEntry{
+		entry(asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 1}, make([]byte, 16)),
+		entry(oidSgxTcb, tcb),
+		entry(oidSgxPceId, []byte{0, 0}),
+		entry(oidSgxFmspc, fmspc),
+		entry(asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 5}, asn1.Enumerated(0)),
+	})
+	pckCert, pckPem := newCert("Test SGX PCK Certificate", false, pckKey, pckCACert, pckCAKey,
+		pkix.Extension{Id: oidSgxExtension, Value: sgxExtension})
+
+	// PCK CRLs revoking another certificate and the PCK certificate
+	newCrl := func(number int64, serial *big.Int) string {
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(number),
+			ThisUpdate: dcapTestIssueDate,
+			NextUpdate: dcapTestIssueDate.AddDate(0, 1, 0),
+			RevokedCertificates: []pkix.RevokedCertificate{
+				{SerialNumber: serial, RevocationTime: dcapTestIssueDate.AddDate(0, 0, -1)},
+			},
+		}, pckCACert, pckCAKey)
+		require.NoError(t, err)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}))
+	}
+	pckCrl := newCrl(1, big.NewInt(1000))
+	pckCrlRevoked := newCrl(2, pckCert.SerialNumber)
+
+	// collateral
+	type svn struct {
+		Svn int `json:"svn"`
+	}
+	tcbLevelJson := func(svnValue, pceSvn int, status string, advisories ...string) map[string]interface{} {
+		components := make([]svn, sgxTcbComponents)
+		for i := range components {
+			components[i].Svn = svnValue
+		}
+		return map[string]interface{}{
+			"tcb":         map[string]interface{}{"sgxtcbcomponents": components, "pcesvn": pceSvn},
+			"tcbDate":     dcapTestIssueDate.AddDate(0, -1, 0),
+			"tcbStatus":   status,
+			"advisoryIDs": advisories,
+		}
+	}
+	tcbInfoJson := signedJson("tcbInfo", map[string]interface{}{
+		"id":                      "SGX",
+		"version":                 tcbInfoVersion,
+		"issueDate":               dcapTestIssueDate,
+		"nextUpdate":              dcapTestIssueDate.AddDate(0, 1, 0),
+		"fmspc":                   dcapTestFmspc,
+		"pceId":                   "0000",
+		"tcbType":                 0,
+		"tcbEvaluationDataNumber": 16,
+		"tcbLevels": []interface{}{
+			tcbLevelJson(5, 13, TcbStatusUpToDate),
+			tcbLevelJson(2, 10, "OutOfDate", "INTEL-SA-00000"),
+		},
+	}, tcbSigningKey)
+
+	qeMrSigner := sha256.Sum256([]byte("test quoting enclave signer"))
+	qeTcbLevelJson := func(isvSvn int, status string) map[string]interface{} {
+		return map[string]interface{}{
+			"tcb":       map[string]interface{}{"isvsvn": isvSvn},
+			"tcbDate":   dcapTestIssueDate.AddDate(0, -1, 0),
+			"tcbStatus": status,
+		}
+	}
+	qeIdentityJson := signedJson("enclaveIdentity", map[string]interface{}{
+		"id":                      "QE",
+		"version":                 qeIdentityVersion,
+		"issueDate":               dcapTestIssueDate,
+		"nextUpdate":              dcapTestIssueDate.AddDate(0, 1, 0),
+		"tcbEvaluationDataNumber": 16,
+		"miscselect":              "00000000",
+		"miscselectMask":          "FFFFFFFF",
+		"attributes":              "11000000000000000000000000000000",
+		"attributesMask":          "FBFFFFFFFFFFFFFF0000000000000000",
+		"mrsigner":                strings.ToUpper(hex.EncodeToString(qeMrSigner[:])),
+		"isvprodid":               1,
+		"tcbLevels": []interface{}{
+			qeTcbLevelJson(8, TcbStatusUpToDate),
+			qeTcbLevelJson(6, "OutOfDate"),
+		},
+	}, tcbSigningKey)
+
+	// quote
+	reportBody := func(flags byte, mrenclave, mrsigner []byte, isvProdId, isvSvn uint16, reportData []byte) []byte {
+		body := make([]byte, quoteReportBodyLen)
+		body[reportAttributesOffset] = flags
+		copy(body[reportMrEnclaveOffset:], mrenclave)
+		copy(body[reportMrSignerOffset:], mrsigner)
+		binary.LittleEndian.PutUint16(body[reportIsvProdIdOffset:], isvProdId)
+		binary.LittleEndian.PutUint16(body[reportIsvSvnOffset:], isvSvn)
+		copy(body[reportDataOffset:], reportData)
+		return body
+	}
+
+	header := make([]byte, quoteHeaderLen)
+	binary.LittleEndian.PutUint16(header[0:], quoteVersion)
+	binary.LittleEndian.PutUint16(header[2:], quoteAttKeyTypeP256)
+	binary.LittleEndian.PutUint16(header[8:], 8)
+	binary.LittleEndian.PutUint16(header[10:], 13)
+
+	mrenclave, err := hex.DecodeString(dcapTestMrEnclave())
+	require.NoError(t, err)
+	statementHash := sha256.Sum256([]byte(dcapTestStatement))
+	// release enclave with INIT and MODE64BIT flags
+	signed := append(header, reportBody(0x05, mrenclave, make([]byte, 32), 0, 1, statementHash[:])...)
+
+	attestKey := newKey()
+	attestPublicKey := make([]byte, ecdsaPublicKeyLen)
+	attestKey.X.FillBytes(attestPublicKey[:32])
+	attestKey.Y.FillBytes(attestPublicKey[32:])
+	qeAuthData := make([]byte, 32)
+	for i := range qeAuthData {
+		qeAuthData[i] = byte(i)
+	}
+	attestKeyHash := sha256.Sum256(append(append([]byte{}, attestPublicKey...), qeAuthData...))
+	qeReport := reportBody(0x11, make([]byte, 32), qeMrSigner[:], 1, 8, attestKeyHash[:])
+	certData := []byte(pckPem + pckCAPem + rootPem)
+
+	sigData := append([]byte{}, sign(attestKey, signed)...)
+	sigData = append(sigData, attestPublicKey...)
+	sigData = append(sigData, qeReport...)
+	sigData = append(sigData, sign(pckKey, qeReport)...)
+	sigData = append(sigData, byte(len(qeAuthData)), byte(len(qeAuthData)>>8))
+	sigData = append(sigData, qeAuthData...)
+	sigData = append(sigData, pckCertChainCertDataType, 0)
+	sigData = append(sigData, make([]byte, 4)...)
+	binary.LittleEndian.PutUint32(sigData[len(sigData)-4:], uint32(len(certData)))
+	sigData = append(sigData, certData...)
+
+	quote := append([]byte{}, signed...)
+	quote = append(quote, make([]byte, quoteSignatureLenLen)...)
+	binary.LittleEndian.PutUint32(quote[quoteSignedLen:], uint32(len(sigData)))
+	quote = append(quote, sigData...)
+
+	for name, content := range map[string]string{
+		dcapQuoteFile:             base64.StdEncoding.EncodeToString(quote),
+		dcapRootCAFile:            rootPem,
+		TcbInfoFile:               tcbInfoJson,
+		TcbInfoIssuerChainFile:    tcbSigningPem + rootPem,
+		QeIdentityFile:            qeIdentityJson,
+		QeIdentityIssuerChainFile: tcbSigningPem + rootPem,
+		PckCrlFile:                pckCrl,
+		PckCrlIssuerChainFile:     pckCAPem + rootPem,
+		dcapRevokedCrlFile:        pckCrlRevoked,
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dcapFixturesPath, name), []byte(content), 0644))
+	}
+}
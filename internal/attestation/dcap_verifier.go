@@ -0,0 +1,461 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IntelSGXRootCA is the Intel SGX Root CA certificate, the root of trust of the PCK certificates, the TCB info, and
+// the QE identity
+const IntelSGXRootCA = `-----BEGIN CERTIFICATE-----
+MIICjzCCAjSgAwIBAgIUImUM1lqdNInzg7SVUr9QGzknBqwwCgYIKoZIzj0EAwIw
+aDEaMBgGA1UEAwwRSW50ZWwgU0dYIFJvb3QgQ0ExGjAYBgNVBAoMEUludGVsIENv
+cnBvcmF0aW9uMRQwEgYDVQQHDAtTYW50YSBDbGFyYTELMAkGA1UECAwCQ0ExCzAJ
+BgNVBAYTAlVTMB4XDTE4MDUyMTEwNDUxMFoXDTQ5MTIzMTIzNTk1OVowaDEaMBgG
+A1UEAwwRSW50ZWwgU0dYIFJvb3QgQ0ExGjAYBgNVBAoMEUludGVsIENvcnBvcmF0
+aW9uMRQwEgYDVQQHDAtTYW50YSBDbGFyYTELMAkGA1UECAwCQ0ExCzAJBgNVBAYT
+AlVTMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEC6nEwMDIYZOj/iPWsCzaEKi7
+1OiOSLRFhWGjbnBVJfVnkY4u3IjkDYYL0MxO4mqsyYjlBalTVYxFP2sJBK5zlKOB
+uzCBuDAfBgNVHSMEGDAWgBQiZQzWWp00ifODtJVSv1AbOScGrDBSBgNVHR8ESzBJ
+MEegRaBDhkFodHRwczovL2NlcnRpZmljYXRlcy50cnVzdGVkc2VydmljZXMuaW50
+ZWwuY29tL0ludGVsU0dYUm9vdENBLmRlcjAdBgNVHQ4EFgQUImUM1lqdNInzg7SV
+Ur9QGzknBqwwDgYDVR0PAQH/BAQDAgEGMBIGA1UdEwEB/wQIMAYBAf8CAQEwCgYI
+KoZIzj0EAwIDSQAwRgIhAOW/5QkR+S9CiSDcNoowLuPRLsWGf/Yi7GSX94BgwTwg
+AiEA4J0lrHoMs+Xo5o/sX6O9QWxHRAvZUGOdRQ7cvqRXaqI=
+-----END CERTIFICATE-----
+`
+
+// supported versions of the collateral
+const (
+	tcbInfoVersion    = 3
+	qeIdentityVersion = 2
+)
+
+// DcapReport contains the verified content of a DCAP evidence
+type DcapReport struct {
+	ReportBody *SgxReportBody
+	// TcbStatus is the status of the TCB level of the platform according to the TCB info
+	TcbStatus   string
+	AdvisoryIDs []string
+	// QeTcbStatus is the status of the TCB level of the quoting enclave according to the QE identity
+	QeTcbStatus string
+}
+
+// DcapVerifier verifies DCAP evidence created by the converter returned by NewDcapConverter
+type DcapVerifier struct {
+	roots *x509.CertPool
+	now   func() time.Time
+}
+
+type DcapVerifierOption func(*DcapVerifier)
+
+// WithRootCertificates option allows to override the trusted root certificates (IntelSGXRootCA). Mainly used for
+// testing
+func WithRootCertificates(roots *x509.CertPool) DcapVerifierOption {
+	return func(v *DcapVerifier) {
+		v.roots = roots
+	}
+}
+
+// WithCurrentTime option allows to override the time used to check the validity of certificates and collateral.
+// Mainly used for testing
+func WithCurrentTime(now func() time.Time) DcapVerifierOption {
+	return func(v *DcapVerifier) {
+		v.now = now
+	}
+}
+
+// NewDcapVerifier returns a new DcapVerifier which trusts IntelSGXRootCA.
+// Optionally, DcapVerifierOption can be provided to change the behavior of the DcapVerifier.
+func NewDcapVerifier(opts ...DcapVerifierOption) *DcapVerifier {
+	verifier := &DcapVerifier{
+		now: time.Now,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(verifier)
+	}
+
+	if verifier.roots == nil {
+		verifier.roots = x509.NewCertPool()
+		if !verifier.roots.AppendCertsFromPEM([]byte(IntelSGXRootCA)) {
+			// ouch this should never happen
+			logger.Panicf("cannot parse Intel SGX Root CA certificate")
+		}
+	}
+
+	return verifier
+}
+
+// At returns a copy of the verifier which checks the validity of certificates and collateral at the given time
+// instead of the current time, e.g., at the timestamp of a transaction such that all endorsers reach the same result.
+func (v *DcapVerifier) At(now time.Time) *DcapVerifier {
+	verifier := *v
+	verifier.now = func() time.Time { return now }
+	return &verifier
+}
+
+// VerifyEvidence verifies DCAP evidence, checks that it complies with the policy, and that the attested enclave has the
+// expected mrenclave (hex encoded) and that the report data binds the expected statement.
+// If policy is nil, the DefaultVerificationPolicy is applied.
+func (v *DcapVerifier) VerifyEvidence(evidenceBytes, expectedStatementBytes []byte, expectedMrEnclave string, policy *VerificationPolicy) error {
+	report, err := v.Verify(evidenceBytes)
+	if err != nil {
+		return err
+	}
+
+	if policy == nil {
+		policy = DefaultVerificationPolicy()
+	}
+	if err := policy.CheckDcapReport(report); err != nil {
+		return err
+	}
+
+	return checkReportBinding(report.ReportBody, expectedStatementBytes, expectedMrEnclave)
+}
+
+// Verify verifies DCAP evidence and returns the verified report. The evidence is valid if
+//   - the PCK certificate chain and the issuer chains of the collateral chain up to a trusted root certificate,
+//   - the PCK certificate is not revoked by the PCK CRL of its issuer,
+//   - the quoting enclave report is signed by the PCK certificate and binds the attestation key,
+//   - the quote is signed by the attestation key,
+//   - the quoting enclave matches the QE identity, and
+//   - the TCB level of the platform and of the quoting enclave are not revoked.
+func (v *DcapVerifier) Verify(evidenceBytes []byte) (*DcapReport, error) {
+	evidence := &DcapEvidence{}
+	if err := json.Unmarshal(evidenceBytes, evidence); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal dcap evidence")
+	}
+	if evidence.Collateral == nil {
+		return nil, errors.New("no collateral")
+	}
+	collateral := evidence.Collateral
+
+	quoteBytes, err := base64.StdEncoding.DecodeString(evidence.Quote)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode quote")
+	}
+	quote, err := ParseDcapQuote(quoteBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse quote")
+	}
+
+	// check PCK certificate
+	pckCert, err := v.verifyCertChain(collateral.PckCertChain)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK certificate chain")
+	}
+	pck, err := parsePckInfo(pckCert)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid PCK certificate")
+	}
+	if err := v.checkRevocation(collateral.PckCrl, collateral.PckCrlIssuerChain, pckCert); err != nil {
+		return nil, err
+	}
+
+	// check quoting enclave report signature and attestation key binding
+	pckKey, ok := pckCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("PCK certificate has no ECDSA public key")
+	}
+	qeReportHash := sha256.Sum256(quote.QeReport.raw)
+	if !verifyP256Signature(pckKey, qeReportHash[:], quote.QeReportSig) {
+		return nil, errors.New("invalid QE report signature")
+	}
+	attestKeyHash := sha256.Sum256(append(append([]byte{}, quote.AttestKey...), quote.QeAuthData...))
+	if !bytes.Equal(quote.QeReport.ReportData[:sha256.Size], attestKeyHash[:]) {
+		return nil, errors.New("attestation key not bound by QE report")
+	}
+
+	// check quote signature
+	attestKey, err := parseP256PublicKey(quote.AttestKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid attestation key")
+	}
+	quoteHash := sha256.Sum256(quote.signed)
+	if !verifyP256Signature(attestKey, quoteHash[:], quote.Signature) {
+		return nil, errors.New("invalid quote signature")
+	}
+
+	// check TCB level of the platform
+	info := &tcbInfo{}
+	if err := v.verifySignedCollateral(collateral.TcbInfo, collateral.TcbInfoIssuerChain, "tcbInfo", info); err != nil {
+		return nil, errors.Wrap(err, "invalid TCB info")
+	}
+	if err := v.checkValidity(info.Version, tcbInfoVersion, info.NextUpdate); err != nil {
+		return nil, errors.Wrap(err, "invalid TCB info")
+	}
+	if !strings.EqualFold(info.Fmspc, pck.Fmspc) || !strings.EqualFold(info.PceId, pck.PceId) {
+		return nil, fmt.Errorf("TCB info for FMSPC %s does not match platform with FMSPC %s", info.Fmspc, pck.Fmspc)
+	}
+	tcbLevel, err := info.match(pck)
+	if err != nil {
+		return nil, err
+	}
+
+	// check quoting enclave identity and TCB level
+	identity := &qeIdentity{}
+	if err := v.verifySignedCollateral(collateral.QeIdentity, collateral.QeIdentityIssuerChain, "enclaveIdentity", identity); err != nil {
+		return nil, errors.Wrap(err, "invalid QE identity")
+	}
+	if err := v.checkValidity(identity.Version, qeIdentityVersion, identity.NextUpdate); err != nil {
+		return nil, errors.Wrap(err, "invalid QE identity")
+	}
+	qeTcbLevel, err := identity.match(quote.QeReport)
+	if err != nil {
+		return nil, err
+	}
+
+	if tcbLevel.TcbStatus == TcbStatusRevoked {
+		return nil, errors.New("TCB level of the platform is revoked")
+	}
+	if qeTcbLevel.TcbStatus == TcbStatusRevoked {
+		return nil, errors.New("TCB level of the quoting enclave is revoked")
+	}
+
+	return &DcapReport{
+		ReportBody:  quote.ReportBody,
+		TcbStatus:   tcbLevel.TcbStatus,
+		AdvisoryIDs: tcbLevel.AdvisoryIDs,
+		QeTcbStatus: qeTcbLevel.TcbStatus,
+	}, nil
+}
+
+// verifyCertChain verifies a PEM encoded certificate chain and returns its leaf certificate
+func (v *DcapVerifier) verifyCertChain(chainPem string) (*x509.Certificate, error) {
+	certs, err := parseCertChain(chainPem)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.now(),
+		// Intel SGX certificates do not specify an extended key usage
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return certs[0], nil
+}
+
+// checkRevocation verifies the PEM encoded PCK CRL and checks that it does not revoke the PCK certificate.
+// The CRL must be issued by the issuer of the PCK certificate, i.e., the leaf of the CRL issuer chain.
+func (v *DcapVerifier) checkRevocation(crlPem, issuerChain string, pckCert *x509.Certificate) error {
+	crlIssuer, err := v.verifyCertChain(issuerChain)
+	if err != nil {
+		return errors.Wrap(err, "invalid PCK CRL issuer chain")
+	}
+	if err := pckCert.CheckSignatureFrom(crlIssuer); err != nil {
+		return errors.Wrap(err, "PCK CRL is not issued by the issuer of the PCK certificate")
+	}
+
+	crl, err := x509.ParseCRL([]byte(crlPem))
+	if err != nil {
+		return errors.Wrap(err, "invalid PCK CRL")
+	}
+	if err := crlIssuer.CheckCRLSignature(crl); err != nil {
+		return errors.Wrap(err, "invalid PCK CRL signature")
+	}
+	if crl.HasExpired(v.now()) {
+		return fmt.Errorf("PCK CRL expired at %s", crl.TBSCertList.NextUpdate)
+	}
+
+	for _, revoked := range crl.TBSCertList.RevokedCertificates {
+		if revoked.SerialNumber.Cmp(pckCert.SerialNumber) == 0 {
+			return errors.New("PCK certificate is revoked")
+		}
+	}
+
+	return nil
+}
+
+// verifySignedCollateral verifies the signature of a signed json document such as the TCB info or the QE identity and
+// unmarshals the signed body (stored with the given field name) into body.
+// The signature covers the exact bytes of the body in the document.
+func (v *DcapVerifier) verifySignedCollateral(document, issuerChain, field string, body interface{}) error {
+	signed := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(document), &signed); err != nil {
+		return errors.Wrap(err, "cannot unmarshal json")
+	}
+	signedBody, ok := signed[field]
+	if !ok {
+		return fmt.Errorf("no %s", field)
+	}
+	var signatureHex string
+	if err := json.Unmarshal(signed["signature"], &signatureHex); err != nil {
+		return errors.Wrap(err, "invalid signature")
+	}
+	signature, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return errors.Wrap(err, "invalid signature")
+	}
+
+	signingCert, err := v.verifyCertChain(issuerChain)
+	if err != nil {
+		return errors.Wrap(err, "invalid issuer chain")
+	}
+	signingKey, ok := signingCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("signing certificate has no ECDSA public key")
+	}
+
+	hash := sha256.Sum256(signedBody)
+	if !verifyP256Signature(signingKey, hash[:], signature) {
+		return errors.New("invalid signature")
+	}
+
+	return json.Unmarshal(signedBody, body)
+}
+
+func (v *DcapVerifier) checkValidity(version, expectedVersion int, nextUpdate time.Time) error {
+	if version != expectedVersion {
+		return fmt.Errorf("unsupported version %d", version)
+	}
+	if v.now().After(nextUpdate) {
+		return fmt.Errorf("expired at %s", nextUpdate)
+	}
+	return nil
+}
+
+type tcbInfo struct {
+	Id         string     `json:"id"`
+	Version    int        `json:"version"`
+	IssueDate  time.Time  `json:"issueDate"`
+	NextUpdate time.Time  `json:"nextUpdate"`
+	Fmspc      string     `json:"fmspc"`
+	PceId      string     `json:"pceId"`
+	TcbLevels  []tcbLevel `json:"tcbLevels"`
+}
+
+type tcbLevel struct {
+	Tcb struct {
+		SgxTcbComponents []struct {
+			Svn int `json:"svn"`
+		} `json:"sgxtcbcomponents"`
+		PceSvn int `json:"pcesvn"`
+	} `json:"tcb"`
+	TcbDate     time.Time `json:"tcbDate"`
+	TcbStatus   string    `json:"tcbStatus"`
+	AdvisoryIDs []string  `json:"advisoryIDs"`
+}
+
+// match returns the first (i.e., highest) TCB level that is lower or equal to the TCB of the platform
+func (t *tcbInfo) match(pck *pckInfo) (*tcbLevel, error) {
+	for i := range t.TcbLevels {
+		level := &t.TcbLevels[i]
+		if len(level.Tcb.SgxTcbComponents) != sgxTcbComponents {
+			return nil, fmt.Errorf("invalid TCB level with %d components", len(level.Tcb.SgxTcbComponents))
+		}
+
+		matches := pck.PceSvn >= level.Tcb.PceSvn
+		for j, comp := range level.Tcb.SgxTcbComponents {
+			matches = matches && pck.TcbSvns[j] >= comp.Svn
+		}
+		if matches {
+			return level, nil
+		}
+	}
+
+	return nil, errors.New("no matching TCB level for the platform")
+}
+
+type qeIdentity struct {
+	Id             string       `json:"id"`
+	Version        int          `json:"version"`
+	IssueDate      time.Time    `json:"issueDate"`
+	NextUpdate     time.Time    `json:"nextUpdate"`
+	MiscSelect     string       `json:"miscselect"`
+	MiscSelectMask string       `json:"miscselectMask"`
+	Attributes     string       `json:"attributes"`
+	AttributesMask string       `json:"attributesMask"`
+	MrSigner       string       `json:"mrsigner"`
+	IsvProdId      uint16       `json:"isvprodid"`
+	TcbLevels      []qeTcbLevel `json:"tcbLevels"`
+}
+
+type qeTcbLevel struct {
+	Tcb struct {
+		IsvSvn uint16 `json:"isvsvn"`
+	} `json:"tcb"`
+	TcbDate     time.Time `json:"tcbDate"`
+	TcbStatus   string    `json:"tcbStatus"`
+	AdvisoryIDs []string  `json:"advisoryIDs"`
+}
+
+// match checks that the quoting enclave report matches the QE identity and returns the first (i.e., highest) TCB
+// level that is lower or equal to the ISV SVN of the quoting enclave
+func (q *qeIdentity) match(report *SgxReportBody) (*qeTcbLevel, error) {
+	miscSelect := make([]byte, 4)
+	for i := range miscSelect {
+		miscSelect[i] = byte(report.MiscSelect >> (8 * (3 - i)))
+	}
+	if err := matchMasked(miscSelect, q.MiscSelect, q.MiscSelectMask); err != nil {
+		return nil, errors.Wrap(err, "QE miscselect mismatch")
+	}
+	if err := matchMasked(report.Attributes, q.Attributes, q.AttributesMask); err != nil {
+		return nil, errors.Wrap(err, "QE attributes mismatch")
+	}
+	if !strings.EqualFold(hex.EncodeToString(report.MrSigner), q.MrSigner) {
+		return nil, errors.New("QE mrsigner mismatch")
+	}
+	if report.IsvProdId != q.IsvProdId {
+		return nil, errors.New("QE isvprodid mismatch")
+	}
+
+	for i := range q.TcbLevels {
+		if report.IsvSvn >= q.TcbLevels[i].Tcb.IsvSvn {
+			return &q.TcbLevels[i], nil
+		}
+	}
+
+	return nil, errors.New("no matching TCB level for the quoting enclave")
+}
+
+// matchMasked compares value with the hex encoded expected value after applying the hex encoded mask to both
+func matchMasked(value []byte, expectedHex, maskHex string) error {
+	expected, err := hex.DecodeString(expectedHex)
+	if err != nil {
+		return err
+	}
+	mask, err := hex.DecodeString(maskHex)
+	if err != nil {
+		return err
+	}
+	if len(expected) != len(value) || len(mask) != len(value) {
+		return fmt.Errorf("unexpected length %d", len(expected))
+	}
+
+	for i := range value {
+		if value[i]&mask[i] != expected[i]&mask[i] {
+			return errors.New("values do not match")
+		}
+	}
+
+	return nil
+}
@@ -8,40 +8,77 @@ SPDX-License-Identifier: Apache-2.0
 package attestation
 
 import (
-	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"strings"
-
 	"github.com/pkg/errors"
 )
 
+// attestation types of Intel SGX EPID attestation
+const (
+	EpidLinkableType   = "epid-linkable"
+	EpidUnlinkableType = "epid-unlinkable"
+)
+
+type epidConverterConfig struct {
+	ias         IntelAttestationService
+	credentials CredentialProvider
+}
+
+type EpidConverterOption func(*epidConverterConfig)
+
+// WithIntelAttestationService option sets the IAS used to convert attestations, e.g., an IASClient with custom options.
+// By default, an IASClient is created for each conversion using the IAS API key of the CredentialProvider, and the
+// IAS_URL and the IAS_REPORT_CACHE_PATH environment variables.
+func WithIntelAttestationService(ias IntelAttestationService) EpidConverterOption {
+	return func(c *epidConverterConfig) {
+		c.ias = ias
+	}
+}
+
+// WithCredentialProvider option sets the provider of the IAS API key (default DefaultCredentialProvider).
+// It is ignored if the IAS is set using WithIntelAttestationService.
+func WithCredentialProvider(credentials CredentialProvider) EpidConverterOption {
+	return func(c *epidConverterConfig) {
+		c.credentials = credentials
+	}
+}
+
 // NewEpidUnlinkableConverter creates a new attestation converter for Intel SGX EPID (unlinkable) attestation
-func NewEpidUnlinkableConverter() *Converter {
+func NewEpidUnlinkableConverter(opts ...EpidConverterOption) *Converter {
 	return &Converter{
-		Type:      "epid-unlinkable",
-		Converter: newEpidConverter(),
+		Type:      EpidUnlinkableType,
+		Converter: newEpidConverter(opts...),
 	}
 }
 
 // NewEpidLinkableConverter creates a new attestation converter for Intel SGX EPID (linkable) attestation
-func NewEpidLinkableConverter() *Converter {
+func NewEpidLinkableConverter(opts ...EpidConverterOption) *Converter {
 	return &Converter{
-		Type:      "epid-linkable",
-		Converter: newEpidConverter(),
+		Type:      EpidLinkableType,
+		Converter: newEpidConverter(opts...),
 	}
 }
 
-func newEpidConverter() ConvertFunction {
+func newEpidConverter(opts ...EpidConverterOption) ConvertFunction {
+	config := &epidConverterConfig{}
+
+	// apply options
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.credentials == nil {
+		config.credentials = DefaultCredentialProvider()
+	}
+
 	return func(attestationBytes []byte) (evidenceBytes []byte, err error) {
 
-		apiKey, err := loadApiKey()
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot load IAS API key")
+		ias := config.ias
+		if ias == nil {
+			ias, err = newDefaultIASClient(config.credentials)
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		ias := NewIASClient(apiKey)
 		evidence, err := ias.RequestAttestationReport(string(attestationBytes))
 		if err != nil {
 			return nil, errors.Wrap(err, "cannot convert epid attestation")
@@ -51,57 +88,18 @@ func newEpidConverter() ConvertFunction {
 	}
 }
 
-// loadApiKey tries to load the IAS API Key from environment variable.
-// If env var not set, use loadApiKeyFromCredentialsEnvPath and then loadApiKeyFromFPCConfig as fallback
-func loadApiKey() (string, error) {
-	// try to load from env variable
-	apiKey := os.Getenv("IAS_API_KEY")
-	if len(apiKey) != 0 {
-		return apiKey, nil
-	}
-
-	// fallback read from $SGX_CREDENTIALS_PATH
-	apiKey, err := loadApiKeyFromCredentialsEnvPath()
-	if err == nil {
-		return apiKey, nil
-	}
-
-	// fallback read from $FPC_PATH
-	return loadApiKeyFromFPCConfig()
-}
-
-func loadApiKeyFromCredentialsEnvPath() (string, error) {
-	path := os.Getenv("SGX_CREDENTIALS_PATH")
-	if len(path) == 0 {
-		return "", fmt.Errorf("$SGX_CREDENTIALS_PATH not set")
-	}
-
-	return loadApiKeyFromPath(path)
-}
-
-// loadApiKeyFromFPCConfig tries to load IAS API Key from $FPC_PATH/config/ias/api_key.txt
-func loadApiKeyFromFPCConfig() (string, error) {
-	fpcPath := os.Getenv("FPC_PATH")
-	if len(fpcPath) == 0 {
-		return "", fmt.Errorf("$FPC_PATH not set")
-	}
-
-	path := filepath.Join(fpcPath, "config", "ias")
-	return loadApiKeyFromPath(path)
-}
-
-// loadApiKeyFromPath loads IAS API key from path/api_key.txt
-func loadApiKeyFromPath(path string) (string, error) {
-	path = filepath.Join(path, "api_key.txt")
-
-	data, err := ioutil.ReadFile(path)
+// newDefaultIASClient returns an IASClient using the API key of the CredentialProvider and configured by environment
+// variables
+func newDefaultIASClient(credentials CredentialProvider) (*IASClient, error) {
+	apiKey, err := credentials.GetAPIKey(IASService)
 	if err != nil {
-		return "", errors.Wrapf(err, "could not read %s", path)
+		return nil, errors.Wrap(err, "cannot load IAS API key")
 	}
 
-	if len(data) == 0 {
-		return "", errors.Errorf("empty file %s", path)
+	opts := []IASClientOption{WithUrl(loadIASUrl())}
+	if cachePath := loadIASReportCachePath(); len(cachePath) != 0 {
+		opts = append(opts, WithReportCache(cachePath, 0))
 	}
 
-	return strings.TrimSuffix(string(data), "\n"), nil
+	return NewIASClient(string(apiKey), opts...), nil
 }
@@ -8,16 +8,42 @@ package attestation
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 const DefaultIASUrl = "https://api.trustedservices.intel.com/sgx/dev/attestation/v4/report"
 
+// default retry behavior of the IASClient
+const (
+	DefaultIASMaxRetries     = 3
+	DefaultIASInitialBackoff = 1 * time.Second
+	DefaultIASMaxBackoff     = 30 * time.Second
+)
+
+// loadIASUrl returns the IAS url set by the IAS_URL environment variable; if not set, DefaultIASUrl is returned
+func loadIASUrl() string {
+	if url := os.Getenv("IAS_URL"); len(url) != 0 {
+		return url
+	}
+	return DefaultIASUrl
+}
+
+// loadIASReportCachePath returns the path of the report cache set by the IAS_REPORT_CACHE_PATH environment variable
+func loadIASReportCachePath() string {
+	return os.Getenv("IAS_REPORT_CACHE_PATH")
+}
+
 type IntelAttestationService interface {
 	RequestAttestationReport(quoteBase64 string) (reportJson string, err error)
 }
@@ -55,9 +81,14 @@ type HTTPClient interface {
 }
 
 type IASClient struct {
-	url        string
-	apiKey     string
-	httpClient HTTPClient
+	url            string
+	apiKey         string
+	httpClient     HTTPClient
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	cachePath      string
+	cacheMaxAge    time.Duration
 }
 
 type IASClientOption func(*IASClient)
@@ -76,13 +107,38 @@ func WithHttpClient(client HTTPClient) IASClientOption {
 	}
 }
 
+// WithRetry option allows to override the default retry behavior. A request failing with 429 or 5xx, or with a
+// connection error, is retried up to maxRetries times, waiting initialBackoff before the first retry and doubling the
+// waiting time with every retry up to maxBackoff. A Retry-After header sent by the IAS takes precedence, but the
+// waiting time never exceeds maxBackoff.
+func WithRetry(maxRetries int, initialBackoff, maxBackoff time.Duration) IASClientOption {
+	return func(c *IASClient) {
+		c.maxRetries = maxRetries
+		c.initialBackoff = initialBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithReportCache option enables an on-disk cache of attestation reports, stored in path and keyed by the hash of the
+// quote. Cached reports older than maxAge are requested again; if maxAge is 0, cached reports never expire.
+// Note that the verification policy may reject (cached) reports which exceed the maximum report age.
+func WithReportCache(path string, maxAge time.Duration) IASClientOption {
+	return func(c *IASClient) {
+		c.cachePath = path
+		c.cacheMaxAge = maxAge
+	}
+}
+
 // NewIASClient returns a new IASClient instance using DefaultIASUrl as IAS endpoint
 // This method requires an API Key as input in order to authenticate with the IAS.
 // Optionally, IASClientOption can be provided to change the behavior of the IASClient.
 func NewIASClient(apiKey string, opts ...IASClientOption) *IASClient {
 	client := &IASClient{
-		url:    DefaultIASUrl,
-		apiKey: apiKey,
+		url:            DefaultIASUrl,
+		apiKey:         apiKey,
+		maxRetries:     DefaultIASMaxRetries,
+		initialBackoff: DefaultIASInitialBackoff,
+		maxBackoff:     DefaultIASMaxBackoff,
 	}
 
 	// apply options
@@ -101,16 +157,22 @@ func NewIASClient(apiKey string, opts ...IASClientOption) *IASClient {
 // RequestAttestationReport submits a quote (provided as base64 encoded string) to the Intel Attestation Service (IAS)
 // in order to verify it and generate an attestation report.
 // The report returned by the attestation service is packaged as a IASReport and serialized as json string.
+// If the report cache is enabled, a cached report of the same quote is returned instead of contacting the IAS.
 func (i *IASClient) RequestAttestationReport(quoteBase64 string) (reportJson string, err error) {
 
+	if reportJson, ok := i.loadCachedReport(quoteBase64); ok {
+		logger.Debugf("Use cached IAS report")
+		return reportJson, nil
+	}
+
 	// build request
 	request := &IASRequest{
 		Quote: quoteBase64,
 	}
 
-	report, err := i.requestAttestationReport(request)
+	report, err := i.requestAttestationReportWithRetry(request)
 	if err != nil {
-		return "", errors.Wrap(err, "")
+		return "", err
 	}
 
 	serializedReport, err := json.Marshal(report)
@@ -119,9 +181,65 @@ func (i *IASClient) RequestAttestationReport(quoteBase64 string) (reportJson str
 	}
 	reportJson = string(serializedReport)
 
+	i.storeCachedReport(quoteBase64, reportJson)
+
 	return reportJson, nil
 }
 
+// iasRequestError is returned by requestAttestationReport if the request may succeed when retried
+type iasRequestError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *iasRequestError) Error() string {
+	return e.err.Error()
+}
+
+func (i *IASClient) requestAttestationReportWithRetry(request *IASRequest) (*IASReport, error) {
+	backoff := i.initialBackoff
+	for attempt := 0; ; attempt++ {
+		report, err := i.requestAttestationReport(request)
+		retryErr, retryable := err.(*iasRequestError)
+		if err == nil || !retryable {
+			return report, err
+		}
+		if attempt >= i.maxRetries {
+			return nil, errors.Wrapf(retryErr.err, "giving up after %d attempts", attempt+1)
+		}
+
+		wait := backoff
+		if retryErr.retryAfter > 0 {
+			wait = retryErr.retryAfter
+			if wait > i.maxBackoff {
+				wait = i.maxBackoff
+			}
+		}
+		logger.Warningf("IAS request failed (attempt %d): %s; retry in %s", attempt+1, retryErr.err, wait)
+		time.Sleep(wait)
+
+		backoff *= 2
+		if backoff > i.maxBackoff {
+			backoff = i.maxBackoff
+		}
+	}
+}
+
+// parseRetryAfter returns the waiting time of a Retry-After header, which is either a number of seconds or an http
+// date. It returns 0 if the header is missing or invalid, or if the date has passed.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
 func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASReport, err error) {
 
 	requestJson, err := json.Marshal(request)
@@ -140,7 +258,7 @@ func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASRe
 
 	resp, err := i.httpClient.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot perform http request")
+		return nil, &iasRequestError{err: errors.Wrap(err, "cannot perform http request")}
 	}
 	defer resp.Body.Close()
 
@@ -148,7 +266,13 @@ func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASRe
 
 	// check response status code
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("request failed! Reason: %d %s. Request ID: %s", resp.StatusCode, resp.Status, reportRequestId)
+		err := fmt.Errorf("request failed! Reason: %d %s. Request ID: %s", resp.StatusCode, resp.Status, reportRequestId)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			// the IAS may tell us how long to wait
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			return nil, &iasRequestError{err: err, retryAfter: retryAfter}
+		}
+		return nil, err
 	}
 
 	// get header
@@ -157,6 +281,9 @@ func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASRe
 
 	// get the response body
 	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &iasRequestError{err: errors.Wrap(err, "cannot read response body")}
+	}
 
 	report = &IASReport{
 		Signature:    reportSignature,
@@ -166,3 +293,63 @@ func (i *IASClient) requestAttestationReport(request *IASRequest) (report *IASRe
 
 	return report, nil
 }
+
+// cacheFile returns the path of the cached report of a quote
+func (i *IASClient) cacheFile(quoteBase64 string) string {
+	hash := sha256.Sum256([]byte(quoteBase64))
+	return filepath.Join(i.cachePath, hex.EncodeToString(hash[:])+".json")
+}
+
+func (i *IASClient) loadCachedReport(quoteBase64 string) (string, bool) {
+	if len(i.cachePath) == 0 {
+		return "", false
+	}
+
+	path := i.cacheFile(quoteBase64)
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	if i.cacheMaxAge > 0 && time.Since(info.ModTime()) > i.cacheMaxAge {
+		logger.Debugf("Cached IAS report %s expired", path)
+		return "", false
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return "", false
+	}
+
+	return string(data), true
+}
+
+// storeCachedReport writes a report to the cache; failures are logged only, as the cache is just an optimization
+func (i *IASClient) storeCachedReport(quoteBase64, reportJson string) {
+	if len(i.cachePath) == 0 {
+		return
+	}
+
+	if err := os.MkdirAll(i.cachePath, 0700); err != nil {
+		logger.Warningf("Cannot create IAS report cache: %s", err)
+		return
+	}
+
+	// write to a temporary file first so that concurrent readers never see a partial report
+	path := i.cacheFile(quoteBase64)
+	tmp, err := ioutil.TempFile(i.cachePath, ".report-")
+	if err != nil {
+		logger.Warningf("Cannot cache IAS report: %s", err)
+		return
+	}
+	_, err = tmp.WriteString(reportJson)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		logger.Warningf("Cannot cache IAS report: %s", err)
+	}
+}
@@ -9,10 +9,13 @@ package attestation
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/fakes"
 	"github.com/stretchr/testify/assert"
@@ -70,3 +73,121 @@ func TestIAS(t *testing.T) {
 
 	assert.EqualValues(t, expectedReport, report)
 }
+
+func newTestResponse(statusCode int, header http.Header, body string) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestIASRetry(t *testing.T) {
+	dummyQuote := base64.StdEncoding.EncodeToString([]byte("dummyQuote"))
+
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoReturnsOnCall(0, newTestResponse(503, nil, ""), nil)
+	fakeHttpClient.DoReturnsOnCall(1, nil, fmt.Errorf("connection reset"))
+	fakeHttpClient.DoReturnsOnCall(2, newTestResponse(429, http.Header{"Retry-After": []string{"0"}}, ""), nil)
+	fakeHttpClient.DoReturnsOnCall(3, newTestResponse(200, nil, "some body"), nil)
+
+	iasClient := NewIASClient("some_key", WithHttpClient(fakeHttpClient), WithRetry(3, time.Millisecond, 2*time.Millisecond))
+	reportJson, err := iasClient.RequestAttestationReport(dummyQuote)
+	assert.NoError(t, err)
+	assert.Contains(t, reportJson, "some body")
+	assert.Equal(t, 4, fakeHttpClient.DoCallCount())
+
+	// the request is sent again with every retry
+	for i := 0; i < fakeHttpClient.DoCallCount(); i++ {
+		body, err := ioutil.ReadAll(fakeHttpClient.DoArgsForCall(i).Body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), dummyQuote)
+	}
+
+	// give up after max retries
+	fakeHttpClient = &fakes.HTTPClient{}
+	fakeHttpClient.DoStub = func(*http.Request) (*http.Response, error) {
+		return newTestResponse(500, nil, ""), nil
+	}
+	iasClient = NewIASClient("some_key", WithHttpClient(fakeHttpClient), WithRetry(2, time.Millisecond, time.Millisecond))
+	_, err = iasClient.RequestAttestationReport(dummyQuote)
+	assert.Contains(t, err.Error(), "giving up after 3 attempts")
+	assert.Equal(t, 3, fakeHttpClient.DoCallCount())
+
+	// the Retry-After header does not exceed the max backoff
+	fakeHttpClient = &fakes.HTTPClient{}
+	fakeHttpClient.DoReturnsOnCall(0, newTestResponse(503, http.Header{"Retry-After": []string{"3600"}}, ""), nil)
+	fakeHttpClient.DoReturnsOnCall(1, newTestResponse(200, nil, "some body"), nil)
+	iasClient = NewIASClient("some_key", WithHttpClient(fakeHttpClient), WithRetry(1, time.Millisecond, time.Millisecond))
+	start := time.Now()
+	_, err = iasClient.RequestAttestationReport(dummyQuote)
+	assert.NoError(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Minute))
+
+	// client errors are not retried
+	fakeHttpClient = &fakes.HTTPClient{}
+	fakeHttpClient.DoReturns(newTestResponse(401, nil, ""), nil)
+	iasClient = NewIASClient("some_key", WithHttpClient(fakeHttpClient), WithRetry(2, time.Millisecond, time.Millisecond))
+	_, err = iasClient.RequestAttestationReport(dummyQuote)
+	assert.Contains(t, err.Error(), "request failed! Reason: 401")
+	assert.Equal(t, 1, fakeHttpClient.DoCallCount())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-1", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
+
+func TestIASReportCache(t *testing.T) {
+	cachePath, err := ioutil.TempDir("", "ias-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(cachePath)
+
+	dummyQuote := base64.StdEncoding.EncodeToString([]byte("dummyQuote"))
+	otherQuote := base64.StdEncoding.EncodeToString([]byte("otherQuote"))
+
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoStub = func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(200, nil, fmt.Sprintf("report %d", fakeHttpClient.DoCallCount())), nil
+	}
+
+	iasClient := NewIASClient("some_key", WithHttpClient(fakeHttpClient), WithReportCache(cachePath, 0))
+	reportJson, err := iasClient.RequestAttestationReport(dummyQuote)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fakeHttpClient.DoCallCount())
+
+	// the cached report is returned
+	cachedReportJson, err := iasClient.RequestAttestationReport(dummyQuote)
+	assert.NoError(t, err)
+	assert.Equal(t, reportJson, cachedReportJson)
+	assert.Equal(t, 1, fakeHttpClient.DoCallCount())
+
+	// other quotes are requested
+	otherReportJson, err := iasClient.RequestAttestationReport(otherQuote)
+	assert.NoError(t, err)
+	assert.NotEqual(t, reportJson, otherReportJson)
+	assert.Equal(t, 2, fakeHttpClient.DoCallCount())
+
+	files, err := ioutil.ReadDir(cachePath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	// expired reports are requested again
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(iasClient.cacheFile(dummyQuote), old, old))
+	iasClient = NewIASClient("some_key", WithHttpClient(fakeHttpClient), WithReportCache(cachePath, time.Hour))
+	newReportJson, err := iasClient.RequestAttestationReport(dummyQuote)
+	assert.NoError(t, err)
+	assert.NotEqual(t, reportJson, newReportJson)
+	assert.Equal(t, 3, fakeHttpClient.DoCallCount())
+}
@@ -0,0 +1,193 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EpidReport contains the verified content of an IAS report
+type EpidReport struct {
+	Response   *IASResponseBody
+	ReportBody *SgxReportBody
+}
+
+// IASVerifier verifies IAS reports, the evidence of EPID attestation created by the converters returned by
+// NewEpidLinkableConverter and NewEpidUnlinkableConverter
+type IASVerifier struct {
+	roots *x509.CertPool
+	now   func() time.Time
+}
+
+type IASVerifierOption func(*IASVerifier)
+
+// WithIASCurrentTime option allows to override the time used to check the validity of the IAS certificates.
+// Mainly used for testing
+func WithIASCurrentTime(now func() time.Time) IASVerifierOption {
+	return func(v *IASVerifier) {
+		v.now = now
+	}
+}
+
+// NewIASVerifier returns a new IASVerifier which trusts the given root certificates, i.e., the Intel SGX Attestation
+// Report Signing CA certificate.
+// Optionally, IASVerifierOption can be provided to change the behavior of the IASVerifier.
+func NewIASVerifier(roots *x509.CertPool, opts ...IASVerifierOption) *IASVerifier {
+	verifier := &IASVerifier{
+		roots: roots,
+		now:   time.Now,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(verifier)
+	}
+
+	return verifier
+}
+
+// At returns a copy of the verifier which checks the validity of the IAS certificates and the report age at the given
+// time instead of the current time, e.g., at the timestamp of a transaction such that all endorsers reach the same
+// result.
+func (v *IASVerifier) At(now time.Time) *IASVerifier {
+	verifier := *v
+	verifier.now = func() time.Time { return now }
+	return &verifier
+}
+
+// LoadIASRootCertificates loads the (PEM encoded) IAS root certificates from path
+func LoadIASRootCertificates(path string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", path)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, errors.Errorf("no certificates found in %s", path)
+	}
+
+	return roots, nil
+}
+
+// VerifyEvidence verifies an IAS report, checks that it complies with the policy, and that the attested enclave has the
+// expected mrenclave (hex encoded) and that the report data binds the expected statement.
+// If policy is nil, the DefaultVerificationPolicy is applied.
+func (v *IASVerifier) VerifyEvidence(evidenceBytes, expectedStatementBytes []byte, expectedMrEnclave string, policy *VerificationPolicy) error {
+	report, err := v.Verify(evidenceBytes)
+	if err != nil {
+		return err
+	}
+
+	if policy == nil {
+		policy = DefaultVerificationPolicy()
+	}
+	if err := policy.CheckEpidReport(report, v.now()); err != nil {
+		return err
+	}
+
+	return checkReportBinding(report.ReportBody, expectedStatementBytes, expectedMrEnclave)
+}
+
+// Verify verifies an IAS report and returns its content. The report is valid if
+//   - the IAS certificates chain up to a trusted root certificate, and
+//   - the report is signed by the IAS signing certificate.
+//
+// Note that the quote status is checked by the VerificationPolicy.
+func (v *IASVerifier) Verify(evidenceBytes []byte) (*EpidReport, error) {
+	report := &IASReport{}
+	if err := json.Unmarshal(evidenceBytes, report); err != nil {
+		return nil, errors.Wrap(err, "bad ias evidence json")
+	}
+	if len(report.Signature) == 0 {
+		return nil, errors.New("no ias signature")
+	}
+	if len(report.Certificates) == 0 {
+		return nil, errors.New("no ias certificates")
+	}
+	if len(report.Body) == 0 {
+		return nil, errors.New("no ias report")
+	}
+
+	// the certificates are url-encoded as in the X-IASReport-Signing-Certificate header
+	certificates, err := url.PathUnescape(report.Certificates)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode ias certificates")
+	}
+	certs, err := parseCertChain(certificates)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ias certificates")
+	}
+	// the signing certificate followed by the root certificate
+	if len(certs) != 2 {
+		return nil, fmt.Errorf("unexpected number of IAS certificates: %d", len(certs))
+	}
+
+	for _, cert := range certs {
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:       v.roots,
+			CurrentTime: v.now(),
+			KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid certificate '%s'", cert.Subject.CommonName)
+		}
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(report.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode report signature")
+	}
+	if err := certs[0].CheckSignature(x509.SHA256WithRSA, []byte(report.Body), signature); err != nil {
+		return nil, errors.Wrap(err, "invalid report signature")
+	}
+
+	return parseEpidReport(report.Body)
+}
+
+// ParseEpidReport returns the content of an IAS report WITHOUT verifying it. It must only be used for evidence which
+// has been verified otherwise, e.g., by the C verify_evidence.
+func ParseEpidReport(evidenceBytes []byte) (*EpidReport, error) {
+	report := &IASReport{}
+	if err := json.Unmarshal(evidenceBytes, report); err != nil {
+		return nil, errors.Wrap(err, "bad ias evidence json")
+	}
+
+	return parseEpidReport(report.Body)
+}
+
+func parseEpidReport(body string) (*EpidReport, error) {
+	response := &IASResponseBody{}
+	if err := json.Unmarshal([]byte(body), response); err != nil {
+		return nil, errors.Wrap(err, "bad ias json report")
+	}
+
+	if len(response.IsvEnclaveQuoteBody) == 0 {
+		return nil, errors.New("no isvEnclaveQuoteBody")
+	}
+	quoteBody, err := base64.StdEncoding.DecodeString(response.IsvEnclaveQuoteBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode isvEnclaveQuoteBody")
+	}
+	// the quote body is a quote without signature
+	if len(quoteBody) != quoteSignedLen {
+		return nil, fmt.Errorf("unexpected quote size %d", len(quoteBody))
+	}
+
+	return &EpidReport{
+		Response:   response,
+		ReportBody: parseSgxReportBody(quoteBody[quoteHeaderLen:]),
+	}, nil
+}
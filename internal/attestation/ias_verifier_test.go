@@ -0,0 +1,225 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	iasFixturesPath = "testdata/ias"
+	iasRootCAFile   = "root_ca.pem"
+
+	// statement and wrong code id as used by the verify_evidence_app test of the C attestation api
+	iasTestStatement      = "1234567890"
+	iasTestWrongMrEnclave = "BADBADBADBAD9E317C4F7312A0D644FFC052F7645350564D43586D8102663358"
+)
+
+func iasTestMrEnclave() string {
+	h := sha256.Sum256([]byte("test ias enclave"))
+	return strings.ToUpper(hex.EncodeToString(h[:]))
+}
+
+func readIASFixture(t *testing.T, quoteStatus string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join(iasFixturesPath, "evidence_"+strings.ToLower(quoteStatus)+".json"))
+	require.NoError(t, err)
+	return data
+}
+
+func newTestIASVerifier(t *testing.T) *IASVerifier {
+	roots, err := LoadIASRootCertificates(filepath.Join(iasFixturesPath, iasRootCAFile))
+	require.NoError(t, err)
+	return NewIASVerifier(roots)
+}
+
+func TestLoadIASRootCertificates(t *testing.T) {
+	roots, err := LoadIASRootCertificates(filepath.Join(iasFixturesPath, iasRootCAFile))
+	assert.NoError(t, err)
+	assert.NotNil(t, roots)
+
+	roots, err = LoadIASRootCertificates(filepath.Join(iasFixturesPath, "nonexisting.pem"))
+	assert.Error(t, err)
+	assert.Nil(t, roots)
+
+	roots, err = LoadIASRootCertificates(filepath.Join(iasFixturesPath, "evidence_ok.json"))
+	assert.Contains(t, err.Error(), "no certificates found")
+	assert.Nil(t, roots)
+}
+
+func TestIASVerifier(t *testing.T) {
+	verifier := newTestIASVerifier(t)
+	evidence := readIASFixture(t, IASQuoteStatusOK)
+
+	report, err := verifier.Verify(evidence)
+	assert.NoError(t, err)
+	assert.Equal(t, IASQuoteStatusOK, report.Response.IsvEnclaveQuoteStatus)
+	assert.Equal(t, iasTestMrEnclave(), strings.ToUpper(hex.EncodeToString(report.ReportBody.MrEnclave)))
+
+	// the test cases of verify_evidence_app
+	assert.NoError(t, verifier.VerifyEvidence(evidence, []byte(iasTestStatement), iasTestMrEnclave(), nil))
+	assert.EqualError(t, verifier.VerifyEvidence(evidence, []byte("wrong statement"), iasTestMrEnclave(), nil), "expected statement mismatch")
+	assert.Contains(t, verifier.VerifyEvidence(evidence, []byte(iasTestStatement), iasTestWrongMrEnclave, nil).Error(), "expected mrenclave mismatch")
+
+	// group out of date is accepted as by verify_evidence
+	assert.NoError(t, verifier.VerifyEvidence(readIASFixture(t, IASQuoteStatusGroupOutOfDate), []byte(iasTestStatement), iasTestMrEnclave(), nil))
+	assert.EqualError(t, verifier.VerifyEvidence(readIASFixture(t, IASQuoteStatusConfigurationNeeded), []byte(iasTestStatement), iasTestMrEnclave(), nil),
+		"invalid quote status 'CONFIGURATION_NEEDED'")
+
+	// the fixtures are not issued by Intel
+	err = NewIASVerifier(x509.NewCertPool()).VerifyEvidence(evidence, []byte(iasTestStatement), iasTestMrEnclave(), nil)
+	assert.Contains(t, err.Error(), "invalid certificate")
+
+	// the certificates have expired
+	expired := NewIASVerifier(verifier.roots, WithIASCurrentTime(func() time.Time { return time.Now().AddDate(100, 0, 0) }))
+	_, err = expired.Verify(evidence)
+	assert.Contains(t, err.Error(), "invalid certificate")
+	_, err = verifier.At(time.Now().AddDate(100, 0, 0)).Verify(evidence)
+	assert.Contains(t, err.Error(), "invalid certificate")
+	_, err = expired.At(time.Now()).Verify(evidence)
+	assert.NoError(t, err)
+}
+
+func TestIASVerifierTampered(t *testing.T) {
+	verifier := newTestIASVerifier(t)
+
+	tamper := func(f func(report *IASReport)) []byte {
+		report := &IASReport{}
+		require.NoError(t, json.Unmarshal(readIASFixture(t, IASQuoteStatusOK), report))
+		f(report)
+		evidence, err := json.Marshal(report)
+		require.NoError(t, err)
+		return evidence
+	}
+
+	for name, testCase := range map[string]struct {
+		evidence    []byte
+		expectedErr string
+	}{
+		"invalid json": {[]byte("{"), "bad ias evidence json"},
+		"no signature": {tamper(func(report *IASReport) {
+			report.Signature = ""
+		}), "no ias signature"},
+		"no certificates": {tamper(func(report *IASReport) {
+			report.Certificates = ""
+		}), "no ias certificates"},
+		"no report": {tamper(func(report *IASReport) {
+			report.Body = ""
+		}), "no ias report"},
+		"missing root certificate": {tamper(func(report *IASReport) {
+			certs, _ := url.PathUnescape(report.Certificates)
+			report.Certificates = certs[:strings.Index(certs, "-----END CERTIFICATE-----")+len("-----END CERTIFICATE-----\n")]
+		}), "unexpected number of IAS certificates: 1"},
+		"signature": {tamper(func(report *IASReport) {
+			report.Signature = base64.StdEncoding.EncodeToString([]byte("some signature"))
+		}), "invalid report signature"},
+		"report": {tamper(func(report *IASReport) {
+			report.Body = strings.Replace(report.Body, `"OK"`, `"GROUP_OUT_OF_DATE"`, 1)
+		}), "invalid report signature"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := verifier.Verify(testCase.evidence)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), testCase.expectedErr)
+			}
+		})
+	}
+}
+
+// TestGenerateIASFixtures records IAS reports issued by a test PKI in testdata/ias
+func TestGenerateIASFixtures(t *testing.T) {
+	if !*updateFixtures {
+		t.Skip("fixtures are only regenerated with -update-fixtures")
+	}
+
+	newCert := func(cn string, isCA bool, key *rsa.PrivateKey, issuer *x509.Certificate, issuerKey *rsa.PrivateKey) (*x509.Certificate, string) {
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(time.Now().UnixNano()),
+			Subject:               pkix.Name{CommonName: cn, Organization: []string{"FPC Test"}},
+			NotBefore:             time.Now().AddDate(-1, 0, 0),
+			NotAfter:              time.Now().AddDate(30, 0, 0),
+			KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+			BasicConstraintsValid: true,
+			IsCA:                  isCA,
+		}
+		if isCA {
+			template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		}
+		if issuer == nil {
+			issuer, issuerKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), issuerKey)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+
+	rootKey, err := rsa.GenerateKey(rand.Reader, 3072)
+	require.NoError(t, err)
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rootCert, rootPem := newCert("Test SGX Attestation Report Signing CA", true, rootKey, nil, nil)
+	_, signingPem := newCert("Test SGX Attestation Report Signing", false, signingKey, rootCert, rootKey)
+
+	// quote body of a release enclave with the test statement
+	quoteBody := make([]byte, quoteSignedLen)
+	binary.LittleEndian.PutUint16(quoteBody[0:], 2)
+	binary.LittleEndian.PutUint16(quoteBody[2:], 1)
+	reportBody := quoteBody[quoteHeaderLen:]
+	reportBody[reportAttributesOffset] = 0x05
+	mrenclave, err := hex.DecodeString(iasTestMrEnclave())
+	require.NoError(t, err)
+	copy(reportBody[reportMrEnclaveOffset:], mrenclave)
+	statementHash := sha256.Sum256([]byte(iasTestStatement))
+	copy(reportBody[reportDataOffset:], statementHash[:])
+
+	for _, status := range []string{IASQuoteStatusOK, IASQuoteStatusGroupOutOfDate, IASQuoteStatusConfigurationNeeded} {
+		body, err := json.Marshal(map[string]interface{}{
+			"id":                    "100342731086430570647295023189732744265",
+			"timestamp":             time.Now().UTC().Format("2006-01-02T15:04:05.000000"),
+			"version":               4,
+			"isvEnclaveQuoteStatus": status,
+			"isvEnclaveQuoteBody":   base64.StdEncoding.EncodeToString(quoteBody),
+		})
+		require.NoError(t, err)
+
+		hash := sha256.Sum256(body)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, signingKey, crypto.SHA256, hash[:])
+		require.NoError(t, err)
+
+		evidence, err := json.Marshal(&IASReport{
+			Signature:    base64.StdEncoding.EncodeToString(signature),
+			Certificates: url.PathEscape(signingPem + rootPem),
+			Body:         string(body),
+		})
+		require.NoError(t, err)
+
+		name := "evidence_" + strings.ToLower(status) + ".json"
+		require.NoError(t, ioutil.WriteFile(filepath.Join(iasFixturesPath, name), evidence, 0644))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(iasFixturesPath, iasRootCAFile), []byte(rootPem), 0644))
+}
@@ -0,0 +1,266 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package iastest provides an in-process stand-in for the Intel Attestation Service (IAS), which allows to run the
+// conversion and verification of EPID attestations offline, e.g., in integration tests.
+// The reports are signed by a test CA; hence, they are only accepted by verifiers trusting the RootCertificates of the
+// Server.
+package iastest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/pkg/errors"
+)
+
+// ReportPath is the path of the report endpoint of the Server, as in the IAS API
+const ReportPath = "/sgx/dev/attestation/v4/report"
+
+// the size of the quote included in the report (a quote without signature)
+const quoteBodyLen = 432
+
+// Server is an IAS stand-in which serves attestation reports signed by a test CA
+type Server struct {
+	*httptest.Server
+
+	apiKey      string
+	rootPem     string
+	signingPem  string
+	signingKey  *rsa.PrivateKey
+	roots       *x509.CertPool
+	mutex       sync.Mutex
+	quoteStatus string
+	failures    []failure
+	requests    int
+}
+
+type failure struct {
+	statusCode int
+	retryAfter int
+}
+
+type ServerOption func(*Server)
+
+// WithAPIKey option makes the Server reject requests without the given API key
+func WithAPIKey(apiKey string) ServerOption {
+	return func(s *Server) {
+		s.apiKey = apiKey
+	}
+}
+
+// WithQuoteStatus option sets the isvEnclaveQuoteStatus of the reports (default OK)
+func WithQuoteStatus(status string) ServerOption {
+	return func(s *Server) {
+		s.quoteStatus = status
+	}
+}
+
+// NewServer starts a new Server with a freshly generated test CA. The caller should call Close when finished.
+// Optionally, ServerOption can be provided to change the behavior of the Server.
+func NewServer(opts ...ServerOption) (*Server, error) {
+	s := &Server{
+		quoteStatus: attestation.IASQuoteStatusOK,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := s.createTestCA(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(ReportPath, s.handleReport)
+	s.Server = httptest.NewServer(mux)
+
+	return s, nil
+}
+
+// ReportURL returns the url of the report endpoint, to be used with attestation.WithUrl or IAS_URL
+func (s *Server) ReportURL() string {
+	return s.URL + ReportPath
+}
+
+// RootCertificates returns the test CA certificate which signs the reports
+func (s *Server) RootCertificates() *x509.CertPool {
+	return s.roots
+}
+
+// RootCertificatePem returns the PEM encoded test CA certificate, e.g., to be used with IAS_ROOT_CA_PATH
+func (s *Server) RootCertificatePem() []byte {
+	return []byte(s.rootPem)
+}
+
+// SetQuoteStatus sets the isvEnclaveQuoteStatus of subsequent reports
+func (s *Server) SetQuoteStatus(status string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.quoteStatus = status
+}
+
+// FailNext makes the next n requests fail with the given http status code. If retryAfter is greater than 0,
+// a Retry-After header (in seconds) is sent.
+func (s *Server) FailNext(n int, statusCode int, retryAfter int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for j := 0; j < n; j++ {
+		s.failures = append(s.failures, failure{statusCode: statusCode, retryAfter: retryAfter})
+	}
+}
+
+// Requests returns the number of report requests received
+func (s *Server) Requests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests++
+	requestId := strconv.Itoa(s.requests)
+	quoteStatus := s.quoteStatus
+	var fail *failure
+	if len(s.failures) > 0 {
+		fail = &s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mutex.Unlock()
+
+	w.Header().Set("Request-ID", requestId)
+
+	if fail != nil {
+		if fail.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(fail.retryAfter))
+		}
+		w.WriteHeader(fail.statusCode)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if len(s.apiKey) != 0 && r.Header.Get("Ocp-Apim-Subscription-Key") != s.apiKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	request := &attestation.IASRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	quote, err := base64.StdEncoding.DecodeString(request.Quote)
+	if err != nil || len(quote) < quoteBodyLen {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body, signature, err := s.signReport(&attestation.IASResponseBody{
+		Id:                    requestId,
+		Timestamp:             time.Now().UTC().Format("2006-01-02T15:04:05.000000"),
+		Version:               4,
+		IsvEnclaveQuoteStatus: quoteStatus,
+		IsvEnclaveQuoteBody:   base64.StdEncoding.EncodeToString(quote[:quoteBodyLen]),
+		Nonce:                 request.Nonce,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-IASReport-Signature", base64.StdEncoding.EncodeToString(signature))
+	w.Header().Set("X-IASReport-Signing-Certificate", url.PathEscape(s.signingPem+s.rootPem))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+func (s *Server) signReport(response *attestation.IASResponseBody) ([]byte, []byte, error) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hash := sha256.Sum256(body)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.signingKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, signature, nil
+}
+
+// createTestCA creates a root CA and a report signing certificate issued by it
+func (s *Server) createTestCA() error {
+	rootKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return errors.Wrap(err, "cannot generate root key")
+	}
+	s.signingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return errors.Wrap(err, "cannot generate signing key")
+	}
+
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test SGX Attestation Report Signing CA", Organization: []string{"FPC Test"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDer, err := x509.CreateCertificate(rand.Reader, root, root, rootKey.Public(), rootKey)
+	if err != nil {
+		return errors.Wrap(err, "cannot create root certificate")
+	}
+	rootCert, err := x509.ParseCertificate(rootDer)
+	if err != nil {
+		return errors.Wrap(err, "cannot parse root certificate")
+	}
+
+	signing := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test SGX Attestation Report Signing", Organization: []string{"FPC Test"}},
+		NotBefore:             root.NotBefore,
+		NotAfter:              root.NotAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		BasicConstraintsValid: true,
+	}
+	signingDer, err := x509.CreateCertificate(rand.Reader, signing, rootCert, s.signingKey.Public(), rootKey)
+	if err != nil {
+		return errors.Wrap(err, "cannot create signing certificate")
+	}
+
+	s.rootPem = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDer}))
+	s.signingPem = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signingDer}))
+	s.roots = x509.NewCertPool()
+	s.roots.AddCert(rootCert)
+
+	return nil
+}
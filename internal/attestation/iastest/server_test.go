@@ -0,0 +1,133 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package iastest
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStatement = "some statement"
+
+// testQuote returns an EPID quote of a release enclave with the given mrenclave binding the test statement
+func testQuote(mrenclave []byte) string {
+	// quote header (48 bytes), report body (384 bytes), signature length and a dummy signature
+	quote := make([]byte, quoteBodyLen+4+64)
+	reportBody := quote[48:]
+	reportBody[48] = 0x05 // attributes: initialized, 64 bit mode
+	copy(reportBody[64:], mrenclave)
+	statementHash := sha256.Sum256([]byte(testStatement))
+	copy(reportBody[320:], statementHash[:])
+	quote[quoteBodyLen] = 64
+	return base64.StdEncoding.EncodeToString(quote)
+}
+
+func TestServer(t *testing.T) {
+	server, err := NewServer(WithAPIKey("some_key"))
+	require.NoError(t, err)
+	defer server.Close()
+
+	mrenclave := sha256.Sum256([]byte("some enclave"))
+	quote := testQuote(mrenclave[:])
+
+	// convert and verify an attestation offline
+	ias := attestation.NewIASClient("some_key", attestation.WithUrl(server.ReportURL()))
+	converter := attestation.NewEpidLinkableConverter(attestation.WithIntelAttestationService(ias))
+	evidence, err := converter.Converter([]byte(quote))
+	require.NoError(t, err)
+
+	verifier := attestation.NewIASVerifier(server.RootCertificates())
+	assert.NoError(t, verifier.VerifyEvidence(evidence, []byte(testStatement), hex.EncodeToString(mrenclave[:]), nil))
+	assert.Error(t, verifier.VerifyEvidence(evidence, []byte("wrong statement"), hex.EncodeToString(mrenclave[:]), nil))
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(server.RootCertificatePem()))
+	assert.NoError(t, attestation.NewIASVerifier(roots).VerifyEvidence(evidence, []byte(testStatement), hex.EncodeToString(mrenclave[:]), nil))
+
+	// reports of another stand-in are not accepted
+	other, err := NewServer()
+	require.NoError(t, err)
+	defer other.Close()
+	assert.Contains(t, attestation.NewIASVerifier(other.RootCertificates()).VerifyEvidence(evidence, []byte(testStatement), hex.EncodeToString(mrenclave[:]), nil).Error(),
+		"invalid certificate")
+
+	// quote status
+	server.SetQuoteStatus(attestation.IASQuoteStatusConfigurationNeeded)
+	evidence, err = converter.Converter([]byte(quote))
+	require.NoError(t, err)
+	assert.EqualError(t, verifier.VerifyEvidence(evidence, []byte(testStatement), hex.EncodeToString(mrenclave[:]), nil),
+		"invalid quote status 'CONFIGURATION_NEEDED'")
+
+	// wrong api key
+	_, err = attestation.NewIASClient("wrong_key", attestation.WithUrl(server.ReportURL())).RequestAttestationReport(quote)
+	assert.Contains(t, err.Error(), "request failed! Reason: 401")
+
+	// bad quote
+	_, err = ias.RequestAttestationReport("bad quote")
+	assert.Contains(t, err.Error(), "request failed! Reason: 400")
+}
+
+func TestServerFailures(t *testing.T) {
+	server, err := NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	mrenclave := sha256.Sum256([]byte("some enclave"))
+	quote := testQuote(mrenclave[:])
+
+	ias := attestation.NewIASClient("some_key", attestation.WithUrl(server.ReportURL()),
+		attestation.WithRetry(2, time.Millisecond, time.Millisecond))
+
+	server.FailNext(1, http.StatusServiceUnavailable, 0)
+	server.FailNext(1, http.StatusTooManyRequests, 0)
+	_, err = ias.RequestAttestationReport(quote)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, server.Requests())
+
+	server.FailNext(3, http.StatusInternalServerError, 0)
+	_, err = ias.RequestAttestationReport(quote)
+	assert.Contains(t, err.Error(), "giving up after 3 attempts")
+	assert.Equal(t, 6, server.Requests())
+}
+
+func TestServerCredentialProvider(t *testing.T) {
+	server, err := NewServer(WithAPIKey("some_key"))
+	require.NoError(t, err)
+	defer server.Close()
+
+	oldUrl := os.Getenv("IAS_URL")
+	defer os.Setenv("IAS_URL", oldUrl)
+	os.Setenv("IAS_URL", server.ReportURL())
+
+	mrenclave := sha256.Sum256([]byte("some enclave"))
+	quote := testQuote(mrenclave[:])
+
+	apiKey := attestation.Secret("some_key")
+	converter := attestation.NewEpidLinkableConverter(attestation.WithCredentialProvider(&attestation.CallbackCredentialProvider{
+		APIKeyFunc: func(service string) (attestation.Secret, error) {
+			return apiKey, nil
+		},
+	}))
+	evidence, err := converter.Converter([]byte(quote))
+	require.NoError(t, err)
+	assert.NoError(t, attestation.NewIASVerifier(server.RootCertificates()).VerifyEvidence(evidence, []byte(testStatement), hex.EncodeToString(mrenclave[:]), nil))
+
+	// the api key is requested for every conversion, e.g., to pick up a rotated key
+	apiKey = "rotated_key"
+	_, err = converter.Converter([]byte(quote))
+	assert.Contains(t, err.Error(), "request failed! Reason: 401")
+}
@@ -0,0 +1,223 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultPCCSUrl is the base url of the Intel Provisioning Certification Service (PCS)
+const DefaultPCCSUrl = "https://api.trustedservices.intel.com/sgx/certification/v4"
+
+// loadPCCSUrl returns the PCCS url set by the PCCS_URL environment variable; if not set, DefaultPCCSUrl is returned
+func loadPCCSUrl() string {
+	if url := os.Getenv("PCCS_URL"); len(url) != 0 {
+		return url
+	}
+	return DefaultPCCSUrl
+}
+
+// file names of the collateral read by the LocalCollateralProvider
+const (
+	TcbInfoFile               = "tcb_info.json"
+	TcbInfoIssuerChainFile    = "tcb_info_issuer_chain.pem"
+	QeIdentityFile            = "qe_identity.json"
+	QeIdentityIssuerChainFile = "qe_identity_issuer_chain.pem"
+	PckCrlFile                = "pck_crl.pem"
+	PckCrlIssuerChainFile     = "pck_crl_issuer_chain.pem"
+)
+
+// PCK CAs issuing PCK certificates, used to select the PCK CRL
+const (
+	PckProcessorCA = "processor"
+	PckPlatformCA  = "platform"
+)
+
+// DcapCollateral contains the collateral needed to verify an Intel SGX ECDSA quote.
+// TcbInfo and QeIdentity are the signed json documents as served by the PCS; the PCK CRL and the issuer chains are
+// PEM encoded.
+type DcapCollateral struct {
+	PckCertChain          string `json:"pckCertChain"`
+	PckCrl                string `json:"pckCrl"`
+	PckCrlIssuerChain     string `json:"pckCrlIssuerChain"`
+	TcbInfo               string `json:"tcbInfo"`
+	TcbInfoIssuerChain    string `json:"tcbInfoIssuerChain"`
+	QeIdentity            string `json:"qeIdentity"`
+	QeIdentityIssuerChain string `json:"qeIdentityIssuerChain"`
+}
+
+// CollateralProvider returns the TCB info of a platform (identified by its FMSPC), the QE identity, and the CRL of the
+// PCK CA (PckProcessorCA or PckPlatformCA) which issued the PCK certificate of the platform.
+// The PCK certificate chain is not provided, as it is part of the quote.
+type CollateralProvider interface {
+	GetCollateral(fmspc, ca string) (*DcapCollateral, error)
+}
+
+// PCCSClient fetches collateral from the Intel PCS or from a Provisioning Certificate Caching Service (PCCS)
+// which implements the same API.
+type PCCSClient struct {
+	url         string
+	httpClient  HTTPClient
+	credentials CredentialProvider
+}
+
+type PCCSClientOption func(*PCCSClient)
+
+// WithPCCSUrl option allows to override the default PCCS endpoint (DefaultPCCSUrl)
+func WithPCCSUrl(url string) PCCSClientOption {
+	return func(c *PCCSClient) {
+		c.url = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithPCCSHttpClient option allows to use a custom http client. Mainly used for testing
+func WithPCCSHttpClient(client HTTPClient) PCCSClientOption {
+	return func(c *PCCSClient) {
+		c.httpClient = client
+	}
+}
+
+// WithPCCSCredentialProvider option sets the provider of the (optional) PCCS API key, which is sent as
+// Ocp-Apim-Subscription-Key header if found
+func WithPCCSCredentialProvider(credentials CredentialProvider) PCCSClientOption {
+	return func(c *PCCSClient) {
+		c.credentials = credentials
+	}
+}
+
+// NewPCCSClient returns a new PCCSClient instance using DefaultPCCSUrl as endpoint.
+// Optionally, PCCSClientOption can be provided to change the behavior of the PCCSClient.
+func NewPCCSClient(opts ...PCCSClientOption) *PCCSClient {
+	client := &PCCSClient{
+		url: DefaultPCCSUrl,
+	}
+
+	// apply options
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	// create default http client if not provided via options
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{}
+	}
+
+	return client
+}
+
+// GetCollateral fetches the TCB info for the given FMSPC, the QE identity, and the PCK CRL of the given PCK CA
+func (c *PCCSClient) GetCollateral(fmspc, ca string) (*DcapCollateral, error) {
+	tcbInfo, tcbInfoIssuerChain, err := c.get("/tcb?fmspc="+url.QueryEscape(fmspc), "TCB-Info-Issuer-Chain")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get TCB info")
+	}
+
+	qeIdentity, qeIdentityIssuerChain, err := c.get("/qe/identity", "SGX-Enclave-Identity-Issuer-Chain")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get QE identity")
+	}
+
+	pckCrl, pckCrlIssuerChain, err := c.get("/pckcrl?ca="+url.QueryEscape(ca)+"&encoding=pem", "SGX-PCK-CRL-Issuer-Chain")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get PCK CRL")
+	}
+
+	return &DcapCollateral{
+		PckCrl:                pckCrl,
+		PckCrlIssuerChain:     pckCrlIssuerChain,
+		TcbInfo:               tcbInfo,
+		TcbInfoIssuerChain:    tcbInfoIssuerChain,
+		QeIdentity:            qeIdentity,
+		QeIdentityIssuerChain: qeIdentityIssuerChain,
+	}, nil
+}
+
+// get returns the body and the (url-encoded) issuer chain header of a PCCS response
+func (c *PCCSClient) get(path, issuerChainHeader string) (body string, issuerChain string, err error) {
+	req, err := http.NewRequest("GET", c.url+path, nil)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot create http request")
+	}
+
+	if c.credentials != nil {
+		apiKey, err := c.credentials.GetAPIKey(PCCSService)
+		if err == nil {
+			req.Header.Add("Ocp-Apim-Subscription-Key", string(apiKey))
+		} else if errors.Cause(err) != ErrCredentialNotFound {
+			return "", "", errors.Wrap(err, "cannot load PCCS API key")
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot perform http request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", "", fmt.Errorf("request failed! Reason: %d %s. Request ID: %s", resp.StatusCode, resp.Status, resp.Header.Get("Request-ID"))
+	}
+
+	issuerChain, err = url.QueryUnescape(resp.Header.Get(issuerChainHeader))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "invalid %s header", issuerChainHeader)
+	}
+	if issuerChain == "" {
+		return "", "", fmt.Errorf("no %s header", issuerChainHeader)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot read response body")
+	}
+
+	return string(data), issuerChain, nil
+}
+
+// LocalCollateralProvider reads the collateral from a local directory, e.g., for platforms without access to a PCCS
+// or for testing. The directory contains the files TcbInfoFile, TcbInfoIssuerChainFile, QeIdentityFile,
+// QeIdentityIssuerChainFile, PckCrlFile, and PckCrlIssuerChainFile as downloaded from the PCS.
+type LocalCollateralProvider struct {
+	path string
+}
+
+// NewLocalCollateralProvider returns a new LocalCollateralProvider reading the collateral from path
+func NewLocalCollateralProvider(path string) *LocalCollateralProvider {
+	return &LocalCollateralProvider{path: path}
+}
+
+// GetCollateral reads the collateral from the local directory.
+// The TCB info and the PCK CRL are returned regardless of the FMSPC and the PCK CA; they are checked against the PCK
+// certificate during verification.
+func (p *LocalCollateralProvider) GetCollateral(fmspc, ca string) (*DcapCollateral, error) {
+	collateral := &DcapCollateral{}
+	for name, field := range map[string]*string{
+		TcbInfoFile:               &collateral.TcbInfo,
+		TcbInfoIssuerChainFile:    &collateral.TcbInfoIssuerChain,
+		QeIdentityFile:            &collateral.QeIdentity,
+		QeIdentityIssuerChainFile: &collateral.QeIdentityIssuerChain,
+		PckCrlFile:                &collateral.PckCrl,
+		PckCrlIssuerChainFile:     &collateral.PckCrlIssuerChain,
+	} {
+		path := filepath.Join(p.path, name)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read %s", path)
+		}
+		*field = string(data)
+	}
+
+	return collateral, nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/fakes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPCCSClient(t *testing.T) {
+	dummyPCCSUrl := "https://pccs.example.com/sgx/certification/v4/"
+	issuerChain := "-----BEGIN CERTIFICATE-----\nsigning+cert\n-----END CERTIFICATE-----\n"
+
+	response := func(header, body string) *http.Response {
+		h := http.Header{}
+		h.Add(header, url.QueryEscape(issuerChain))
+		return &http.Response{
+			StatusCode: 200,
+			Header:     h,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		}
+	}
+
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoReturnsOnCall(0, response("TCB-Info-Issuer-Chain", "tcb info"), nil)
+	fakeHttpClient.DoReturnsOnCall(1, response("SGX-Enclave-Identity-Issuer-Chain", "qe identity"), nil)
+	fakeHttpClient.DoReturnsOnCall(2, response("SGX-PCK-CRL-Issuer-Chain", "pck crl"), nil)
+
+	client := NewPCCSClient(WithPCCSUrl(dummyPCCSUrl), WithPCCSHttpClient(fakeHttpClient))
+	collateral, err := client.GetCollateral(dcapTestFmspc, PckPlatformCA)
+	assert.NoError(t, err)
+	assert.Equal(t, &DcapCollateral{
+		PckCrl:                "pck crl",
+		PckCrlIssuerChain:     issuerChain,
+		TcbInfo:               "tcb info",
+		TcbInfoIssuerChain:    issuerChain,
+		QeIdentity:            "qe identity",
+		QeIdentityIssuerChain: issuerChain,
+	}, collateral)
+
+	tcbReq := fakeHttpClient.DoArgsForCall(0)
+	assert.Equal(t, "GET", tcbReq.Method)
+	assert.Equal(t, "https://pccs.example.com/sgx/certification/v4/tcb?fmspc="+dcapTestFmspc, tcbReq.URL.String())
+	qeReq := fakeHttpClient.DoArgsForCall(1)
+	assert.Equal(t, "https://pccs.example.com/sgx/certification/v4/qe/identity", qeReq.URL.String())
+	crlReq := fakeHttpClient.DoArgsForCall(2)
+	assert.Equal(t, "https://pccs.example.com/sgx/certification/v4/pckcrl?ca=platform&encoding=pem", crlReq.URL.String())
+
+	// missing issuer chain
+	fakeHttpClient.DoReturnsOnCall(3, &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil)
+	collateral, err = client.GetCollateral(dcapTestFmspc, PckPlatformCA)
+	assert.EqualError(t, err, "cannot get TCB info: no TCB-Info-Issuer-Chain header")
+	assert.Nil(t, collateral)
+
+	// unknown fmspc
+	fakeHttpClient.DoReturnsOnCall(4, &http.Response{StatusCode: 404, Status: "Not Found", Body: ioutil.NopCloser(strings.NewReader(""))}, nil)
+	collateral, err = client.GetCollateral(dcapTestFmspc, PckPlatformCA)
+	assert.Contains(t, err.Error(), "404")
+	assert.Nil(t, collateral)
+
+	// PCK CRL not available
+	fakeHttpClient.DoReturnsOnCall(5, response("TCB-Info-Issuer-Chain", "tcb info"), nil)
+	fakeHttpClient.DoReturnsOnCall(6, response("SGX-Enclave-Identity-Issuer-Chain", "qe identity"), nil)
+	fakeHttpClient.DoReturnsOnCall(7, &http.Response{StatusCode: 503, Status: "Service Unavailable", Body: ioutil.NopCloser(strings.NewReader(""))}, nil)
+	collateral, err = client.GetCollateral(dcapTestFmspc, PckProcessorCA)
+	assert.Contains(t, err.Error(), "cannot get PCK CRL")
+	assert.Nil(t, collateral)
+	assert.Equal(t, "https://pccs.example.com/sgx/certification/v4/pckcrl?ca=processor&encoding=pem", fakeHttpClient.DoArgsForCall(7).URL.String())
+}
+
+func TestLocalCollateralProvider(t *testing.T) {
+	collateral, err := NewLocalCollateralProvider(dcapFixturesPath).GetCollateral(dcapTestFmspc, PckPlatformCA)
+	assert.NoError(t, err)
+	assert.Empty(t, collateral.PckCertChain)
+	assert.Equal(t, readDcapFixture(t, PckCrlIssuerChainFile), collateral.PckCrlIssuerChain)
+	assert.Equal(t, readDcapFixture(t, TcbInfoIssuerChainFile), collateral.TcbInfoIssuerChain)
+	assert.Equal(t, readDcapFixture(t, QeIdentityFile), collateral.QeIdentity)
+
+	collateral, err = NewLocalCollateralProvider("testdata/nonexisting").GetCollateral(dcapTestFmspc, PckPlatformCA)
+	assert.Error(t, err)
+	assert.Nil(t, collateral)
+}
+
+func TestLoadPCCSUrl(t *testing.T) {
+	defer os.Setenv("PCCS_URL", os.Getenv("PCCS_URL"))
+
+	os.Setenv("PCCS_URL", "")
+	assert.Equal(t, DefaultPCCSUrl, loadPCCSUrl())
+
+	os.Setenv("PCCS_URL", "https://localhost:8081/sgx/certification/v4")
+	assert.Equal(t, "https://localhost:8081/sgx/certification/v4", loadPCCSUrl())
+}
+
+func TestPCCSClientApiKey(t *testing.T) {
+	fakeHttpClient := &fakes.HTTPClient{}
+	fakeHttpClient.DoReturns(&http.Response{StatusCode: 404, Status: "Not Found", Body: ioutil.NopCloser(strings.NewReader(""))}, nil)
+
+	// the api key is optional
+	client := NewPCCSClient(WithPCCSHttpClient(fakeHttpClient), WithPCCSCredentialProvider(&CallbackCredentialProvider{}))
+	_, err := client.GetCollateral(dcapTestFmspc, PckPlatformCA)
+	assert.Contains(t, err.Error(), "404")
+	assert.Empty(t, fakeHttpClient.DoArgsForCall(0).Header.Get("Ocp-Apim-Subscription-Key"))
+
+	client = NewPCCSClient(WithPCCSHttpClient(fakeHttpClient), WithPCCSCredentialProvider(&CallbackCredentialProvider{
+		APIKeyFunc: func(service string) (Secret, error) {
+			assert.Equal(t, PCCSService, service)
+			return "some_key", nil
+		},
+	}))
+	_, err = client.GetCollateral(dcapTestFmspc, PckPlatformCA)
+	assert.Contains(t, err.Error(), "404")
+	assert.Equal(t, "some_key", fakeHttpClient.DoArgsForCall(1).Header.Get("Ocp-Apim-Subscription-Key"))
+
+	// other errors are returned
+	client = NewPCCSClient(WithPCCSHttpClient(fakeHttpClient), WithPCCSCredentialProvider(&CallbackCredentialProvider{
+		APIKeyFunc: func(service string) (Secret, error) {
+			return "", errors.New("secrets manager unavailable")
+		},
+	}))
+	_, err = client.GetCollateral(dcapTestFmspc, PckPlatformCA)
+	assert.EqualError(t, err, "cannot get TCB info: cannot load PCCS API key: secrets manager unavailable")
+	assert.Equal(t, 2, fakeHttpClient.DoCallCount())
+}
@@ -0,0 +1,169 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// values of the isvEnclaveQuoteStatus of an IAS report
+const (
+	IASQuoteStatusOK                                = "OK"
+	IASQuoteStatusGroupOutOfDate                    = "GROUP_OUT_OF_DATE"
+	IASQuoteStatusConfigurationNeeded               = "CONFIGURATION_NEEDED"
+	IASQuoteStatusSwHardeningNeeded                 = "SW_HARDENING_NEEDED"
+	IASQuoteStatusConfigurationAndSwHardeningNeeded = "CONFIGURATION_AND_SW_HARDENING_NEEDED"
+)
+
+// values of the tcbStatus of the TCB info and QE identity of DCAP collateral
+const (
+	TcbStatusUpToDate                          = "UpToDate"
+	TcbStatusSWHardeningNeeded                 = "SWHardeningNeeded"
+	TcbStatusConfigurationNeeded               = "ConfigurationNeeded"
+	TcbStatusConfigurationAndSWHardeningNeeded = "ConfigurationAndSWHardeningNeeded"
+	TcbStatusOutOfDate                         = "OutOfDate"
+	TcbStatusOutOfDateConfigurationNeeded      = "OutOfDateConfigurationNeeded"
+	TcbStatusRevoked                           = "Revoked"
+)
+
+// iasReportTimestampLayout is the format of the timestamp of an IAS report (UTC)
+const iasReportTimestampLayout = "2006-01-02T15:04:05.999999"
+
+// VerificationPolicy defines which attestation evidence is accepted beyond the checks of its signatures and the
+// binding to the expected statement and mrenclave.
+type VerificationPolicy struct {
+	// AllowedQuoteStatuses are the accepted isvEnclaveQuoteStatus values of IAS reports (EPID)
+	AllowedQuoteStatuses []string `json:"allowed_quote_statuses"`
+	// AllowedTcbStatuses are the accepted TCB statuses of the platform and the quoting enclave (DCAP)
+	AllowedTcbStatuses []string `json:"allowed_tcb_statuses"`
+	// AllowDebugEnclaves accepts enclaves running in debug mode
+	AllowDebugEnclaves bool `json:"allow_debug_enclaves"`
+	// MinIsvSvn is the minimum security version of the enclave
+	MinIsvSvn uint16 `json:"min_isv_svn"`
+	// MaxReportAgeSeconds is the maximum age of IAS reports; 0 means no limit. Note that DCAP quotes are not timestamped.
+	MaxReportAgeSeconds uint64 `json:"max_report_age_seconds"`
+}
+
+// DefaultVerificationPolicy returns the policy applied if no other policy is configured. It accepts IAS reports with
+// status OK or GROUP_OUT_OF_DATE, as the C verify_evidence, and DCAP quotes of platforms which are not revoked.
+// Unlike the C verify_evidence, it rejects debug enclaves, whose memory can be inspected by the host; these must be
+// allowed explicitly, e.g., for development in SGX hardware mode.
+func DefaultVerificationPolicy() *VerificationPolicy {
+	return &VerificationPolicy{
+		AllowedQuoteStatuses: []string{IASQuoteStatusOK, IASQuoteStatusGroupOutOfDate},
+		AllowedTcbStatuses: []string{
+			TcbStatusUpToDate,
+			TcbStatusSWHardeningNeeded,
+			TcbStatusConfigurationNeeded,
+			TcbStatusConfigurationAndSWHardeningNeeded,
+			TcbStatusOutOfDate,
+			TcbStatusOutOfDateConfigurationNeeded,
+		},
+		AllowDebugEnclaves: false,
+	}
+}
+
+// ParseVerificationPolicy parses a json encoded policy. Fields not set in the json keep the value of the
+// DefaultVerificationPolicy.
+func ParseVerificationPolicy(policyBytes []byte) (*VerificationPolicy, error) {
+	policy := DefaultVerificationPolicy()
+
+	decoder := json.NewDecoder(bytes.NewReader(policyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(policy); err != nil {
+		return nil, errors.Wrap(err, "invalid verification policy json")
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Validate checks that the policy only contains known statuses
+func (p *VerificationPolicy) Validate() error {
+	for _, status := range p.AllowedQuoteStatuses {
+		switch status {
+		case IASQuoteStatusOK, IASQuoteStatusGroupOutOfDate, IASQuoteStatusConfigurationNeeded,
+			IASQuoteStatusSwHardeningNeeded, IASQuoteStatusConfigurationAndSwHardeningNeeded:
+		default:
+			return fmt.Errorf("unknown quote status '%s'", status)
+		}
+	}
+
+	for _, status := range p.AllowedTcbStatuses {
+		switch status {
+		case TcbStatusUpToDate, TcbStatusSWHardeningNeeded, TcbStatusConfigurationNeeded,
+			TcbStatusConfigurationAndSWHardeningNeeded, TcbStatusOutOfDate, TcbStatusOutOfDateConfigurationNeeded:
+		case TcbStatusRevoked:
+			return errors.New("revoked tcb status cannot be allowed")
+		default:
+			return fmt.Errorf("unknown tcb status '%s'", status)
+		}
+	}
+
+	return nil
+}
+
+// CheckEpidReport checks that a verified IAS report complies with the policy at the given time
+func (p *VerificationPolicy) CheckEpidReport(report *EpidReport, now time.Time) error {
+	if !contains(p.AllowedQuoteStatuses, report.Response.IsvEnclaveQuoteStatus) {
+		return fmt.Errorf("invalid quote status '%s'", report.Response.IsvEnclaveQuoteStatus)
+	}
+
+	if p.MaxReportAgeSeconds > 0 {
+		timestamp, err := time.Parse(iasReportTimestampLayout, report.Response.Timestamp)
+		if err != nil {
+			return errors.Wrap(err, "invalid report timestamp")
+		}
+		maxAge := time.Duration(p.MaxReportAgeSeconds) * time.Second
+		if now.Sub(timestamp) > maxAge {
+			return fmt.Errorf("report from %s is older than %s", report.Response.Timestamp, maxAge)
+		}
+	}
+
+	return p.checkReportBody(report.ReportBody)
+}
+
+// CheckDcapReport checks that a verified DCAP quote complies with the policy
+func (p *VerificationPolicy) CheckDcapReport(report *DcapReport) error {
+	if !contains(p.AllowedTcbStatuses, report.TcbStatus) {
+		return fmt.Errorf("invalid tcb status '%s'", report.TcbStatus)
+	}
+	if !contains(p.AllowedTcbStatuses, report.QeTcbStatus) {
+		return fmt.Errorf("invalid quoting enclave tcb status '%s'", report.QeTcbStatus)
+	}
+
+	return p.checkReportBody(report.ReportBody)
+}
+
+func (p *VerificationPolicy) checkReportBody(report *SgxReportBody) error {
+	if !p.AllowDebugEnclaves && report.Debug() {
+		return errors.New("debug enclaves are not accepted")
+	}
+
+	if report.IsvSvn < p.MinIsvSvn {
+		return fmt.Errorf("isv svn %d is lower than %d", report.IsvSvn, p.MinIsvSvn)
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
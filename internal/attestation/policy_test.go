@@ -0,0 +1,112 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVerificationPolicy(t *testing.T) {
+	policy, err := ParseVerificationPolicy([]byte(`{}`))
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultVerificationPolicy(), policy)
+
+	policy, err = ParseVerificationPolicy([]byte(`{"allow_debug_enclaves": false, "min_isv_svn": 2, "allowed_quote_statuses": ["OK"]}`))
+	assert.NoError(t, err)
+	assert.False(t, policy.AllowDebugEnclaves)
+	assert.Equal(t, uint16(2), policy.MinIsvSvn)
+	assert.Equal(t, []string{IASQuoteStatusOK}, policy.AllowedQuoteStatuses)
+	assert.Equal(t, DefaultVerificationPolicy().AllowedTcbStatuses, policy.AllowedTcbStatuses)
+
+	for policyJson, expectedErr := range map[string]string{
+		`not json`:               "invalid verification policy json",
+		`{"allow_debug": false}`: "invalid verification policy json",
+		`{"allowed_quote_statuses": ["REVOKED"]}`:  "unknown quote status 'REVOKED'",
+		`{"allowed_tcb_statuses": ["Revoked"]}`:    "revoked tcb status cannot be allowed",
+		`{"allowed_tcb_statuses": ["SomeStatus"]}`: "unknown tcb status 'SomeStatus'",
+	} {
+		_, err := ParseVerificationPolicy([]byte(policyJson))
+		if assert.Error(t, err, policyJson) {
+			assert.Contains(t, err.Error(), expectedErr)
+		}
+	}
+}
+
+func TestVerificationPolicyEpid(t *testing.T) {
+	verifier := newTestIASVerifier(t)
+	report, err := verifier.Verify(readIASFixture(t, IASQuoteStatusOK))
+	require.NoError(t, err)
+	timestamp, err := time.Parse(iasReportTimestampLayout, report.Response.Timestamp)
+	require.NoError(t, err)
+
+	check := func(policyJson string, quoteStatus string, now time.Time) error {
+		policy, err := ParseVerificationPolicy([]byte(policyJson))
+		require.NoError(t, err)
+		verifier := NewIASVerifier(verifier.roots, WithIASCurrentTime(func() time.Time { return now }))
+		return verifier.VerifyEvidence(readIASFixture(t, quoteStatus), []byte(iasTestStatement), iasTestMrEnclave(), policy)
+	}
+
+	// quote status
+	assert.NoError(t, check(`{}`, IASQuoteStatusGroupOutOfDate, timestamp))
+	assert.EqualError(t, check(`{"allowed_quote_statuses": ["OK"]}`, IASQuoteStatusGroupOutOfDate, timestamp),
+		"invalid quote status 'GROUP_OUT_OF_DATE'")
+	assert.EqualError(t, check(`{}`, IASQuoteStatusConfigurationNeeded, timestamp),
+		"invalid quote status 'CONFIGURATION_NEEDED'")
+	assert.NoError(t, check(`{"allowed_quote_statuses": ["OK", "CONFIGURATION_NEEDED"]}`, IASQuoteStatusConfigurationNeeded, timestamp))
+
+	// the fixtures are created by a release enclave
+	assert.NoError(t, check(`{"allow_debug_enclaves": false}`, IASQuoteStatusOK, timestamp))
+
+	// isv svn
+	assert.NoError(t, check(`{"min_isv_svn": 0}`, IASQuoteStatusOK, timestamp))
+	assert.EqualError(t, check(`{"min_isv_svn": 1}`, IASQuoteStatusOK, timestamp), "isv svn 0 is lower than 1")
+
+	// report age
+	assert.NoError(t, check(`{"max_report_age_seconds": 3600}`, IASQuoteStatusOK, timestamp.Add(time.Hour)))
+	assert.Contains(t, check(`{"max_report_age_seconds": 3600}`, IASQuoteStatusOK, timestamp.Add(time.Hour+time.Second)).Error(),
+		"is older than 1h0m0s")
+	assert.NoError(t, check(`{}`, IASQuoteStatusOK, timestamp.AddDate(1, 0, 0)))
+}
+
+func TestVerificationPolicyDcap(t *testing.T) {
+	evidence := convertDcapFixture(t)
+	verifier := newTestDcapVerifier(t)
+
+	check := func(policyJson string) error {
+		policy, err := ParseVerificationPolicy([]byte(policyJson))
+		require.NoError(t, err)
+		return verifier.VerifyEvidence(evidence, []byte(dcapTestStatement), dcapTestMrEnclave(), policy)
+	}
+
+	assert.NoError(t, check(`{}`))
+	assert.NoError(t, check(`{"allowed_tcb_statuses": ["UpToDate"], "allow_debug_enclaves": false}`))
+	assert.EqualError(t, check(`{"allowed_tcb_statuses": ["OutOfDate"]}`), "invalid tcb status 'UpToDate'")
+
+	// isv svn
+	report, err := verifier.Verify(evidence)
+	require.NoError(t, err)
+	minIsvSvn := report.ReportBody.IsvSvn + 1
+	assert.NoError(t, check(fmt.Sprintf(`{"min_isv_svn": %d}`, report.ReportBody.IsvSvn)))
+	assert.EqualError(t, check(fmt.Sprintf(`{"min_isv_svn": %d}`, minIsvSvn)),
+		fmt.Sprintf("isv svn %d is lower than %d", report.ReportBody.IsvSvn, minIsvSvn))
+
+	// debug enclaves
+	report = &DcapReport{
+		ReportBody: parseSgxReportBody(make([]byte, quoteReportBodyLen)),
+		TcbStatus:  TcbStatusUpToDate, QeTcbStatus: TcbStatusUpToDate,
+	}
+	report.ReportBody.Attributes[0] |= sgxFlagsDebug
+	assert.EqualError(t, DefaultVerificationPolicy().CheckDcapReport(report), "debug enclaves are not accepted")
+	allowDebug, err := ParseVerificationPolicy([]byte(`{"allow_debug_enclaves": true}`))
+	require.NoError(t, err)
+	assert.NoError(t, allowDebug.CheckDcapReport(report))
+}
@@ -0,0 +1,346 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package attestation
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// layout of an Intel SGX ECDSA quote (version 3) as defined by sgx_quote_3_t of the Intel SGX DCAP library.
+// The header and the report body are shared with EPID quotes (sgx_quote_t).
+const (
+	quoteHeaderLen       = 48
+	quoteReportBodyLen   = 384
+	quoteSignedLen       = quoteHeaderLen + quoteReportBodyLen
+	quoteSignatureLenLen = 4
+	ecdsaSignatureLen    = 64
+	ecdsaPublicKeyLen    = 64
+
+	quoteVersion        = 3
+	quoteAttKeyTypeP256 = 2
+
+	// certification data type of a PEM encoded PCK certificate chain
+	pckCertChainCertDataType = 5
+
+	// sgx report body offsets
+	reportCpuSvnOffset     = 0
+	reportMiscSelectOffset = 16
+	reportAttributesOffset = 48
+	reportMrEnclaveOffset  = 64
+	reportMrSignerOffset   = 128
+	reportIsvProdIdOffset  = 256
+	reportIsvSvnOffset     = 258
+	reportDataOffset       = 320
+	reportDataLen          = 64
+
+	sgxFlagsDebug = 0x02
+)
+
+// object identifiers of the Intel SGX PCK certificate extensions
+var (
+	oidSgxExtension = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1}
+	oidSgxTcb       = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 2}
+	oidSgxPceId     = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 3}
+	oidSgxFmspc     = asn1.ObjectIdentifier{1, 2, 840, 113741, 1, 13, 1, 4}
+)
+
+const (
+	// the TCB extension contains the 16 SGX TCB component SVNs (1-16), the PCE SVN (17) and the CPU SVN (18)
+	sgxTcbComponents = 16
+	sgxTcbPceSvn     = 17
+)
+
+// SgxReportBody contains the fields of an Intel SGX report used for attestation
+type SgxReportBody struct {
+	CpuSvn     []byte
+	MiscSelect uint32
+	Attributes []byte
+	MrEnclave  []byte
+	MrSigner   []byte
+	IsvProdId  uint16
+	IsvSvn     uint16
+	ReportData []byte
+	raw        []byte
+}
+
+// Debug returns true if the report is created by an enclave in debug mode
+func (r *SgxReportBody) Debug() bool {
+	return r.Attributes[0]&sgxFlagsDebug != 0
+}
+
+// checkReportBinding checks that the report has the expected mrenclave (hex encoded) and that the report data binds
+// the expected statement, i.e., contains the sha256 hash of the statement followed by zeros
+func checkReportBinding(report *SgxReportBody, expectedStatementBytes []byte, expectedMrEnclave string) error {
+	mrenclave := hex.EncodeToString(report.MrEnclave)
+	if !strings.EqualFold(mrenclave, expectedMrEnclave) {
+		return fmt.Errorf("expected mrenclave mismatch: found '%s', expected '%s'", mrenclave, expectedMrEnclave)
+	}
+
+	expectedReportData := make([]byte, reportDataLen)
+	statementHash := sha256.Sum256(expectedStatementBytes)
+	copy(expectedReportData, statementHash[:])
+	if !bytes.Equal(report.ReportData, expectedReportData) {
+		return errors.New("expected statement mismatch")
+	}
+
+	return nil
+}
+
+// DcapQuote is a parsed Intel SGX ECDSA quote
+type DcapQuote struct {
+	Version     uint16
+	AttKeyType  uint16
+	QeSvn       uint16
+	PceSvn      uint16
+	ReportBody  *SgxReportBody
+	Signature   []byte
+	AttestKey   []byte
+	QeReport    *SgxReportBody
+	QeReportSig []byte
+	QeAuthData  []byte
+	CertType    uint16
+	CertData    []byte
+	signed      []byte
+}
+
+// ParseDcapQuote parses an Intel SGX ECDSA (version 3) quote
+func ParseDcapQuote(quoteBytes []byte) (*DcapQuote, error) {
+	if len(quoteBytes) < quoteSignedLen+quoteSignatureLenLen {
+		return nil, fmt.Errorf("quote too short (%d bytes)", len(quoteBytes))
+	}
+
+	q := &DcapQuote{
+		Version:    binary.LittleEndian.Uint16(quoteBytes[0:]),
+		AttKeyType: binary.LittleEndian.Uint16(quoteBytes[2:]),
+		QeSvn:      binary.LittleEndian.Uint16(quoteBytes[8:]),
+		PceSvn:     binary.LittleEndian.Uint16(quoteBytes[10:]),
+		ReportBody: parseSgxReportBody(quoteBytes[quoteHeaderLen:quoteSignedLen]),
+		signed:     quoteBytes[:quoteSignedLen],
+	}
+	if q.Version != quoteVersion {
+		return nil, fmt.Errorf("unsupported quote version %d", q.Version)
+	}
+	if q.AttKeyType != quoteAttKeyTypeP256 {
+		return nil, fmt.Errorf("unsupported attestation key type %d", q.AttKeyType)
+	}
+
+	sigDataLen := binary.LittleEndian.Uint32(quoteBytes[quoteSignedLen:])
+	sigData := quoteBytes[quoteSignedLen+quoteSignatureLenLen:]
+	if uint64(len(sigData)) != uint64(sigDataLen) {
+		return nil, fmt.Errorf("unexpected quote signature data length %d, expected %d", len(sigData), sigDataLen)
+	}
+
+	r := &quoteReader{data: sigData}
+	q.Signature = r.next(ecdsaSignatureLen)
+	q.AttestKey = r.next(ecdsaPublicKeyLen)
+	if qeReport := r.next(quoteReportBodyLen); qeReport != nil {
+		q.QeReport = parseSgxReportBody(qeReport)
+	}
+	q.QeReportSig = r.next(ecdsaSignatureLen)
+	q.QeAuthData = r.next(int(r.uint16()))
+	q.CertType = r.uint16()
+	q.CertData = r.next(int(r.uint32()))
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid quote signature data")
+	}
+
+	return q, nil
+}
+
+// PckCertChain returns the PCK certificate chain embedded in the certification data of the quote.
+// The chain is returned PEM encoded, starting with the PCK certificate.
+func (q *DcapQuote) PckCertChain() (string, error) {
+	if q.CertType != pckCertChainCertDataType {
+		return "", fmt.Errorf("unsupported certification data type %d", q.CertType)
+	}
+	// the certification data may be zero terminated
+	certData := q.CertData
+	if n := len(certData); n > 0 && certData[n-1] == 0 {
+		certData = certData[:n-1]
+	}
+	return string(certData), nil
+}
+
+func parseSgxReportBody(raw []byte) *SgxReportBody {
+	return &SgxReportBody{
+		CpuSvn:     raw[reportCpuSvnOffset : reportCpuSvnOffset+16],
+		MiscSelect: binary.LittleEndian.Uint32(raw[reportMiscSelectOffset:]),
+		Attributes: raw[reportAttributesOffset : reportAttributesOffset+16],
+		MrEnclave:  raw[reportMrEnclaveOffset : reportMrEnclaveOffset+32],
+		MrSigner:   raw[reportMrSignerOffset : reportMrSignerOffset+32],
+		IsvProdId:  binary.LittleEndian.Uint16(raw[reportIsvProdIdOffset:]),
+		IsvSvn:     binary.LittleEndian.Uint16(raw[reportIsvSvnOffset:]),
+		ReportData: raw[reportDataOffset : reportDataOffset+reportDataLen],
+		raw:        raw,
+	}
+}
+
+// quoteReader reads the variable length fields of the quote signature data; after the first error all reads return
+// nil or zero.
+type quoteReader struct {
+	data []byte
+	err  error
+}
+
+func (r *quoteReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) < n {
+		r.err = fmt.Errorf("unexpected end of data, expected %d more bytes", n-len(r.data))
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *quoteReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *quoteReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// pckInfo contains the platform information of the Intel SGX extension of a PCK certificate
+type pckInfo struct {
+	Fmspc   string
+	PceId   string
+	TcbSvns []int
+	PceSvn  int
+}
+
+type sgxExtensionEntry struct {
+	Id    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// parsePckInfo extracts the FMSPC, the PCE ID, and the TCB of the platform from a PCK certificate
+func parsePckInfo(cert *x509.Certificate) (*pckInfo, error) {
+	var extension []byte
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidSgxExtension) {
+			extension = ext.Value
+		}
+	}
+	if extension == nil {
+		return nil, errors.New("no SGX extension in PCK certificate")
+	}
+
+	var entries []sgxExtensionEntry
+	if _, err := asn1.Unmarshal(extension, &entries); err != nil {
+		return nil, errors.Wrap(err, "invalid SGX extension")
+	}
+
+	info := &pckInfo{TcbSvns: make([]int, sgxTcbComponents), PceSvn: -1}
+	for _, entry := range entries {
+		switch {
+		case entry.Id.Equal(oidSgxFmspc):
+			info.Fmspc = hex.EncodeToString(entry.Value.Bytes)
+		case entry.Id.Equal(oidSgxPceId):
+			info.PceId = hex.EncodeToString(entry.Value.Bytes)
+		case entry.Id.Equal(oidSgxTcb):
+			var tcb []sgxExtensionEntry
+			if _, err := asn1.Unmarshal(entry.Value.FullBytes, &tcb); err != nil {
+				return nil, errors.Wrap(err, "invalid SGX TCB extension")
+			}
+			for _, comp := range tcb {
+				index := comp.Id[len(comp.Id)-1]
+				if index > sgxTcbPceSvn {
+					// cpusvn
+					continue
+				}
+				var svn int
+				if _, err := asn1.Unmarshal(comp.Value.FullBytes, &svn); err != nil {
+					return nil, errors.Wrapf(err, "invalid SGX TCB component %d", index)
+				}
+				if index == sgxTcbPceSvn {
+					info.PceSvn = svn
+				} else {
+					info.TcbSvns[index-1] = svn
+				}
+			}
+		}
+	}
+
+	if info.Fmspc == "" {
+		return nil, errors.New("no FMSPC in PCK certificate")
+	}
+	if info.PceSvn < 0 {
+		return nil, errors.New("no PCE SVN in PCK certificate")
+	}
+
+	return info, nil
+}
+
+// parseCertChain parses a PEM encoded certificate chain
+func parseCertChain(chainPem string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(chainPem)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse certificate")
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	return certs, nil
+}
+
+// parseP256PublicKey parses a raw P-256 public key (x || y) as used in quotes
+func parseP256PublicKey(raw []byte) (*ecdsa.PublicKey, error) {
+	curve := elliptic.P256()
+	key := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(raw[:32]),
+		Y:     new(big.Int).SetBytes(raw[32:]),
+	}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("invalid P-256 public key")
+	}
+	return key, nil
+}
+
+// verifyP256Signature verifies a raw P-256 signature (r || s) over the SHA256 digest of a message
+func verifyP256Signature(key *ecdsa.PublicKey, digest, signature []byte) bool {
+	if len(signature) != ecdsaSignatureLen {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	return ecdsa.Verify(key, digest, r, s)
+}
@@ -6,10 +6,13 @@ SPDX-License-Identifier: Apache-2.0
 
 package attestation
 
+// SimulatedType is the attestation type of Intel SGX simulation mode
+const SimulatedType = "simulated"
+
 // NewSimulationConverter creates a new attestation converter for Intel SGX simulation mode
 func NewSimulationConverter() *Converter {
 	return &Converter{
-		Type: "simulated",
+		Type: SimulatedType,
 		Converter: func(attestationBytes []byte) (evidenceBytes []byte, err error) {
 			return attestationBytes, nil
 		},
@@ -0,0 +1,57 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+)
+
+// RequestNonceLength is the length of the request nonce in KeyTransportMessage
+const RequestNonceLength = 32
+
+// labels to distinguish the associated data of requests and responses
+const (
+	requestAssociatedDataLabel  = "fpc-request"
+	responseAssociatedDataLabel = "fpc-response"
+)
+
+// NewRequestNonce returns a fresh random nonce for a KeyTransportMessage
+func NewRequestNonce() ([]byte, error) {
+	nonce := make([]byte, RequestNonceLength)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// RequestAssociatedData returns the associated data of the encryption of a CleartextChaincodeRequest, which binds the
+// request to the channel, the chaincode and the request nonce of the KeyTransportMessage.
+// The enclave builds the same associated data (see build_associated_data in ecc_enclave/enclave/crypto.h).
+func RequestAssociatedData(channelId, chaincodeId string, requestNonce []byte) []byte {
+	return associatedData(requestAssociatedDataLabel, channelId, chaincodeId, requestNonce)
+}
+
+// ResponseAssociatedData returns the associated data of the encryption of a CleartextChaincodeResponse. It binds the
+// response to the same channel, chaincode and request nonce as the request.
+func ResponseAssociatedData(channelId, chaincodeId string, requestNonce []byte) []byte {
+	return associatedData(responseAssociatedDataLabel, channelId, chaincodeId, requestNonce)
+}
+
+// associatedData encodes each field as 4 bytes big-endian length followed by the value, so that the encoding is
+// unambiguous
+func associatedData(label, channelId, chaincodeId string, requestNonce []byte) []byte {
+	var ad []byte
+	for _, field := range [][]byte{[]byte(label), []byte(channelId), []byte(chaincodeId), requestNonce} {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+		ad = append(ad, length[:]...)
+		ad = append(ad, field...)
+	}
+	return ad
+}
@@ -27,9 +27,25 @@ type EncryptionProvider interface {
 type EncryptionProviderImpl struct {
 	CSP                CSP
 	GetCcEncryptionKey func() ([]byte, error)
+
+	// ChannelId and ChaincodeId bind the request and response encryption to the chaincode (see
+	// RequestAssociatedData). If ChaincodeId is empty, requests are not bound, as required by enclaves that do not
+	// support the request nonce of KeyTransportMessage.
+	ChannelId   string
+	ChaincodeId string
+
+	// RequestPadding and ResponsePadding, if set, pad requests and responses before their encryption, so that the
+	// size of the ciphertexts does not reveal the exact size of the arguments and the responses. This requires that
+	// the chaincode enclave supports the padding policies of KeyTransportMessage.
+	RequestPadding  *PaddingPolicy
+	ResponsePadding *PaddingPolicy
 }
 
 func (p EncryptionProviderImpl) NewEncryptionContext() (EncryptionContext, error) {
+	return p.newEncryptionContext()
+}
+
+func (p EncryptionProviderImpl) newEncryptionContext() (*EncryptionContextImpl, error) {
 	// pick request encryption key
 	requestEncryptionKey, err := p.CSP.NewSymmetricKey()
 	if err != nil {
@@ -52,14 +68,35 @@ func (p EncryptionProviderImpl) NewEncryptionContext() (EncryptionContext, error
 		return nil, err
 	}
 
+	requestNonce, err := newRequestNonceIfBound(p.ChaincodeId)
+	if err != nil {
+		return nil, err
+	}
+
 	return &EncryptionContextImpl{
 		csp:                    p.CSP,
 		requestEncryptionKey:   requestEncryptionKey,
 		responseEncryptionKey:  resultEncryptionKey,
 		chaincodeEncryptionKey: ccEncryptionKey,
+		channelId:              p.ChannelId,
+		chaincodeId:            p.ChaincodeId,
+		requestNonce:           requestNonce,
+		requestPadding:         p.RequestPadding,
+		responsePadding:        p.ResponsePadding,
 	}, nil
 }
 
+func newRequestNonceIfBound(chaincodeId string) ([]byte, error) {
+	if len(chaincodeId) == 0 {
+		return nil, nil
+	}
+	requestNonce, err := NewRequestNonce()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create request nonce")
+	}
+	return requestNonce, nil
+}
+
 // EncryptionContext defines the interface of an object responsible to encrypt the contents of a transaction invocation
 // and to decrypt the corresponding response.
 // Conceal and Reveal must be called only once during the lifetime of an object that implements this interface. That is,
@@ -69,14 +106,72 @@ type EncryptionContext interface {
 	Reveal(r []byte) ([]byte, error)
 }
 
+// Invocation describes a single chaincode invocation of a batch
+type Invocation struct {
+	Function string
+	Args     []string
+}
+
+// BatchEncryptionContext extends EncryptionContext with batch invocations, i.e., several chaincode invocations which
+// are executed by the enclave within a single transaction. As for EncryptionContext, ConcealBatch and RevealBatch must
+// be called only once during the lifetime of an object.
+type BatchEncryptionContext interface {
+	EncryptionContext
+	ConcealBatch(invocations []Invocation) (string, error)
+	RevealBatch(r []byte) ([][]byte, error)
+}
+
 type EncryptionContextImpl struct {
 	csp                    CSP
 	requestEncryptionKey   []byte
 	responseEncryptionKey  []byte
 	chaincodeEncryptionKey []byte
+
+	// if requestNonce is set, request and response are bound to channelId, chaincodeId and requestNonce
+	channelId    string
+	chaincodeId  string
+	requestNonce []byte
+
+	// if set, request and response are padded according to these policies
+	requestPadding  *PaddingPolicy
+	responsePadding *PaddingPolicy
+}
+
+func (e *EncryptionContextImpl) requestAssociatedData() []byte {
+	if e.requestNonce == nil {
+		return nil
+	}
+	return RequestAssociatedData(e.channelId, e.chaincodeId, e.requestNonce)
+}
+
+func (e *EncryptionContextImpl) responseAssociatedData() []byte {
+	if e.requestNonce == nil {
+		return nil
+	}
+	return ResponseAssociatedData(e.channelId, e.chaincodeId, e.requestNonce)
 }
 
 func (e *EncryptionContextImpl) Reveal(signedResponseBytesB64 []byte) ([]byte, error) {
+	return e.reveal(signedResponseBytesB64)
+}
+
+// RevealBatch returns the responses of a batch invocation, in the order of the invocations passed to ConcealBatch
+func (e *EncryptionContextImpl) RevealBatch(signedResponseBytesB64 []byte) ([][]byte, error) {
+	clearResponse, err := e.reveal(signedResponseBytesB64)
+	if err != nil {
+		return nil, err
+	}
+
+	batchResponse := &protos.CleartextChaincodeBatchResponse{}
+	err = proto.Unmarshal(clearResponse, batchResponse)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid batch response")
+	}
+
+	return batchResponse.GetResponses(), nil
+}
+
+func (e *EncryptionContextImpl) reveal(signedResponseBytesB64 []byte) ([]byte, error) {
 	signedResponseBytes, err := base64.StdEncoding.DecodeString(string(signedResponseBytesB64))
 	if err != nil {
 		return nil, err
@@ -99,10 +194,16 @@ func (e *EncryptionContextImpl) Reveal(signedResponseBytesB64 []byte) ([]byte, e
 		return nil, err
 	}
 
-	clearResponseB64, err := e.csp.DecryptMessage(e.responseEncryptionKey, response.EncryptedResponse)
+	clearResponseB64, err := e.csp.DecryptMessageWithAAD(e.responseEncryptionKey, response.EncryptedResponse, e.responseAssociatedData())
 	if err != nil {
 		return nil, errors.Wrap(err, "decryption of response failed")
 	}
+	if e.responsePadding != nil {
+		clearResponseB64, err = UnpadMessage(clearResponseB64)
+		if err != nil {
+			return nil, errors.Wrap(err, "response is not padded")
+		}
+	}
 	// TODO: above should eventually be a (protobuf but not base64 serialized) fabric response object,
 	//   rather than just the (base64-serialized) response string.
 	//   so we also get fpc chaincode return-code/error-message as in for normal fabric
@@ -115,16 +216,49 @@ func (e *EncryptionContextImpl) Reveal(signedResponseBytesB64 []byte) ([]byte, e
 }
 
 func (e *EncryptionContextImpl) Conceal(function string, args []string) (string, error) {
+	// prepare CleartextChaincodeRequest
+	ccRequest := &protos.CleartextChaincodeRequest{
+		Input: chaincodeInput(function, args),
+	}
+	logger.Debugf("prepping chaincode params: %s", ccRequest)
+
+	return e.conceal(ccRequest)
+}
+
+// ConcealBatch encrypts several invocations which are executed by the enclave, in the given order, as a single
+// transaction
+func (e *EncryptionContextImpl) ConcealBatch(invocations []Invocation) (string, error) {
+	if len(invocations) == 0 {
+		return "", fmt.Errorf("empty batch")
+	}
+
+	// prepare CleartextChaincodeRequest
+	ccRequest := &protos.CleartextChaincodeRequest{}
+	for _, i := range invocations {
+		ccRequest.BatchInputs = append(ccRequest.BatchInputs, chaincodeInput(i.Function, i.Args))
+	}
+	logger.Debugf("prepping chaincode params for batch of %d invocations", len(invocations))
+
+	return e.conceal(ccRequest)
+}
+
+func chaincodeInput(function string, args []string) *peer.ChaincodeInput {
 	args = append([]string{function}, args...)
 	bytes := make([][]byte, len(args))
 	for i, v := range args {
 		bytes[i] = []byte(v)
 	}
+	return &peer.ChaincodeInput{Args: bytes}
+}
 
+func (e *EncryptionContextImpl) conceal(ccRequest *protos.CleartextChaincodeRequest) (string, error) {
 	// prepare KeyTransportMessage
 	keyTransport := &protos.KeyTransportMessage{
 		RequestEncryptionKey:  e.requestEncryptionKey,
 		ResponseEncryptionKey: e.responseEncryptionKey,
+		RequestNonce:          e.requestNonce,
+		RequestPadding:        e.requestPadding,
+		ResponsePadding:       e.responsePadding,
 	}
 
 	serializedKeyTransport, err := proto.Marshal(keyTransport)
@@ -137,18 +271,15 @@ func (e *EncryptionContextImpl) Conceal(function string, args []string) (string,
 		return "", errors.Wrap(err, "encryption of request encryption key failed")
 	}
 
-	// prepare CleartextChaincodeRequest
-	ccRequest := &protos.CleartextChaincodeRequest{
-		Input: &peer.ChaincodeInput{Args: bytes},
-	}
-	logger.Debugf("prepping chaincode params: %s", ccRequest)
-
 	serializedCcRequest, err := proto.Marshal(ccRequest)
 	if err != nil {
 		return "", err
 	}
+	if e.requestPadding != nil {
+		serializedCcRequest = PadMessage(serializedCcRequest, e.requestPadding)
+	}
 
-	encryptedRequest, err := e.csp.EncryptMessage(e.requestEncryptionKey, serializedCcRequest)
+	encryptedRequest, err := e.csp.EncryptMessageWithAAD(e.requestEncryptionKey, serializedCcRequest, e.requestAssociatedData())
 	if err != nil {
 		return "", errors.Wrap(err, "encryption of request failed")
 	}
@@ -16,6 +16,7 @@ import (
 	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/test-go/testify/assert"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNewEncryptionContext(t *testing.T) {
@@ -165,3 +166,226 @@ func TestReveal(t *testing.T) {
 	assert.Equal(t, resp, msg)
 	assert.NoError(t, err)
 }
+
+func TestConcealBatch(t *testing.T) {
+	requestEncryptionKey, err := GetDefaultCSP().NewSymmetricKey()
+	assert.NoError(t, err)
+
+	// we use a symmetric "chaincode encryption key" here to be able to decrypt the key transport in the test
+	ctx := &EncryptionContextImpl{
+		csp:                    &symmetricPkCSP{GetDefaultCSP()},
+		requestEncryptionKey:   requestEncryptionKey,
+		chaincodeEncryptionKey: requestEncryptionKey,
+	}
+
+	request, err := ctx.ConcealBatch(nil)
+	assert.Empty(t, request)
+	assert.EqualError(t, err, "empty batch")
+
+	request, err = ctx.ConcealBatch([]Invocation{
+		{Function: "put", Args: []string{"k1", "v1"}},
+		{Function: "put", Args: []string{"k2", "v2"}},
+	})
+	assert.NoError(t, err)
+
+	requestBytes, err := base64.StdEncoding.DecodeString(request)
+	assert.NoError(t, err)
+	requestMessage := &protos.ChaincodeRequestMessage{}
+	assert.NoError(t, proto.Unmarshal(requestBytes, requestMessage))
+	clearRequestBytes, err := GetDefaultCSP().DecryptMessage(requestEncryptionKey, requestMessage.EncryptedRequest)
+	assert.NoError(t, err)
+	clearRequest := &protos.CleartextChaincodeRequest{}
+	assert.NoError(t, proto.Unmarshal(clearRequestBytes, clearRequest))
+
+	assert.Nil(t, clearRequest.Input)
+	assert.Len(t, clearRequest.BatchInputs, 2)
+	assert.Equal(t, [][]byte{[]byte("put"), []byte("k2"), []byte("v2")}, clearRequest.BatchInputs[1].Args)
+}
+
+func TestRevealBatch(t *testing.T) {
+	responseEncryptionKey, err := GetDefaultCSP().NewSymmetricKey()
+	assert.NoError(t, err)
+
+	ctx := &EncryptionContextImpl{
+		csp:                   GetDefaultCSP(),
+		responseEncryptionKey: responseEncryptionKey,
+	}
+
+	encrypt := func(clearResponse []byte) []byte {
+		encryptedMsg, err := GetDefaultCSP().EncryptMessage(responseEncryptionKey, []byte(base64.StdEncoding.EncodeToString(clearResponse)))
+		assert.NoError(t, err)
+		responseBytes := protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{EncryptedResponse: encryptedMsg})
+		return []byte(utils.MarshallProto(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: responseBytes}))
+	}
+
+	// not a batch response
+	resp, err := ctx.RevealBatch(encrypt([]byte("not a batch response")))
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+
+	// should succeed
+	expected := [][]byte{[]byte("first"), []byte("second")}
+	resp, err = ctx.RevealBatch(encrypt(protoutil.MarshalOrPanic(&protos.CleartextChaincodeBatchResponse{Responses: expected})))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, resp)
+}
+
+func TestBoundEncryptionContext(t *testing.T) {
+	csp := &symmetricPkCSP{GetDefaultCSP()}
+	ccEncryptionKey, err := csp.NewSymmetricKey()
+	assert.NoError(t, err)
+
+	provider := &EncryptionProviderImpl{
+		CSP: csp,
+		GetCcEncryptionKey: func() ([]byte, error) {
+			return []byte(base64.StdEncoding.EncodeToString(ccEncryptionKey)), nil
+		},
+		ChannelId:   "mychannel",
+		ChaincodeId: "mycc",
+	}
+	ctx, err := provider.NewEncryptionContext()
+	assert.NoError(t, err)
+
+	request, err := ctx.Conceal("some function", []string{"some", "args"})
+	assert.NoError(t, err)
+
+	// decrypt the request as the enclave does
+	requestBytes, err := base64.StdEncoding.DecodeString(request)
+	assert.NoError(t, err)
+	requestMessage := &protos.ChaincodeRequestMessage{}
+	assert.NoError(t, proto.Unmarshal(requestBytes, requestMessage))
+	keyTransportBytes, err := csp.DecryptMessage(ccEncryptionKey, requestMessage.EncryptedKeyTransportMessage)
+	assert.NoError(t, err)
+	keyTransport := &protos.KeyTransportMessage{}
+	assert.NoError(t, proto.Unmarshal(keyTransportBytes, keyTransport))
+	assert.Len(t, keyTransport.RequestNonce, RequestNonceLength)
+
+	// the request is bound to channel and chaincode
+	_, err = csp.DecryptMessage(keyTransport.RequestEncryptionKey, requestMessage.EncryptedRequest)
+	assert.Error(t, err)
+	_, err = csp.DecryptMessageWithAAD(keyTransport.RequestEncryptionKey, requestMessage.EncryptedRequest,
+		RequestAssociatedData("mychannel", "othercc", keyTransport.RequestNonce))
+	assert.Error(t, err)
+	_, err = csp.DecryptMessageWithAAD(keyTransport.RequestEncryptionKey, requestMessage.EncryptedRequest,
+		RequestAssociatedData("otherchannel", "mycc", keyTransport.RequestNonce))
+	assert.Error(t, err)
+	_, err = csp.DecryptMessageWithAAD(keyTransport.RequestEncryptionKey, requestMessage.EncryptedRequest,
+		ResponseAssociatedData("mychannel", "mycc", keyTransport.RequestNonce))
+	assert.Error(t, err)
+	clearRequestBytes, err := csp.DecryptMessageWithAAD(keyTransport.RequestEncryptionKey,
+		requestMessage.EncryptedRequest, RequestAssociatedData("mychannel", "mycc", keyTransport.RequestNonce))
+	assert.NoError(t, err)
+	clearRequest := &protos.CleartextChaincodeRequest{}
+	assert.NoError(t, proto.Unmarshal(clearRequestBytes, clearRequest))
+	assert.Equal(t, []byte("some function"), clearRequest.Input.Args[0])
+
+	encrypt := func(ad []byte) []byte {
+		encryptedMsg, err := csp.EncryptMessageWithAAD(keyTransport.ResponseEncryptionKey,
+			[]byte(base64.StdEncoding.EncodeToString([]byte("some response"))), ad)
+		assert.NoError(t, err)
+		responseBytes := protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{EncryptedResponse: encryptedMsg})
+		return []byte(utils.MarshallProto(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: responseBytes}))
+	}
+
+	// a response of another request or chaincode is rejected
+	otherNonce, err := NewRequestNonce()
+	assert.NoError(t, err)
+	_, err = ctx.Reveal(encrypt(ResponseAssociatedData("mychannel", "mycc", otherNonce)))
+	assert.Error(t, err)
+	_, err = ctx.Reveal(encrypt(ResponseAssociatedData("mychannel", "othercc", keyTransport.RequestNonce)))
+	assert.Error(t, err)
+	_, err = ctx.Reveal(encrypt(nil))
+	assert.Error(t, err)
+
+	// should succeed
+	resp, err := ctx.Reveal(encrypt(ResponseAssociatedData("mychannel", "mycc", keyTransport.RequestNonce)))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("some response"), resp)
+}
+
+func TestPaddedEncryptionContext(t *testing.T) {
+	csp := &symmetricPkCSP{GetDefaultCSP()}
+	ccEncryptionKey, err := csp.NewSymmetricKey()
+	assert.NoError(t, err)
+
+	provider := &EncryptionProviderImpl{
+		CSP: csp,
+		GetCcEncryptionKey: func() ([]byte, error) {
+			return []byte(base64.StdEncoding.EncodeToString(ccEncryptionKey)), nil
+		},
+		RequestPadding:  BucketPadding(1024),
+		ResponsePadding: PowerOfTwoPadding(),
+	}
+
+	// decrypt the request as the enclave does
+	conceal := func(ctx EncryptionContext, args ...string) (*protos.ChaincodeRequestMessage, *protos.KeyTransportMessage) {
+		request, err := ctx.Conceal("some function", args)
+		assert.NoError(t, err)
+		requestBytes, err := base64.StdEncoding.DecodeString(request)
+		assert.NoError(t, err)
+		requestMessage := &protos.ChaincodeRequestMessage{}
+		assert.NoError(t, proto.Unmarshal(requestBytes, requestMessage))
+		keyTransportBytes, err := csp.DecryptMessage(ccEncryptionKey, requestMessage.EncryptedKeyTransportMessage)
+		assert.NoError(t, err)
+		keyTransport := &protos.KeyTransportMessage{}
+		assert.NoError(t, proto.Unmarshal(keyTransportBytes, keyTransport))
+		return requestMessage, keyTransport
+	}
+
+	ctx, err := provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	requestMessage, keyTransport := conceal(ctx, "1")
+	assert.Equal(t, []uint32{1024}, keyTransport.RequestPadding.GetBucketSizes())
+	assert.NotNil(t, keyTransport.ResponsePadding)
+
+	// requests with arguments of different size cannot be distinguished
+	otherCtx, err := provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	otherRequestMessage, _ := conceal(otherCtx, "1000000", "some more args")
+	assert.Len(t, otherRequestMessage.EncryptedRequest, len(requestMessage.EncryptedRequest))
+
+	paddedRequest, err := csp.DecryptMessage(keyTransport.RequestEncryptionKey, requestMessage.EncryptedRequest)
+	assert.NoError(t, err)
+	assert.Len(t, paddedRequest, 1024)
+	clearRequestBytes, err := UnpadMessage(paddedRequest)
+	assert.NoError(t, err)
+	clearRequest := &protos.CleartextChaincodeRequest{}
+	assert.NoError(t, proto.Unmarshal(clearRequestBytes, clearRequest))
+	assert.Equal(t, [][]byte{[]byte("some function"), []byte("1")}, clearRequest.Input.Args)
+
+	encrypt := func(clearResponse []byte) []byte {
+		encryptedMsg, err := csp.EncryptMessage(keyTransport.ResponseEncryptionKey, clearResponse)
+		assert.NoError(t, err)
+		responseBytes := protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{EncryptedResponse: encryptedMsg})
+		return []byte(utils.MarshallProto(&protos.SignedChaincodeResponseMessage{ChaincodeResponseMessage: responseBytes}))
+	}
+
+	// the response must be padded
+	b64Response := []byte(base64.StdEncoding.EncodeToString([]byte("some response")))
+	_, err = ctx.Reveal(encrypt(b64Response))
+	assert.Error(t, err)
+
+	// should succeed
+	resp, err := ctx.Reveal(encrypt(PadMessage(b64Response, keyTransport.ResponsePadding)))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("some response"), resp)
+}
+
+func TestAssociatedData(t *testing.T) {
+	nonce := []byte{1, 2}
+	assert.Equal(t, []byte("\x00\x00\x00\x0bfpc-request\x00\x00\x00\x02ch\x00\x00\x00\x02cc\x00\x00\x00\x02\x01\x02"),
+		RequestAssociatedData("ch", "cc", nonce))
+
+	// the encoding is unambiguous
+	assert.NotEqual(t, RequestAssociatedData("ch", "cc", nonce), RequestAssociatedData("chc", "c", nonce))
+	assert.NotEqual(t, RequestAssociatedData("ch", "cc", nonce), ResponseAssociatedData("ch", "cc", nonce))
+}
+
+// symmetricPkCSP replaces public key encryption with symmetric encryption
+type symmetricPkCSP struct {
+	CSP
+}
+
+func (c *symmetricPkCSP) PkEncryptMessage(key, message []byte) ([]byte, error) {
+	return c.EncryptMessage(key, message)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// EventDecrypter verifies and decrypts encrypted chaincode events emitted by FPC chaincodes.
+// The payload of such an event is the SignedChaincodeResponseMessage of the transaction setting the event.
+type EventDecrypter struct {
+	CSP CSP
+
+	// EventKey is the (symmetric) key used by the chaincode to encrypt the event payload
+	EventKey []byte
+
+	// GetEnclaveVk returns the enclave verification key (enclave_vk) of the enclave with the given enclave id,
+	// as registered at ERCC
+	GetEnclaveVk func(enclaveId string) ([]byte, error)
+}
+
+// Decrypt checks the enclave signature over the given event payload and that the encrypted event matches the name
+// of the Fabric chaincode event, and then returns the decrypted event payload.
+func (d *EventDecrypter) Decrypt(eventName string, payload []byte) ([]byte, error) {
+	signedResponse := &protos.SignedChaincodeResponseMessage{}
+	if err := proto.Unmarshal(payload, signedResponse); err != nil {
+		return nil, errors.Wrap(err, "invalid event payload")
+	}
+
+	responseBytes := signedResponse.GetChaincodeResponseMessage()
+	if responseBytes == nil {
+		return nil, fmt.Errorf("no chaincode response message")
+	}
+
+	response := &protos.ChaincodeResponseMessage{}
+	if err := proto.Unmarshal(responseBytes, response); err != nil {
+		return nil, err
+	}
+
+	event := response.GetChaincodeEvent()
+	if event == nil {
+		return nil, fmt.Errorf("no encrypted event in chaincode response message")
+	}
+	if event.EventName != eventName {
+		return nil, fmt.Errorf("event name mismatch: expected=%s, actual=%s", eventName, event.EventName)
+	}
+
+	enclaveVk, err := d.GetEnclaveVk(response.EnclaveId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get enclave_vk for enclave %s", response.EnclaveId)
+	}
+
+	if err := d.CSP.VerifyMessage(enclaveVk, responseBytes, signedResponse.GetSignature()); err != nil {
+		return nil, errors.Wrap(err, "enclave signature verification failed")
+	}
+
+	clearPayload, err := d.CSP.DecryptMessage(d.EventKey, event.EncryptedPayload)
+	if err != nil {
+		return nil, errors.Wrap(err, "decryption of event failed")
+	}
+
+	return clearPayload, nil
+}
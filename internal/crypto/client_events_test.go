@@ -0,0 +1,99 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignedEvent(t *testing.T, signingKey, eventKey []byte, name string, payload []byte) []byte {
+	csp := GetDefaultCSP()
+	encryptedPayload, err := csp.EncryptMessage(eventKey, payload)
+	assert.NoError(t, err)
+
+	responseBytes := protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{
+		EnclaveId:      "someEnclave",
+		ChaincodeEvent: &protos.EncryptedChaincodeEvent{EventName: name, EncryptedPayload: encryptedPayload},
+	})
+	signature, err := csp.SignMessage(signingKey, responseBytes)
+	assert.NoError(t, err)
+
+	return protoutil.MarshalOrPanic(&protos.SignedChaincodeResponseMessage{
+		ChaincodeResponseMessage: responseBytes,
+		Signature:                signature,
+	})
+}
+
+func TestEventDecrypter(t *testing.T) {
+	csp := GetDefaultCSP()
+	enclaveVk, enclaveSk, err := csp.NewECDSAKeys()
+	assert.NoError(t, err)
+	eventKey, err := csp.NewSymmetricKey()
+	assert.NoError(t, err)
+
+	d := &EventDecrypter{
+		CSP:      csp,
+		EventKey: eventKey,
+		GetEnclaveVk: func(enclaveId string) ([]byte, error) {
+			assert.Equal(t, "someEnclave", enclaveId)
+			return enclaveVk, nil
+		},
+	}
+
+	// success
+	msg := []byte("some event")
+	payload, err := d.Decrypt("someEvent", newSignedEvent(t, enclaveSk, eventKey, "someEvent", msg))
+	assert.NoError(t, err)
+	assert.Equal(t, msg, payload)
+
+	// invalid payloads
+	payload, err = d.Decrypt("someEvent", []byte("not a SignedChaincodeResponseMessage"))
+	assert.Nil(t, payload)
+	assert.Error(t, err)
+
+	payload, err = d.Decrypt("someEvent", protoutil.MarshalOrPanic(&protos.SignedChaincodeResponseMessage{}))
+	assert.Nil(t, payload)
+	assert.Error(t, err)
+
+	payload, err = d.Decrypt("someEvent", protoutil.MarshalOrPanic(&protos.SignedChaincodeResponseMessage{
+		ChaincodeResponseMessage: protoutil.MarshalOrPanic(&protos.ChaincodeResponseMessage{EnclaveId: "someEnclave"}),
+	}))
+	assert.Nil(t, payload)
+	assert.EqualError(t, err, "no encrypted event in chaincode response message")
+
+	// event name mismatch
+	payload, err = d.Decrypt("otherEvent", newSignedEvent(t, enclaveSk, eventKey, "someEvent", msg))
+	assert.Nil(t, payload)
+	assert.EqualError(t, err, "event name mismatch: expected=otherEvent, actual=someEvent")
+
+	// signed by another enclave
+	_, otherSk, err := csp.NewECDSAKeys()
+	assert.NoError(t, err)
+	payload, err = d.Decrypt("someEvent", newSignedEvent(t, otherSk, eventKey, "someEvent", msg))
+	assert.Nil(t, payload)
+	assert.Error(t, err)
+
+	// encrypted with another key
+	otherKey, err := csp.NewSymmetricKey()
+	assert.NoError(t, err)
+	payload, err = d.Decrypt("someEvent", newSignedEvent(t, enclaveSk, otherKey, "someEvent", msg))
+	assert.Nil(t, payload)
+	assert.Error(t, err)
+
+	// unknown enclave
+	d.GetEnclaveVk = func(enclaveId string) ([]byte, error) {
+		return nil, fmt.Errorf("not found")
+	}
+	payload, err = d.Decrypt("someEvent", newSignedEvent(t, enclaveSk, eventKey, "someEvent", msg))
+	assert.Nil(t, payload)
+	assert.Error(t, err)
+}
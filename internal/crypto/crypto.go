@@ -18,6 +18,11 @@ type CSP interface {
 	PkEncryptMessage(publicKey []byte, message []byte) ([]byte, error)
 	DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, error)
 	EncryptMessage(key []byte, message []byte) (encryptedMessage []byte, e error)
+	// DecryptMessageWithAAD decrypts a message encrypted with EncryptMessageWithAAD; the decryption fails if the
+	// associated data does not match the associated data used for the encryption
+	DecryptMessageWithAAD(key []byte, encryptedMessage []byte, associatedData []byte) ([]byte, error)
+	// EncryptMessageWithAAD encrypts a message (AES-GCM) and authenticates, but does not encrypt, the associated data
+	EncryptMessageWithAAD(key []byte, message []byte, associatedData []byte) (encryptedMessage []byte, e error)
 }
 
 func GetDefaultCSP() CSP {
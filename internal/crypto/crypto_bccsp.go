@@ -0,0 +1,137 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/utils"
+	"github.com/pkg/errors"
+)
+
+// BCCSPKeyReferenceType is the PEM block type of the private keys returned by BCCSPCrypto.NewECDSAKeys
+const BCCSPKeyReferenceType = "BCCSP KEY REFERENCE"
+
+// BCCSPCrypto implements CSP using a Fabric BCCSP, e.g., a PKCS#11 based BCCSP (see NewPKCS11Crypto), for ECDSA only.
+//
+// ECDSA keys are generated and used by the BCCSP. The private key returned by NewECDSAKeys is not the key material
+// but a (PEM encoded) reference to the key in the BCCSP, i.e., its subject key identifier, which can only be used with
+// SignMessage of the same BCCSP. SignMessage also accepts software keys as created by GoCrypto.
+// As BCCSP offers neither RSA key generation and OAEP encryption nor AES-GCM encryption, these operations are
+// performed by the embedded software CSP; hence, RSA and AES keys are never kept in the BCCSP.
+type BCCSPCrypto struct {
+	CSP
+	bccsp bccsp.BCCSP
+}
+
+// NewBCCSPCrypto returns a CSP which uses the given BCCSP for ECDSA key generation, signing and verification
+func NewBCCSPCrypto(b bccsp.BCCSP) *BCCSPCrypto {
+	return &BCCSPCrypto{
+		CSP:   NewGoCrypto(),
+		bccsp: b,
+	}
+}
+
+func (c *BCCSPCrypto) NewECDSAKeys() (publicKey []byte, privateKey []byte, err error) {
+	// the key size follows the security level of the BCCSP, which must be 256 to use secp256r1 (prime256v1)
+	key, err := c.bccsp.KeyGen(&bccsp.ECDSAP256KeyGenOpts{Temporary: false})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot generate ecdsa key")
+	}
+
+	pub, err := key.PublicKey()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot get public key")
+	}
+	x509encodedPub, err := pub.Bytes()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot serialize public key")
+	}
+
+	publicKey = pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: x509encodedPub,
+	})
+
+	privateKey = pem.EncodeToMemory(&pem.Block{
+		Type:  BCCSPKeyReferenceType,
+		Bytes: key.SKI(),
+	})
+
+	return publicKey, privateKey, nil
+}
+
+func (c *BCCSPCrypto) VerifyMessage(publicKey []byte, message []byte, signature []byte) error {
+	block, _ := pem.Decode(publicKey)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return fmt.Errorf("failed to decode PEM block containing public key, got %v", block)
+	}
+
+	key, err := c.bccsp.KeyImport(block.Bytes, &bccsp.ECDSAPKIXPublicKeyImportOpts{Temporary: true})
+	if err != nil {
+		return errors.Wrap(err, "cannot import public key")
+	}
+
+	// BCCSP only accepts signatures with low S, whereas GoCrypto, PDO and the enclave do not normalize S
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return errors.Wrap(err, "cannot parse public key")
+	}
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("not an ecdsa public key")
+	}
+	signature, err = utils.SignatureToLowS(ecdsaPub, signature)
+	if err != nil {
+		return errors.Wrap(err, "invalid signature")
+	}
+
+	hash, err := c.bccsp.Hash(message, &bccsp.SHA256Opts{})
+	if err != nil {
+		return err
+	}
+
+	valid, err := c.bccsp.Verify(key, signature, hash, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify signature")
+	}
+	if !valid {
+		return fmt.Errorf("failed to verify signature")
+	}
+	return nil
+}
+
+func (c *BCCSPCrypto) SignMessage(privateKey []byte, message []byte) (signature []byte, e error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+	if block.Type != BCCSPKeyReferenceType {
+		// not a key of the BCCSP
+		return c.CSP.SignMessage(privateKey, message)
+	}
+
+	key, err := c.bccsp.GetKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get private key")
+	}
+	if !key.Private() {
+		return nil, fmt.Errorf("not a private key")
+	}
+
+	hash, err := c.bccsp.Hash(message, &bccsp.SHA256Opts{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the signature is ASN.1 DER encoded as with GoCrypto
+	return c.bccsp.Sign(key, hash, nil)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBCCSPCrypto(t *testing.T) {
+	path, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	ks, err := sw.NewFileBasedKeyStore(nil, path, false)
+	require.NoError(t, err)
+	b, err := sw.NewDefaultSecurityLevelWithKeystore(ks)
+	require.NoError(t, err)
+
+	testBCCSPCrypto(t, NewBCCSPCrypto(b))
+}
+
+// testBCCSPCrypto checks that a BCCSPCrypto keeps the private keys and is compatible with GoCrypto
+func testBCCSPCrypto(t *testing.T, csp *BCCSPCrypto) {
+	msg := []byte("some message")
+	goCrypto := NewGoCrypto()
+
+	pubKey, privKey, err := csp.NewECDSAKeys()
+	require.NoError(t, err)
+
+	// the private key is only a reference
+	block, _ := pem.Decode(privKey)
+	require.NotNil(t, block)
+	assert.Equal(t, BCCSPKeyReferenceType, block.Type)
+
+	sig, err := csp.SignMessage(privKey, msg)
+	assert.NoError(t, err)
+	assert.NoError(t, csp.VerifyMessage(pubKey, msg, sig))
+	assert.NoError(t, goCrypto.VerifyMessage(pubKey, msg, sig))
+	assert.Error(t, csp.VerifyMessage(pubKey, []byte("invalid msg"), sig))
+	assert.Error(t, csp.VerifyMessage(pubKey, msg, []byte("invalid sig")))
+	assert.Error(t, csp.VerifyMessage([]byte("invalid key"), msg, sig))
+
+	// a software key can still be used
+	goPubKey, goPrivKey, err := goCrypto.NewECDSAKeys()
+	require.NoError(t, err)
+	sig, err = csp.SignMessage(goPrivKey, msg)
+	assert.NoError(t, err)
+	assert.NoError(t, csp.VerifyMessage(goPubKey, msg, sig))
+	sig, err = goCrypto.SignMessage(goPrivKey, msg)
+	assert.NoError(t, err)
+	assert.NoError(t, csp.VerifyMessage(goPubKey, msg, sig))
+
+	// invalid key references
+	_, err = csp.SignMessage([]byte("invalid key"), msg)
+	assert.Error(t, err)
+	_, err = csp.SignMessage(pem.EncodeToMemory(&pem.Block{Type: BCCSPKeyReferenceType, Bytes: []byte("unknown")}), msg)
+	assert.Error(t, err)
+
+	// the other operations are performed in software
+	key, err := csp.NewSymmetricKey()
+	require.NoError(t, err)
+	cipher, err := csp.EncryptMessage(key, msg)
+	assert.NoError(t, err)
+	plain, err := goCrypto.DecryptMessage(key, cipher)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+}
@@ -199,6 +199,10 @@ func (g GoCrypto) PkEncryptMessage(publicKey []byte, message []byte) ([]byte, er
 }
 
 func (g GoCrypto) DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, error) {
+	return g.DecryptMessageWithAAD(key, encryptedMessage, nil)
+}
+
+func (g GoCrypto) DecryptMessageWithAAD(key []byte, encryptedMessage []byte, associatedData []byte) ([]byte, error) {
 
 	if len(encryptedMessage) <= NonceLength+TagLength {
 		return nil, fmt.Errorf("encrypted message to small. expect len to be larger than %d, actual %d", NonceLength+TagLength, len(encryptedMessage))
@@ -210,8 +214,10 @@ func (g GoCrypto) DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, e
 	tag := encryptedMessage[NonceLength : NonceLength+TagLength]
 	ciphertext := encryptedMessage[NonceLength+TagLength:]
 
-	// append tag to ciphertext
-	aesgcmCiphertext := ciphertext
+	// append tag to ciphertext; note that we copy the ciphertext, as appending to it could overwrite the encrypted
+	// message if the caller passes a sub-slice
+	aesgcmCiphertext := make([]byte, 0, len(ciphertext)+TagLength)
+	aesgcmCiphertext = append(aesgcmCiphertext, ciphertext...)
 	aesgcmCiphertext = append(aesgcmCiphertext, tag...)
 
 	block, err := aes.NewCipher(key)
@@ -224,7 +230,7 @@ func (g GoCrypto) DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, e
 		return nil, err
 	}
 
-	plaintext, err := aesgcm.Open(nil, nonce, aesgcmCiphertext, nil)
+	plaintext, err := aesgcm.Open(nil, nonce, aesgcmCiphertext, associatedData)
 	if err != nil {
 		return nil, err
 	}
@@ -233,6 +239,10 @@ func (g GoCrypto) DecryptMessage(key []byte, encryptedMessage []byte) ([]byte, e
 }
 
 func (g GoCrypto) EncryptMessage(key []byte, message []byte) (encryptedMessage []byte, err error) {
+	return g.EncryptMessageWithAAD(key, message, nil)
+}
+
+func (g GoCrypto) EncryptMessageWithAAD(key []byte, message []byte, associatedData []byte) (encryptedMessage []byte, err error) {
 
 	// generate nonce (IV)
 	nonce := make([]byte, NonceLength)
@@ -251,7 +261,7 @@ func (g GoCrypto) EncryptMessage(key []byte, message []byte) (encryptedMessage [
 		return nil, err
 	}
 
-	aesgcmCiphertext := aesgcm.Seal(nil, nonce, message, nil)
+	aesgcmCiphertext := aesgcm.Seal(nil, nonce, message, associatedData)
 
 	// Note that Seal appends the authentication tag to the cipertext, whereas PDO crypto prepends the tag
 	ciphertext, tag := aesgcmCiphertext[:len(aesgcmCiphertext)-TagLength], aesgcmCiphertext[len(aesgcmCiphertext)-TagLength:]
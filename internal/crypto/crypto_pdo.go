@@ -289,3 +289,78 @@ func (c PDOCrypto) EncryptMessage(key []byte, message []byte) (encryptedMessage
 
 	return C.GoBytes(encryptedMessagePtr, C.int(encryptedMessageActualLen)), nil
 }
+
+// DecryptMessageWithAAD is a symmetric-key decryption performed with the crypto adapt library
+// that additionally checks the associated data
+func (c PDOCrypto) DecryptMessageWithAAD(key []byte, encryptedMessage []byte, associatedData []byte) ([]byte, error) {
+
+	encryptedMessagePtr := C.CBytes(encryptedMessage)
+	defer C.free(encryptedMessagePtr)
+
+	keyPtr := C.CBytes(key)
+	defer C.free(keyPtr)
+
+	associatedDataPtr := C.CBytes(associatedData)
+	defer C.free(associatedDataPtr)
+
+	// the (decrypted) message size is estimated to be <= the encrypted message size
+	messageSize := len(encryptedMessage)
+	messagePtr := C.malloc(C.ulong(messageSize))
+	defer C.free(messagePtr)
+
+	messageActualSize := C.uint32_t(0)
+
+	ret := C.decrypt_message_with_aad(
+		(*C.uint8_t)(keyPtr),
+		C.uint32_t(len(key)),
+		(*C.uint8_t)(encryptedMessagePtr),
+		C.uint32_t(len(encryptedMessage)),
+		(*C.uint8_t)(associatedDataPtr),
+		C.uint32_t(len(associatedData)),
+		(*C.uint8_t)(messagePtr),
+		C.uint32_t(messageSize),
+		&messageActualSize)
+	if ret == false {
+		return nil, fmt.Errorf("decryption failed")
+	}
+
+	return C.GoBytes(messagePtr, C.int(messageActualSize)), nil
+}
+
+// EncryptMessageWithAAD is a symmetric-key encryption performed with the crypto adapt library
+// that additionally authenticates the associated data
+func (c PDOCrypto) EncryptMessageWithAAD(key []byte, message []byte, associatedData []byte) (encryptedMessage []byte, e error) {
+
+	keyPtr := C.CBytes(key)
+	defer C.free(keyPtr)
+
+	messagePtr := C.CBytes(message)
+	defer C.free(messagePtr)
+
+	associatedDataPtr := C.CBytes(associatedData)
+	defer C.free(associatedDataPtr)
+
+	// the encrypted message includes the iv and tag
+	encryptedMessageLen := C.uint32_t(len(message)) + C.IV_LEN + C.TAG_LEN
+	encryptedMessagePtr := C.malloc(C.ulong(encryptedMessageLen))
+	defer C.free(encryptedMessagePtr)
+
+	encryptedMessageActualLen := C.uint32_t(0)
+
+	ret := C.encrypt_message_with_aad(
+		(*C.uint8_t)(keyPtr),
+		(C.uint32_t)(len(key)),
+		(*C.uint8_t)(messagePtr),
+		(C.uint32_t)(len(message)),
+		(*C.uint8_t)(associatedDataPtr),
+		(C.uint32_t)(len(associatedData)),
+		(*C.uint8_t)(encryptedMessagePtr),
+		encryptedMessageLen,
+		&encryptedMessageActualLen,
+	)
+	if ret == false {
+		return nil, fmt.Errorf("encryption failed")
+	}
+
+	return C.GoBytes(encryptedMessagePtr, C.int(encryptedMessageActualLen)), nil
+}
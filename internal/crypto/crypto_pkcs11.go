@@ -0,0 +1,39 @@
+// +build pkcs11
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"github.com/hyperledger/fabric/bccsp/pkcs11"
+	"github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/pkg/errors"
+)
+
+// PKCS11Opts configures the PKCS#11 token used by NewPKCS11Crypto, i.e., the path of the PKCS#11 library, and the label
+// and the pin of the token
+type PKCS11Opts = pkcs11.PKCS11Opts
+
+// NewPKCS11Crypto returns a CSP whose ECDSA keys are generated and used by a PKCS#11 token, e.g., a HSM or SoftHSM.
+// The private ECDSA keys never leave the token; RSA and AES keys are software keys (see BCCSPCrypto).
+func NewPKCS11Crypto(opts PKCS11Opts) (*BCCSPCrypto, error) {
+	// FPC uses secp256r1 (prime256v1) and sha256
+	if opts.Security == 0 {
+		opts.Security = 256
+	}
+	if len(opts.Hash) == 0 {
+		opts.Hash = "SHA2"
+	}
+
+	// keys are kept in the token only; the software fallback of the pkcs11 BCCSP does not store any key
+	p, err := pkcs11.New(opts, sw.NewDummyKeyStore())
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot initialize pkcs11")
+	}
+
+	return NewBCCSPCrypto(p), nil
+}
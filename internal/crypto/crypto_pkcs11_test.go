@@ -0,0 +1,35 @@
+// +build pkcs11
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestPKCS11Crypto requires a PKCS#11 token, e.g., SoftHSM:
+//  softhsm2-util --init-token --slot 0 --label ForFabric --so-pin 1234 --pin 98765432
+//  PKCS11_LIB=/usr/lib/softhsm/libsofthsm2.so PKCS11_PIN=98765432 PKCS11_LABEL=ForFabric go test -tags pkcs11
+func TestPKCS11Crypto(t *testing.T) {
+	lib := os.Getenv("PKCS11_LIB")
+	if len(lib) == 0 {
+		t.Skip("PKCS11_LIB not set")
+	}
+
+	csp, err := NewPKCS11Crypto(PKCS11Opts{
+		Library: lib,
+		Pin:     os.Getenv("PKCS11_PIN"),
+		Label:   os.Getenv("PKCS11_LABEL"),
+	})
+	require.NoError(t, err)
+
+	testBCCSPCrypto(t, csp)
+}
@@ -169,3 +169,61 @@ func TestSymEncryption(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+func TestSymEncryptionWithAAD(t *testing.T) {
+	msg := []byte("some message")
+	ad := []byte("some associated data")
+
+	for _, tc := range allTestCases {
+		key, err := tc.CSP.NewSymmetricKey()
+		assert.NoError(t, err)
+
+		cipher, err := tc.CSP.EncryptMessageWithAAD([]byte("invalid key"), msg, ad)
+		assert.Nil(t, cipher)
+		assert.Error(t, err)
+
+		// should succeed
+		cipher, err = tc.CSP.EncryptMessageWithAAD(key, msg, ad)
+		assert.NotNil(t, cipher)
+		assert.NoError(t, err)
+
+		// associated data does not match
+		plain, err := tc.CSP.DecryptMessageWithAAD(key, cipher, []byte("other associated data"))
+		assert.Nil(t, plain)
+		assert.Error(t, err)
+
+		plain, err = tc.CSP.DecryptMessage(key, cipher)
+		assert.Nil(t, plain)
+		assert.Error(t, err)
+
+		// should succeed
+		plain, err = tc.CSP.DecryptMessageWithAAD(key, cipher, ad)
+		assert.Equal(t, msg, plain)
+		assert.NoError(t, err)
+
+		// without associated data, the format is the same as with EncryptMessage
+		cipher, err = tc.CSP.EncryptMessageWithAAD(key, msg, nil)
+		assert.NoError(t, err)
+		plain, err = tc.CSP.DecryptMessage(key, cipher)
+		assert.Equal(t, msg, plain)
+		assert.NoError(t, err)
+	}
+}
+
+func TestSymDecryptionKeepsInput(t *testing.T) {
+	msg := []byte("some message")
+
+	for _, tc := range allTestCases {
+		key, err := tc.CSP.NewSymmetricKey()
+		assert.NoError(t, err)
+		cipher, err := tc.CSP.EncryptMessage(key, msg)
+		assert.NoError(t, err)
+
+		// decrypting a truncated cipher must not modify the cipher
+		_, err = tc.CSP.DecryptMessage(key, cipher[:len(cipher)-1])
+		assert.Error(t, err)
+		plain, err := tc.CSP.DecryptMessage(key, cipher)
+		assert.NoError(t, err)
+		assert.Equal(t, msg, plain)
+	}
+}
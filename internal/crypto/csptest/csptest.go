@@ -0,0 +1,281 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package csptest provides a conformance test suite for implementations of crypto.CSP.
+//
+// A CSP used by FPC clients and peers must be compatible with the crypto of the chaincode enclave, i.e., its keys,
+// signatures and ciphertexts must be accepted by the other CSPs and vice versa. Run checks the individual operations,
+// the key formats and the behavior on invalid input, decrypts and verifies fixed test vectors, and checks the
+// interoperability with reference CSPs such as GoCrypto.
+package csptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	msg = []byte("some message")
+	ad  = []byte("some associated data")
+)
+
+// Run runs the conformance test suite against csp. Besides the tests of csp itself, the keys, signatures and
+// ciphertexts of csp are used with each of the references, and vice versa.
+func Run(t *testing.T, csp crypto.CSP, references ...crypto.CSP) {
+	t.Run("KeyFormats", func(t *testing.T) { testKeyFormats(t, csp) })
+	t.Run("Signature", func(t *testing.T) { testSignature(t, csp, csp) })
+	t.Run("PkEncryption", func(t *testing.T) { testPkEncryption(t, csp, csp) })
+	t.Run("SymEncryption", func(t *testing.T) { testSymEncryption(t, csp, csp) })
+	t.Run("SymEncryptionWithAAD", func(t *testing.T) { testSymEncryptionWithAAD(t, csp, csp) })
+	t.Run("TestVectors", func(t *testing.T) { testVectors(t, csp) })
+
+	for _, reference := range references {
+		for _, tc := range []struct {
+			name     string
+			producer crypto.CSP
+			consumer crypto.CSP
+		}{
+			{fmt.Sprintf("%T->%T", csp, reference), csp, reference},
+			{fmt.Sprintf("%T->%T", reference, csp), reference, csp},
+		} {
+			producer, consumer := tc.producer, tc.consumer
+			t.Run(tc.name, func(t *testing.T) {
+				t.Run("Signature", func(t *testing.T) { testSignature(t, producer, consumer) })
+				t.Run("PkEncryption", func(t *testing.T) { testPkEncryption(t, producer, consumer) })
+				t.Run("SymEncryption", func(t *testing.T) { testSymEncryption(t, producer, consumer) })
+				t.Run("SymEncryptionWithAAD", func(t *testing.T) { testSymEncryptionWithAAD(t, producer, consumer) })
+			})
+		}
+	}
+}
+
+// testKeyFormats checks the encoding of the public keys, which are shared with the enclave and other parties.
+// The encoding of the private keys is up to the CSP, e.g., a HSM returns a reference to the key only.
+func testKeyFormats(t *testing.T, csp crypto.CSP) {
+	// RSA public keys are PKCS#1 encoded
+	pubKey, privKey, err := csp.NewRSAKeys()
+	require.NoError(t, err)
+	assert.NotEmpty(t, privKey)
+	block := decodePEM(t, pubKey, "RSA PUBLIC KEY")
+	rsaPubKey, err := x509.ParsePKCS1PublicKey(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.RSAKeyLength, rsaPubKey.N.BitLen())
+
+	// ECDSA public keys are PKIX encoded keys on secp256r1 (prime256v1)
+	pubKey, privKey, err = csp.NewECDSAKeys()
+	require.NoError(t, err)
+	assert.NotEmpty(t, privKey)
+	block = decodePEM(t, pubKey, "PUBLIC KEY")
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	require.NoError(t, err)
+	require.IsType(t, &ecdsa.PublicKey{}, key)
+	assert.Equal(t, elliptic.P256(), key.(*ecdsa.PublicKey).Curve)
+
+	// the request and response encryption keys are AES-128 keys
+	symKey, err := csp.NewSymmetricKey()
+	require.NoError(t, err)
+	assert.Len(t, symKey, crypto.SymKeyLength)
+	otherSymKey, err := csp.NewSymmetricKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, symKey, otherSymKey)
+}
+
+func testSignature(t *testing.T, producer, consumer crypto.CSP) {
+	pubKey, privKey, err := producer.NewECDSAKeys()
+	require.NoError(t, err)
+
+	_, err = producer.SignMessage([]byte("invalid key"), msg)
+	assert.Error(t, err)
+
+	// should succeed
+	sig, err := producer.SignMessage(privKey, msg)
+	require.NoError(t, err)
+	assert.NotEmpty(t, sig)
+
+	assert.Error(t, consumer.VerifyMessage([]byte("invalid key"), msg, sig))
+	assert.Error(t, consumer.VerifyMessage(pubKey, []byte("invalid msg"), sig))
+	assert.Error(t, consumer.VerifyMessage(pubKey, msg, []byte("invalid sig")))
+	assert.Error(t, consumer.VerifyMessage(pubKey, msg, nil))
+	for _, tampered := range tamper(sig) {
+		assert.Error(t, consumer.VerifyMessage(pubKey, msg, tampered))
+	}
+
+	otherPubKey, _, err := consumer.NewECDSAKeys()
+	require.NoError(t, err)
+	assert.Error(t, consumer.VerifyMessage(otherPubKey, msg, sig))
+
+	// should succeed
+	assert.NoError(t, consumer.VerifyMessage(pubKey, msg, sig))
+}
+
+func testPkEncryption(t *testing.T, producer, consumer crypto.CSP) {
+	// the consumer creates the key pair, as the enclave does for its chaincode encryption key
+	pubKey, privKey, err := consumer.NewRSAKeys()
+	require.NoError(t, err)
+
+	cipher, err := producer.PkEncryptMessage([]byte("invalid key"), msg)
+	assert.Nil(t, cipher)
+	assert.Error(t, err)
+
+	// should succeed
+	cipher, err = producer.PkEncryptMessage(pubKey, msg)
+	require.NoError(t, err)
+	assert.Len(t, cipher, crypto.RSAKeyLength/8)
+
+	// the encryption is randomized
+	otherCipher, err := producer.PkEncryptMessage(pubKey, msg)
+	require.NoError(t, err)
+	assert.NotEqual(t, cipher, otherCipher)
+
+	plain, err := consumer.PkDecryptMessage([]byte("invalid key"), cipher)
+	assert.Nil(t, plain)
+	assert.Error(t, err)
+
+	_, otherPrivKey, err := consumer.NewRSAKeys()
+	require.NoError(t, err)
+	plain, err = consumer.PkDecryptMessage(otherPrivKey, cipher)
+	assert.Nil(t, plain)
+	assert.Error(t, err)
+
+	for _, tampered := range tamper(cipher) {
+		plain, err = consumer.PkDecryptMessage(privKey, tampered)
+		assert.Nil(t, plain)
+		assert.Error(t, err)
+	}
+
+	// should succeed
+	plain, err = consumer.PkDecryptMessage(privKey, cipher)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+}
+
+func testSymEncryption(t *testing.T, producer, consumer crypto.CSP) {
+	key, err := producer.NewSymmetricKey()
+	require.NoError(t, err)
+
+	cipher, err := producer.EncryptMessage([]byte("invalid key"), msg)
+	assert.Nil(t, cipher)
+	assert.Error(t, err)
+
+	// should succeed
+	cipher, err = producer.EncryptMessage(key, msg)
+	require.NoError(t, err)
+	assert.Len(t, cipher, crypto.NonceLength+crypto.TagLength+len(msg))
+
+	plain, err := consumer.DecryptMessage([]byte("invalid key"), cipher)
+	assert.Nil(t, plain)
+	assert.Error(t, err)
+
+	otherKey, err := consumer.NewSymmetricKey()
+	require.NoError(t, err)
+	plain, err = consumer.DecryptMessage(otherKey, cipher)
+	assert.Nil(t, plain)
+	assert.Error(t, err)
+
+	for _, tampered := range tamper(cipher) {
+		plain, err = consumer.DecryptMessage(key, tampered)
+		assert.Nil(t, plain)
+		assert.Error(t, err)
+	}
+
+	// should succeed
+	plain, err = consumer.DecryptMessage(key, cipher)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+}
+
+func testSymEncryptionWithAAD(t *testing.T, producer, consumer crypto.CSP) {
+	key, err := producer.NewSymmetricKey()
+	require.NoError(t, err)
+
+	cipher, err := producer.EncryptMessageWithAAD([]byte("invalid key"), msg, ad)
+	assert.Nil(t, cipher)
+	assert.Error(t, err)
+
+	// should succeed
+	cipher, err = producer.EncryptMessageWithAAD(key, msg, ad)
+	require.NoError(t, err)
+
+	// the associated data does not match
+	plain, err := consumer.DecryptMessageWithAAD(key, cipher, []byte("other associated data"))
+	assert.Nil(t, plain)
+	assert.Error(t, err)
+	plain, err = consumer.DecryptMessage(key, cipher)
+	assert.Nil(t, plain)
+	assert.Error(t, err)
+
+	for _, tampered := range tamper(cipher) {
+		plain, err = consumer.DecryptMessageWithAAD(key, tampered, ad)
+		assert.Nil(t, plain)
+		assert.Error(t, err)
+	}
+
+	// should succeed
+	plain, err = consumer.DecryptMessageWithAAD(key, cipher, ad)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+
+	// without associated data, the format is the same as with EncryptMessage
+	cipher, err = producer.EncryptMessageWithAAD(key, msg, nil)
+	require.NoError(t, err)
+	plain, err = consumer.DecryptMessage(key, cipher)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, plain)
+}
+
+// testVectors checks that the CSP verifies and decrypts the fixed test vectors
+func testVectors(t *testing.T, csp crypto.CSP) {
+	message := []byte(vectorMessage)
+
+	assert.NoError(t, csp.VerifyMessage([]byte(vectorECDSAPublicKey), message, decodeHex(t, vectorECDSASignature)))
+	assert.Error(t, csp.VerifyMessage([]byte(vectorECDSAPublicKey), []byte("invalid msg"), decodeHex(t, vectorECDSASignature)))
+
+	plain, err := csp.PkDecryptMessage([]byte(vectorRSAPrivateKey), decodeHex(t, vectorRSACiphertext))
+	assert.NoError(t, err)
+	assert.Equal(t, message, plain)
+
+	key := decodeHex(t, vectorSymmetricKey)
+	plain, err = csp.DecryptMessage(key, decodeHex(t, vectorSymCiphertext))
+	assert.NoError(t, err)
+	assert.Equal(t, message, plain)
+
+	plain, err = csp.DecryptMessageWithAAD(key, decodeHex(t, vectorSymCiphertextWithAAD), []byte(vectorAssociatedData))
+	assert.NoError(t, err)
+	assert.Equal(t, message, plain)
+}
+
+// tamper returns copies of data with a bit flipped at the first, the middle and the last byte, and truncated copies
+func tamper(data []byte) [][]byte {
+	var tampered [][]byte
+	for _, i := range []int{0, len(data) / 2, len(data) - 1} {
+		c := append([]byte{}, data...)
+		c[i] ^= 1
+		tampered = append(tampered, c)
+	}
+	return append(tampered, data[:len(data)-1], data[:len(data)/2], data[:0])
+}
+
+func decodePEM(t *testing.T, key []byte, blockType string) *pem.Block {
+	block, _ := pem.Decode(key)
+	require.NotNil(t, block, "not PEM encoded")
+	require.Equal(t, blockType, block.Type)
+	return block
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
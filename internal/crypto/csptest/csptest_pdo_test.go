@@ -0,0 +1,20 @@
+// +build WITH_PDO_CRYPTO
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package csptest
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+)
+
+// TestPDOCrypto checks the PDO crypto lib, which is also used by the enclave, against GoCrypto
+func TestPDOCrypto(t *testing.T) {
+	Run(t, crypto.NewPdoCrypto(), crypto.NewGoCrypto())
+}
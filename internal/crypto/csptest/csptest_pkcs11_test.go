@@ -0,0 +1,34 @@
+// +build pkcs11
+
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package csptest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPKCS11Crypto requires a PKCS#11 token (see TestPKCS11Crypto in the crypto package)
+func TestPKCS11Crypto(t *testing.T) {
+	lib := os.Getenv("PKCS11_LIB")
+	if len(lib) == 0 {
+		t.Skip("PKCS11_LIB not set")
+	}
+
+	csp, err := crypto.NewPKCS11Crypto(crypto.PKCS11Opts{
+		Library: lib,
+		Pin:     os.Getenv("PKCS11_PIN"),
+		Label:   os.Getenv("PKCS11_LABEL"),
+	})
+	require.NoError(t, err)
+
+	Run(t, csp, crypto.NewGoCrypto())
+}
@@ -0,0 +1,34 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package csptest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoCrypto(t *testing.T) {
+	Run(t, crypto.NewGoCrypto())
+}
+
+func TestBCCSPCrypto(t *testing.T) {
+	path, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	ks, err := sw.NewFileBasedKeyStore(nil, path, false)
+	require.NoError(t, err)
+	b, err := sw.NewDefaultSecurityLevelWithKeystore(ks)
+	require.NoError(t, err)
+
+	Run(t, crypto.NewBCCSPCrypto(b), crypto.NewGoCrypto())
+}
@@ -0,0 +1,157 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"encoding/base64"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CachingEncryptionProvider is an EncryptionProvider that caches the chaincode encryption key.
+// Unlike EncryptionProviderImpl, which fetches the key from ERCC for every new encryption context, the key is only
+// re-fetched once the TTL expires, the chaincode sequence changes, or the cache has been invalidated explicitly.
+// The cache is also invalidated when an encryption context created by this provider fails to reveal a response.
+// Concurrent callers that find no valid key share a single fetch, which is performed without holding the cache lock.
+type CachingEncryptionProvider struct {
+	// EncryptionProviderImpl creates the encryption contexts; its GetCcEncryptionKey fetches the (base64-encoded)
+	// chaincode encryption key whenever the cached key is absent or stale
+	EncryptionProviderImpl
+
+	// TTL defines how long a fetched chaincode encryption key is used; a TTL <= 0 keeps the key until invalidated
+	TTL time.Duration
+
+	fetch singleflight.Group
+
+	mutex      sync.Mutex
+	key        []byte
+	expiry     time.Time
+	sequence   int64
+	generation uint64
+	now        func() time.Time
+}
+
+// NewCachingEncryptionProvider returns a CachingEncryptionProvider that uses getCcEncryptionKey to fetch the
+// (base64-encoded) chaincode encryption key whenever the cached key is absent or stale.
+func NewCachingEncryptionProvider(csp CSP, ttl time.Duration, getCcEncryptionKey func() ([]byte, error)) *CachingEncryptionProvider {
+	return &CachingEncryptionProvider{
+		EncryptionProviderImpl: EncryptionProviderImpl{
+			CSP:                csp,
+			GetCcEncryptionKey: getCcEncryptionKey,
+		},
+		TTL: ttl,
+	}
+}
+
+func (p *CachingEncryptionProvider) NewEncryptionContext() (EncryptionContext, error) {
+	provider := p.EncryptionProviderImpl
+	provider.GetCcEncryptionKey = p.getCcEncryptionKey
+
+	ctx, err := provider.newEncryptionContext()
+	if err != nil {
+		return nil, err
+	}
+	return &cachedEncryptionContext{EncryptionContextImpl: ctx, provider: p}, nil
+}
+
+// ObserveSequence notifies the provider about the current chaincode sequence number.
+// If the sequence differs from the last observed one, the cached chaincode encryption key is dropped.
+func (p *CachingEncryptionProvider) ObserveSequence(sequence int64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.sequence != sequence {
+		logger.Debugf("chaincode sequence changed from %d to %d; invalidating cached chaincode encryption key", p.sequence, sequence)
+		p.sequence = sequence
+		p.invalidate()
+	}
+}
+
+// Invalidate drops the cached chaincode encryption key so that the next encryption context fetches it again.
+func (p *CachingEncryptionProvider) Invalidate() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.invalidate()
+}
+
+// invalidate drops the cached key; a key that is currently being fetched is not cached either. The caller must hold
+// the mutex.
+func (p *CachingEncryptionProvider) invalidate() {
+	p.key = nil
+	p.generation++
+}
+
+// getCcEncryptionKey returns the cached (base64-encoded) chaincode encryption key or fetches it
+func (p *CachingEncryptionProvider) getCcEncryptionKey() ([]byte, error) {
+	p.mutex.Lock()
+	if p.key != nil && (p.TTL <= 0 || p.clock().Before(p.expiry)) {
+		key := p.key
+		p.mutex.Unlock()
+		return key, nil
+	}
+	generation := p.generation
+	p.mutex.Unlock()
+
+	// callers share a fetch unless the cache has been invalidated after the fetch started
+	key, err, _ := p.fetch.Do(strconv.FormatUint(generation, 10), func() (interface{}, error) {
+		ccEncryptionKey, err := p.GetCcEncryptionKey()
+		if err != nil {
+			return nil, err
+		}
+		// do not cache a key which cannot be decoded
+		if _, err := base64.StdEncoding.DecodeString(string(ccEncryptionKey)); err != nil {
+			return nil, err
+		}
+
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		if p.generation == generation {
+			p.key = ccEncryptionKey
+			p.expiry = p.clock().Add(p.TTL)
+		}
+		return ccEncryptionKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key.([]byte), nil
+}
+
+func (p *CachingEncryptionProvider) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+// cachedEncryptionContext invalidates the cache of its provider if a response cannot be revealed
+type cachedEncryptionContext struct {
+	*EncryptionContextImpl
+	provider *CachingEncryptionProvider
+}
+
+func (e *cachedEncryptionContext) Reveal(signedResponseBytesB64 []byte) ([]byte, error) {
+	clearResponse, err := e.EncryptionContextImpl.Reveal(signedResponseBytesB64)
+	if err != nil {
+		e.provider.Invalidate()
+		return nil, err
+	}
+	return clearResponse, nil
+}
+
+func (e *cachedEncryptionContext) RevealBatch(signedResponseBytesB64 []byte) ([][]byte, error) {
+	clearResponses, err := e.EncryptionContextImpl.RevealBatch(signedResponseBytesB64)
+	if err != nil {
+		e.provider.Invalidate()
+		return nil, err
+	}
+	return clearResponses, nil
+}
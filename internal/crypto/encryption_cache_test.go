@@ -0,0 +1,135 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachingEncryptionProvider(t *testing.T) {
+	pubKey, _, err := GetDefaultCSP().NewRSAKeys()
+	assert.NoError(t, err)
+
+	calls := 0
+	provider := NewCachingEncryptionProvider(GetDefaultCSP(), time.Minute, func() ([]byte, error) {
+		calls++
+		return []byte(base64.StdEncoding.EncodeToString(pubKey)), nil
+	})
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	// first context fetches the key, second one uses the cache
+	for i := 0; i < 2; i++ {
+		ctx, err := provider.NewEncryptionContext()
+		assert.NotNil(t, ctx)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, calls)
+
+	// expired key is fetched again
+	now = now.Add(2 * time.Minute)
+	_, err = provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	// sequence change drops the key, same sequence keeps it
+	provider.ObserveSequence(2)
+	_, err = provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	provider.ObserveSequence(2)
+	_, err = provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// failing reveal drops the key
+	ctx, err := provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	resp, err := ctx.Reveal([]byte("invalid input (not base64)"))
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+	_, err = provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, calls)
+
+	// explicit invalidation
+	provider.Invalidate()
+	_, err = provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, calls)
+}
+
+func TestCachingEncryptionProviderFail(t *testing.T) {
+	provider := NewCachingEncryptionProvider(GetDefaultCSP(), 0, func() ([]byte, error) {
+		return nil, fmt.Errorf("some error while fetching key")
+	})
+	ctx, err := provider.NewEncryptionContext()
+	assert.Nil(t, ctx)
+	assert.EqualError(t, err, "failed to get chaincode encryption key from ercc: some error while fetching key")
+
+	provider.GetCcEncryptionKey = func() ([]byte, error) {
+		return []byte("some invalid base64 encoded key"), nil
+	}
+	ctx, err = provider.NewEncryptionContext()
+	assert.Nil(t, ctx)
+	assert.Error(t, err)
+
+	// errors are not cached
+	provider.GetCcEncryptionKey = func() ([]byte, error) {
+		return []byte(base64.StdEncoding.EncodeToString([]byte("some key"))), nil
+	}
+	ctx, err = provider.NewEncryptionContext()
+	assert.NotNil(t, ctx)
+	assert.NoError(t, err)
+}
+
+func TestCachingEncryptionProviderConcurrentFetch(t *testing.T) {
+	pubKey, _, err := GetDefaultCSP().NewRSAKeys()
+	assert.NoError(t, err)
+
+	fetching := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	var calls int32
+	provider := NewCachingEncryptionProvider(GetDefaultCSP(), 0, func() ([]byte, error) {
+		atomic.AddInt32(&calls, 1)
+		once.Do(func() { close(fetching) })
+		<-release
+		return []byte(base64.StdEncoding.EncodeToString(pubKey)), nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctx, err := provider.NewEncryptionContext()
+		assert.NotNil(t, ctx)
+		assert.NoError(t, err)
+	}()
+
+	// the cache is not locked while the key is fetched
+	<-fetching
+	provider.Invalidate()
+	close(release)
+	<-done
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// the key fetched before the invalidation is not cached
+	_, err = provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	_, err = provider.NewEncryptionContext()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+}
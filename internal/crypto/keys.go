@@ -0,0 +1,289 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// KeyFormat is an encoding of a public key
+type KeyFormat int
+
+const (
+	// PEM is the encoding used by the CSPs and the enclave, i.e., a PKCS#1 encoded RSA public key ("RSA PUBLIC KEY"),
+	// or a PKIX encoded ECDSA public key ("PUBLIC KEY")
+	PEM KeyFormat = iota
+	// DER is the PKIX (SubjectPublicKeyInfo) encoding of RSA and ECDSA public keys
+	DER
+	// JWK is the JSON Web Key (RFC 7517) encoding of RSA and ECDSA public keys, with the JWK thumbprint as key id
+	JWK
+)
+
+func (f KeyFormat) String() string {
+	switch f {
+	case PEM:
+		return "PEM"
+	case DER:
+		return "DER"
+	case JWK:
+		return "JWK"
+	default:
+		return fmt.Sprintf("KeyFormat(%d)", int(f))
+	}
+}
+
+// jsonWebKey contains the members of a JWK used for RSA and ECDSA public keys
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// ParsePublicKey parses a PEM, DER or JWK encoded public key, e.g., the enclave_vk or chaincode_ek of AttestedData, and
+// returns an *rsa.PublicKey or *ecdsa.PublicKey. The format is detected automatically.
+func ParsePublicKey(key []byte) (interface{}, error) {
+	return parsePublicKey(key)
+}
+
+// EncodePublicKey converts a PEM, DER or JWK encoded public key into the given format
+func EncodePublicKey(key []byte, format KeyFormat) ([]byte, error) {
+	pub, err := parsePublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case PEM:
+		return encodePEM(pub)
+	case DER:
+		return encodeDER(pub)
+	case JWK:
+		return encodeJWK(pub)
+	default:
+		return nil, fmt.Errorf("unknown key format %v", format)
+	}
+}
+
+// Fingerprint returns the SHA-256 hash of the DER (PKIX) encoding of a PEM, DER or JWK encoded public key as
+// "SHA256:<hex>". As the fingerprint does not depend on the encoding of the key, it can be used to display and pin
+// enclave keys.
+func Fingerprint(key []byte) (string, error) {
+	der, err := EncodePublicKey(key, DER)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(der)
+	return "SHA256:" + hex.EncodeToString(h[:]), nil
+}
+
+// JWKThumbprint returns the base64url encoded JWK thumbprint (RFC 7638) of a PEM, DER or JWK encoded RSA or ECDSA
+// public key
+func JWKThumbprint(key []byte) (string, error) {
+	pub, err := parsePublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	jwk, err := toJSONWebKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return jwkThumbprint(jwk), nil
+}
+
+func parsePublicKey(key []byte) (interface{}, error) {
+	trimmed := bytes.TrimSpace(key)
+	switch {
+	case len(trimmed) == 0:
+		return nil, fmt.Errorf("empty public key")
+	case bytes.HasPrefix(trimmed, []byte("-----BEGIN")):
+		return parsePEM(trimmed)
+	case trimmed[0] == '{':
+		return parseJWK(trimmed)
+	default:
+		return parseDER(key)
+	}
+}
+
+func parsePEM(key []byte) (interface{}, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing public key")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot parse rsa public key")
+		}
+		return pub, nil
+	case "PUBLIC KEY":
+		return parsePKIX(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %s", block.Type)
+	}
+}
+
+func parseDER(key []byte) (interface{}, error) {
+	if pub, err := parsePKIX(key); err == nil {
+		return pub, nil
+	}
+	// RSA public keys of the enclave are PKCS#1 encoded
+	if pub, err := x509.ParsePKCS1PublicKey(key); err == nil {
+		return pub, nil
+	}
+	return nil, fmt.Errorf("cannot parse DER encoded public key")
+}
+
+func parsePKIX(der []byte) (interface{}, error) {
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse public key")
+	}
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+func parseJWK(key []byte) (interface{}, error) {
+	jwk := &jsonWebKey{}
+	if err := json.Unmarshal(key, jwk); err != nil {
+		return nil, errors.Wrap(err, "cannot parse JWK")
+	}
+
+	decode := func(name, value string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("invalid JWK member %s", name)
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch jwk.Kty {
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported JWK curve %s", jwk.Crv)
+		}
+		x, err := decode("x", jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode("y", jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		curve := elliptic.P256()
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid JWK: point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "RSA":
+		n, err := decode("n", jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode("e", jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid JWK member e")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWK key type %s", jwk.Kty)
+	}
+}
+
+func encodePEM(pub interface{}) ([]byte, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(k)}), nil
+	case *ecdsa.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+func encodeDER(pub interface{}) ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(pub)
+}
+
+func encodeJWK(pub interface{}) ([]byte, error) {
+	jwk, err := toJSONWebKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Kid = jwkThumbprint(jwk)
+	return json.Marshal(jwk)
+}
+
+func toJSONWebKey(pub interface{}) (*jsonWebKey, error) {
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return &jsonWebKey{
+			Kty: "RSA",
+			N:   encode(k.N.Bytes()),
+			E:   encode(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("unsupported curve %s", k.Curve.Params().Name)
+		}
+		// the coordinates have the full length of the field size (RFC 7518, Section 6.2.1.2)
+		return &jsonWebKey{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   encode(k.X.FillBytes(make([]byte, 32))),
+			Y:   encode(k.Y.FillBytes(make([]byte, 32))),
+		}, nil
+	default:
+		return nil, fmt.Errorf("cannot encode %T as JWK", pub)
+	}
+}
+
+// jwkThumbprint hashes the required members of the JWK in lexicographic order (RFC 7638, Section 3)
+func jwkThumbprint(jwk *jsonWebKey) string {
+	var canonical string
+	switch jwk.Kty {
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, jwk.Crv, jwk.X, jwk.Y)
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, jwk.E, jwk.N)
+	}
+	h := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
@@ -0,0 +1,129 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyEncoding(t *testing.T) {
+	csp := GetDefaultCSP()
+	rsaKey, _, err := csp.NewRSAKeys()
+	require.NoError(t, err)
+	ecdsaKey, _, err := csp.NewECDSAKeys()
+	require.NoError(t, err)
+
+	for _, key := range [][]byte{rsaKey, ecdsaKey} {
+		fingerprint, err := Fingerprint(key)
+		require.NoError(t, err)
+		assert.Regexp(t, "^SHA256:[0-9a-f]{64}$", fingerprint)
+
+		// the PEM encoding is the one of the CSP
+		pemKey, err := EncodePublicKey(key, PEM)
+		require.NoError(t, err)
+		assert.Equal(t, key, pemKey)
+
+		derKey, err := EncodePublicKey(key, DER)
+		require.NoError(t, err)
+		pemKey, err = EncodePublicKey(derKey, PEM)
+		require.NoError(t, err)
+		assert.Equal(t, key, pemKey)
+
+		// the fingerprint does not depend on the encoding
+		otherFingerprint, err := Fingerprint(derKey)
+		require.NoError(t, err)
+		assert.Equal(t, fingerprint, otherFingerprint)
+
+		jwkKey, err := EncodePublicKey(key, JWK)
+		require.NoError(t, err)
+		pemKey, err = EncodePublicKey(jwkKey, PEM)
+		require.NoError(t, err)
+		assert.Equal(t, key, pemKey)
+		otherFingerprint, err = Fingerprint(jwkKey)
+		require.NoError(t, err)
+		assert.Equal(t, fingerprint, otherFingerprint)
+
+		// the key id is the thumbprint
+		thumbprint, err := JWKThumbprint(key)
+		require.NoError(t, err)
+		jwk := map[string]string{}
+		require.NoError(t, json.Unmarshal(jwkKey, &jwk))
+		assert.Equal(t, thumbprint, jwk["kid"])
+	}
+
+	pub, err := ParsePublicKey(rsaKey)
+	require.NoError(t, err)
+	assert.IsType(t, &rsa.PublicKey{}, pub)
+	pub, err = ParsePublicKey(ecdsaKey)
+	require.NoError(t, err)
+	assert.IsType(t, &ecdsa.PublicKey{}, pub)
+
+	// the DER encoding of RSA keys is PKIX, but PKCS#1 is accepted as well
+	block, _ := pem.Decode(rsaKey)
+	pub, err = ParsePublicKey(block.Bytes)
+	require.NoError(t, err)
+	assert.IsType(t, &rsa.PublicKey{}, pub)
+	derKey, err := EncodePublicKey(rsaKey, DER)
+	require.NoError(t, err)
+	assert.NotEqual(t, block.Bytes, derKey)
+
+	_, err = EncodePublicKey(rsaKey, KeyFormat(42))
+	assert.Error(t, err)
+}
+
+func TestJWK(t *testing.T) {
+	// RFC 7638, Section 3.1
+	rfcKey := []byte(`{"kty":"RSA","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhD` +
+		`R1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2Q` +
+		`vzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2` +
+		`NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw","e":"AQAB","alg":"RS256"}`)
+	thumbprint, err := JWKThumbprint(rfcKey)
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+
+	// RFC 7517, Appendix A.1
+	ecKey := []byte(`{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",` +
+		`"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM","use":"enc","kid":"1"}`)
+	pub, err := ParsePublicKey(ecKey)
+	require.NoError(t, err)
+	assert.IsType(t, &ecdsa.PublicKey{}, pub)
+
+	for _, invalid := range []string{
+		`{`,
+		`{"kty":"oct","k":"AQAB"}`,
+		`{"kty":"EC","crv":"P-384","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM"}`,
+		`{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyQ"}`,
+		`{"kty":"RSA","n":"AQAB"}`,
+		`{"kty":"RSA","n":"AQAB","e":"AQ"}`,
+	} {
+		_, err := ParsePublicKey([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestInvalidPublicKey(t *testing.T) {
+	for _, invalid := range [][]byte{
+		nil,
+		[]byte("  "),
+		[]byte("invalid key"),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("invalid key")}),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: []byte("invalid key")}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("invalid key")}),
+	} {
+		_, err := Fingerprint(invalid)
+		assert.Error(t, err)
+		_, err = EncodePublicKey(invalid, PEM)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+)
+
+// PaddingPolicy determines the padded size of requests and responses (see PaddedSize)
+type PaddingPolicy = protos.PaddingPolicy
+
+// PowerOfTwoPadding returns a padding policy which pads a message to the next power of two larger than the message
+func PowerOfTwoPadding() *PaddingPolicy {
+	return &PaddingPolicy{}
+}
+
+// BucketPadding returns a padding policy which pads a message to the smallest of the given sizes larger than the
+// message, or to a multiple of the largest size for larger messages
+func BucketPadding(sizes ...uint32) *PaddingPolicy {
+	return &PaddingPolicy{BucketSizes: sizes}
+}
+
+// PaddedSize returns the size of a message of the given size after padding it according to the policy.
+// The padded size is always larger than size, as the padding takes at least one byte.
+// The enclave computes the same size (see pad_message in ecc_enclave/enclave/crypto.h).
+func PaddedSize(size int, policy *PaddingPolicy) int {
+	minSize := uint64(size) + 1
+
+	var best, largest uint64
+	for _, b := range policy.GetBucketSizes() {
+		bucket := uint64(b)
+		if bucket >= minSize && (best == 0 || bucket < best) {
+			best = bucket
+		}
+		if bucket > largest {
+			largest = bucket
+		}
+	}
+
+	switch {
+	case best != 0:
+		return int(best)
+	case largest != 0:
+		return int((minSize + largest - 1) / largest * largest)
+	default:
+		padded := uint64(1)
+		for padded < minSize {
+			padded <<= 1
+		}
+		return int(padded)
+	}
+}
+
+// PadMessage pads message according to the policy with 0x80 followed by zero bytes (ISO/IEC 7816-4)
+func PadMessage(message []byte, policy *PaddingPolicy) []byte {
+	padded := make([]byte, PaddedSize(len(message), policy))
+	copy(padded, message)
+	padded[len(message)] = 0x80
+	return padded
+}
+
+// UnpadMessage removes the padding added by PadMessage
+func UnpadMessage(padded []byte) ([]byte, error) {
+	for i := len(padded) - 1; i >= 0; i-- {
+		switch padded[i] {
+		case 0x00:
+			continue
+		case 0x80:
+			return padded[:i], nil
+		default:
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+	return nil, fmt.Errorf("invalid padding")
+}
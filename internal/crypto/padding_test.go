@@ -0,0 +1,55 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package crypto
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaddedSize(t *testing.T) {
+	powerOfTwo := PowerOfTwoPadding()
+	assert.Equal(t, 1, PaddedSize(0, powerOfTwo))
+	assert.Equal(t, 8, PaddedSize(7, powerOfTwo))
+	assert.Equal(t, 16, PaddedSize(8, powerOfTwo))
+	assert.Equal(t, 1024, PaddedSize(1000, powerOfTwo))
+
+	// the order of the bucket sizes does not matter
+	buckets := BucketPadding(64, 16, 256)
+	assert.Equal(t, 16, PaddedSize(0, buckets))
+	assert.Equal(t, 16, PaddedSize(15, buckets))
+	assert.Equal(t, 64, PaddedSize(16, buckets))
+	assert.Equal(t, 256, PaddedSize(255, buckets))
+	assert.Equal(t, 512, PaddedSize(256, buckets))
+	assert.Equal(t, 512, PaddedSize(300, buckets))
+
+	// empty buckets
+	assert.Equal(t, 8, PaddedSize(7, BucketPadding(0)))
+}
+
+func TestPadding(t *testing.T) {
+	for _, msg := range [][]byte{{}, []byte("a"), []byte("some message"), {0x80, 0x00}, make([]byte, 100)} {
+		for _, policy := range []*protos.PaddingPolicy{PowerOfTwoPadding(), BucketPadding(16, 64)} {
+			padded := PadMessage(msg, policy)
+			assert.Len(t, padded, PaddedSize(len(msg), policy))
+
+			unpadded, err := UnpadMessage(padded)
+			assert.NoError(t, err)
+			assert.Equal(t, msg, unpadded)
+		}
+	}
+
+	// messages of similar size cannot be distinguished
+	assert.Equal(t, len(PadMessage([]byte("bid 10"), BucketPadding(64))), len(PadMessage([]byte("bid 1000000"), BucketPadding(64))))
+
+	for _, invalid := range [][]byte{nil, {}, {0x00}, []byte("a"), {0x80, 0x01}, {0x81, 0x00}} {
+		_, err := UnpadMessage(invalid)
+		assert.Error(t, err)
+	}
+}
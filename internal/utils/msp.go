@@ -7,14 +7,20 @@ SPDX-License-Identifier: Apache-2.0
 package utils
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/msp"
 )
 
+// AdminOU is the organizational unit of admin identities of MSPs with NodeOUs enabled
+const AdminOU = "admin"
+
 type IdentityEvaluatorInterface interface {
 	EvaluateCreatorIdentity(creatorIdentityBytes []byte, ownerMSP string) error
+	EvaluateAdminIdentity(creatorIdentityBytes []byte) error
 }
 
 type IdentityEvaluator struct {
@@ -35,6 +41,34 @@ func (id *IdentityEvaluator) EvaluateCreatorIdentity(creatorIdentityBytes []byte
 	return nil
 }
 
+// EvaluateAdminIdentity checks that an identity is an admin of its organization, i.e., its certificate contains the
+// AdminOU organizational unit as issued for admins by MSPs with NodeOUs enabled.
+// This function requires a marshalled msp.SerializedIdentity as input.
+func (id *IdentityEvaluator) EvaluateAdminIdentity(creatorIdentityBytes []byte) error {
+	sID := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(creatorIdentityBytes, sID); err != nil {
+		return fmt.Errorf("error while deserialzing creator identity, err: %s", err)
+	}
+
+	block, _ := pem.Decode(sID.IdBytes)
+	if block == nil {
+		return fmt.Errorf("creator identity does not contain a PEM encoded certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("error while parsing creator certificate, err: %s", err)
+	}
+
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == AdminOU {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("creator is not an admin of %s", sID.Mspid)
+}
+
 func ExtractMSPID(serializedIdentityRaw []byte) (string, error) {
 	sID := &msp.SerializedIdentity{}
 	err := proto.Unmarshal(serializedIdentityRaw, sID)
@@ -0,0 +1,79 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package utils_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func serializedIdentityWithOUs(mspId string, ous ...string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "someone", OrganizationalUnit: ous},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	serializedId, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspId,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}),
+	})
+	Expect(err).ShouldNot(HaveOccurred())
+	return serializedId
+}
+
+var _ = Describe("Identity evaluator", func() {
+
+	ie := &utils.IdentityEvaluator{}
+
+	Context("EvaluateAdminIdentity", func() {
+
+		When("identity is an admin", func() {
+			It("should succeed", func() {
+				err := ie.EvaluateAdminIdentity(serializedIdentityWithOUs("SampleOrg", "org1", utils.AdminOU))
+				Expect(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		When("identity is not an admin", func() {
+			It("should return error", func() {
+				err := ie.EvaluateAdminIdentity(serializedIdentityWithOUs("SampleOrg", "client"))
+				Expect(err).Should(MatchError("creator is not an admin of SampleOrg"))
+			})
+		})
+
+		When("identity has no certificate", func() {
+			It("should return error", func() {
+				serializedId, err := proto.Marshal(&msp.SerializedIdentity{Mspid: "SampleOrg", IdBytes: []byte("no pem")})
+				Expect(err).ShouldNot(HaveOccurred())
+				err = ie.EvaluateAdminIdentity(serializedId)
+				Expect(err).Should(HaveOccurred())
+
+				err = ie.EvaluateAdminIdentity([]byte("invalid"))
+				Expect(err).Should(HaveOccurred())
+			})
+		})
+	})
+})
@@ -18,6 +18,11 @@ import (
 
 const MrEnclaveStateKey = "MRENCLAVE"
 
+// ChaincodeErrorStatus is the status of the `__invoke` response if the chaincode, rather than the enclave, failed,
+// e.g., as a request of a batch failed. Clients do not fail over to other enclaves on such errors, as the chaincode
+// would fail there as well.
+const ChaincodeErrorStatus int32 = 400
+
 // Response contains the response data and signature produced by the enclave
 // TODO remove once ecc uses new ChaincodeResponseMessage
 type Response struct {
@@ -72,7 +72,6 @@ func (a *Admin) InitEnclave(targetPeer string) error {
 
 	logger.Infof("--> LifecycleInitEnclave ")
 	_, err = a.client.LifecycleInitEnclave(a.config.ChannelId, initReq,
-		// Note that these options are currently ignored by our implementation
 		resmgmt.WithRetry(retry.DefaultResMgmtOpts),
 		resmgmt.WithTargetEndpoints(peers...), // peers that are responsible for enclave registration
 		resmgmt.WithOrdererEndpoint(orderer),
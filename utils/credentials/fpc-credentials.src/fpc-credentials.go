@@ -0,0 +1,442 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/pkg/errors"
+)
+
+const usage = `Usage: %s [options] [<credentials-file>]
+Decodes and explains (base64-encoded) FPC Credentials, as returned by __initEnclave or stored in ERCC.
+The credentials are read from <credentials-file> or, if not given or '-', from stdin.
+If -mrenclave is given, the evidence is verified as ERCC does on enclave registration.
+
+Options:
+`
+
+// verification options
+type options struct {
+	mrenclave      string
+	iasRootCAPath  string
+	dcapRootCAPath string
+	policyPath     string
+	allowSimulated bool
+}
+
+func main() {
+	if err := run(os.Args, os.Stdin, os.Stdout); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		}
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	opts := &options{}
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.StringVar(&opts.mrenclave, "mrenclave", "", "verify the evidence against the given (hex-encoded) mrenclave")
+	flags.StringVar(&opts.iasRootCAPath, "ias-root-ca", os.Getenv("IAS_ROOT_CA_PATH"),
+		"path to the IAS root CA certificate, needed to verify EPID evidence (default $IAS_ROOT_CA_PATH)")
+	flags.StringVar(&opts.dcapRootCAPath, "dcap-root-ca", "",
+		"path to the root CA certificate to verify DCAP evidence (default Intel SGX Root CA)")
+	flags.StringVar(&opts.policyPath, "policy", "",
+		"path to a (json-encoded) verification policy (default policy of ERCC)")
+	flags.BoolVar(&opts.allowSimulated, "allow-simulated", false,
+		"accept simulated evidence, which does not provide any security guarantees")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), usage, args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("expected at most one credentials file")
+	}
+
+	var input []byte
+	var err error
+	if path := flags.Arg(0); len(path) == 0 || path == "-" {
+		input, err = ioutil.ReadAll(stdin)
+	} else {
+		input, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return errors.Wrap(err, "cannot read credentials")
+	}
+
+	credentials, err := utils.UnmarshalCredentials(strings.TrimSpace(string(input)))
+	if err != nil {
+		return errors.Wrap(err, "cannot decode credentials")
+	}
+	attestedData := &protos.AttestedData{}
+	if err := ptypes.UnmarshalAny(credentials.SerializedAttestedData, attestedData); err != nil {
+		return errors.Wrap(err, "cannot decode attested data")
+	}
+
+	p := &printer{w: stdout}
+	describeAttestedData(p, attestedData)
+	describeAttestation(p, credentials.Attestation)
+	describeEvidence(p, credentials.Evidence, credentials.SerializedAttestedData.Value)
+
+	if len(opts.mrenclave) == 0 {
+		return nil
+	}
+
+	p.section("Verification")
+	p.field("expected mrenclave", opts.mrenclave)
+	if err := verify(opts, credentials, attestedData); err != nil {
+		p.field("result", "FAILED")
+		return errors.Wrap(err, "verification failed")
+	}
+	p.field("result", "OK")
+
+	return nil
+}
+
+// printer writes indented "name: value" lines with aligned values
+type printer struct {
+	w      io.Writer
+	indent int
+}
+
+func (p *printer) section(title string) {
+	p.indent = 0
+	p.field(title, "")
+	p.indent = 1
+}
+
+func (p *printer) subsection(title string) {
+	p.indent = 1
+	p.field(title, "")
+	p.indent = 2
+}
+
+func (p *printer) field(name string, value interface{}) {
+	prefix := strings.Repeat("  ", p.indent)
+	if s, ok := value.(string); ok && len(s) == 0 {
+		fmt.Fprintf(p.w, "%s%s:\n", prefix, name)
+		return
+	}
+	fmt.Fprintf(p.w, "%s%-*s %v\n", prefix, 28-len(prefix), name+":", value)
+}
+
+func describeAttestedData(p *printer, attestedData *protos.AttestedData) {
+	p.section("Attested data")
+
+	p.subsection("cc params")
+	if ccParams := attestedData.CcParams; ccParams != nil {
+		p.field("chaincode id", ccParams.ChaincodeId)
+		p.field("version (mrenclave)", ccParams.Version)
+		p.field("sequence", ccParams.Sequence)
+		p.field("channel id", ccParams.ChannelId)
+	} else {
+		p.field("error", "missing")
+	}
+
+	p.subsection("host params")
+	if hostParams := attestedData.HostParams; hostParams != nil {
+		p.field("peer msp id", hostParams.PeerMspId)
+		p.field("peer endpoint", hostParams.PeerEndpoint)
+		p.field("certificate", fmt.Sprintf("%d bytes", len(hostParams.Certificate)))
+	} else {
+		p.field("error", "missing")
+	}
+
+	p.subsection("enclave_vk")
+	describeKey(p, attestedData.EnclaveVk)
+	p.indent = 1
+	p.field("enclave id", utils.GetEnclaveId(attestedData))
+
+	p.subsection("chaincode_ek")
+	describeKey(p, attestedData.ChaincodeEk)
+
+	p.indent = 1
+	p.field("channel hash", hex.EncodeToString(attestedData.ChannelHash))
+	p.field("tlcc mrenclave", attestedData.TlccMrenclave)
+}
+
+// describeKey prints the type and the fingerprint (see crypto.Fingerprint) of a PEM encoded public key as created by
+// the crypto package
+func describeKey(p *printer, keyBytes []byte) {
+	if len(keyBytes) == 0 {
+		p.field("error", "missing")
+		return
+	}
+
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		p.field("error", fmt.Sprintf("not PEM encoded (%d bytes)", len(keyBytes)))
+		return
+	}
+
+	key, err := crypto.ParsePublicKey(keyBytes)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		p.field("type", fmt.Sprintf("ECDSA %s", k.Curve.Params().Name))
+	case *rsa.PublicKey:
+		p.field("type", fmt.Sprintf("RSA %d", k.N.BitLen()))
+	default:
+		p.field("type", fmt.Sprintf("%s (%v)", block.Type, err))
+		return
+	}
+
+	fingerprint, err := crypto.Fingerprint(keyBytes)
+	if err != nil {
+		p.field("error", err.Error())
+		return
+	}
+	p.field("fingerprint", fingerprint)
+}
+
+func describeAttestation(p *printer, attestationBytes []byte) {
+	p.section("Attestation")
+	if len(attestationBytes) == 0 {
+		p.field("error", "missing")
+		return
+	}
+
+	att := &struct {
+		Type string `json:"attestation_type"`
+		Data string `json:"attestation"`
+	}{}
+	if err := json.Unmarshal(attestationBytes, att); err != nil {
+		p.field("error", fmt.Sprintf("cannot decode attestation json: %v", err))
+		return
+	}
+	p.field("attestation type", att.Type)
+	if att.Type == attestation.SimulatedType {
+		return
+	}
+
+	quoteBytes, err := base64.StdEncoding.DecodeString(att.Data)
+	if err != nil {
+		p.field("error", fmt.Sprintf("cannot decode attestation: %v", err))
+		return
+	}
+	p.field("quote", fmt.Sprintf("%d bytes", len(quoteBytes)))
+}
+
+func describeEvidence(p *printer, evidenceBytes []byte, statement []byte) {
+	p.section("Evidence")
+	if len(evidenceBytes) == 0 {
+		p.field("error", "missing; the attestation has not been converted yet (see peer-cli-assist attestation2Evidence)")
+		return
+	}
+
+	evidenceType, evidenceData, err := unmarshalEvidence(evidenceBytes)
+	if err != nil {
+		p.field("error", err)
+		return
+	}
+	p.field("attestation type", evidenceType)
+
+	var reportBody *attestation.SgxReportBody
+	switch evidenceType {
+	case attestation.SimulatedType:
+		p.field("note", "simulated attestation does not provide any security guarantees")
+		return
+	case attestation.EpidLinkableType, attestation.EpidUnlinkableType:
+		report, err := attestation.ParseEpidReport([]byte(evidenceData))
+		if err != nil {
+			p.field("error", err)
+			return
+		}
+		describeIASReport(p, report.Response)
+		reportBody = report.ReportBody
+	case attestation.DcapType:
+		reportBody = describeDcapEvidence(p, evidenceData)
+	default:
+		p.field("error", "unknown attestation type")
+	}
+	if reportBody == nil {
+		return
+	}
+
+	p.subsection("enclave report")
+	p.field("mrenclave", hex.EncodeToString(reportBody.MrEnclave))
+	p.field("mrsigner", hex.EncodeToString(reportBody.MrSigner))
+	p.field("isv prod id", reportBody.IsvProdId)
+	p.field("isv svn", reportBody.IsvSvn)
+	p.field("debug", reportBody.Debug())
+	p.field("report data", hex.EncodeToString(reportBody.ReportData))
+	expectedReportData := make([]byte, len(reportBody.ReportData))
+	statementHash := sha256.Sum256(statement)
+	copy(expectedReportData, statementHash[:])
+	p.field("binds attested data", bytes.Equal(reportBody.ReportData, expectedReportData))
+}
+
+func describeIASReport(p *printer, response *attestation.IASResponseBody) {
+	p.subsection("ias report")
+	p.field("id", response.Id)
+	p.field("timestamp", response.Timestamp)
+	p.field("version", response.Version)
+	p.field("quote status", response.IsvEnclaveQuoteStatus)
+	if len(response.RevocationReason) != 0 {
+		p.field("revocation reason", response.RevocationReason)
+	}
+	if len(response.AdvisoryIDs) != 0 {
+		p.field("advisory ids", strings.Join(response.AdvisoryIDs, ", "))
+		p.field("advisory url", response.AdvisoryURL)
+	}
+	if len(response.Nonce) != 0 {
+		p.field("nonce", response.Nonce)
+	}
+	if len(response.EpidPseudonym) != 0 {
+		p.field("epid pseudonym", response.EpidPseudonym)
+	}
+	if len(response.PlatformInfoBlob) != 0 {
+		p.field("platform info blob", response.PlatformInfoBlob)
+	}
+}
+
+func describeDcapEvidence(p *printer, evidenceData string) *attestation.SgxReportBody {
+	p.subsection("dcap quote")
+	evidence := &attestation.DcapEvidence{}
+	if err := json.Unmarshal([]byte(evidenceData), evidence); err != nil {
+		p.field("error", fmt.Sprintf("bad dcap evidence json: %v", err))
+		return nil
+	}
+
+	quoteBytes, err := base64.StdEncoding.DecodeString(evidence.Quote)
+	if err != nil {
+		p.field("error", fmt.Sprintf("cannot decode quote: %v", err))
+		return nil
+	}
+	quote, err := attestation.ParseDcapQuote(quoteBytes)
+	if err != nil {
+		p.field("error", err)
+		return nil
+	}
+	p.field("version", quote.Version)
+	p.field("qe svn", quote.QeSvn)
+	p.field("pce svn", quote.PceSvn)
+
+	if evidence.Collateral != nil && len(evidence.Collateral.TcbInfo) != 0 {
+		tcbInfo := &struct {
+			TcbInfo struct {
+				Fmspc      string `json:"fmspc"`
+				IssueDate  string `json:"issueDate"`
+				NextUpdate string `json:"nextUpdate"`
+			} `json:"tcbInfo"`
+		}{}
+		if err := json.Unmarshal([]byte(evidence.Collateral.TcbInfo), tcbInfo); err != nil {
+			p.field("tcb info", fmt.Sprintf("bad json: %v", err))
+		} else {
+			p.field("fmspc", tcbInfo.TcbInfo.Fmspc)
+			p.field("tcb info issue date", tcbInfo.TcbInfo.IssueDate)
+			p.field("tcb info next update", tcbInfo.TcbInfo.NextUpdate)
+		}
+	} else {
+		p.field("collateral", "missing")
+	}
+
+	return quote.ReportBody
+}
+
+func unmarshalEvidence(evidenceBytes []byte) (string, string, error) {
+	evidence := &struct {
+		Type *string `json:"attestation_type"`
+		Data *string `json:"evidence"`
+	}{}
+	if err := json.Unmarshal(evidenceBytes, evidence); err != nil {
+		return "", "", errors.Wrap(err, "cannot decode evidence json")
+	}
+	if evidence.Type == nil {
+		return "", "", errors.New("no attestation type")
+	}
+	if evidence.Data == nil {
+		return "", "", errors.New("no evidence field")
+	}
+	return *evidence.Type, *evidence.Data, nil
+}
+
+// verify performs the checks of ERCC on enclave registration which do not depend on the ledger, i.e., that the
+// chaincode version is the expected mrenclave and that the evidence is valid, complies with the verification policy,
+// and binds the attested data to an enclave with the expected mrenclave.
+func verify(opts *options, credentials *protos.Credentials, attestedData *protos.AttestedData) error {
+	if attestedData.CcParams == nil || attestedData.CcParams.Version != opts.mrenclave {
+		return errors.New("mrenclave does not match the chaincode version of the cc params")
+	}
+
+	policy := attestation.DefaultVerificationPolicy()
+	if len(opts.policyPath) != 0 {
+		policyBytes, err := ioutil.ReadFile(opts.policyPath)
+		if err != nil {
+			return errors.Wrap(err, "cannot read verification policy")
+		}
+		if policy, err = attestation.ParseVerificationPolicy(policyBytes); err != nil {
+			return err
+		}
+	}
+
+	if len(credentials.Evidence) == 0 {
+		return errors.New("no evidence")
+	}
+	evidenceType, evidenceData, err := unmarshalEvidence(credentials.Evidence)
+	if err != nil {
+		return err
+	}
+
+	statement := credentials.SerializedAttestedData.Value
+	switch evidenceType {
+	case attestation.SimulatedType:
+		if !opts.allowSimulated {
+			return errors.New("simulated attestation is not accepted (see -allow-simulated)")
+		}
+		return nil
+	case attestation.EpidLinkableType, attestation.EpidUnlinkableType:
+		if len(opts.iasRootCAPath) == 0 {
+			return errors.New("no IAS root certificate configured (see -ias-root-ca)")
+		}
+		roots, err := attestation.LoadIASRootCertificates(opts.iasRootCAPath)
+		if err != nil {
+			return err
+		}
+		return attestation.NewIASVerifier(roots).VerifyEvidence([]byte(evidenceData), statement, opts.mrenclave, policy)
+	case attestation.DcapType:
+		var verifierOpts []attestation.DcapVerifierOption
+		if len(opts.dcapRootCAPath) != 0 {
+			rootPem, err := ioutil.ReadFile(opts.dcapRootCAPath)
+			if err != nil {
+				return errors.Wrap(err, "cannot read DCAP root certificate")
+			}
+			roots := x509.NewCertPool()
+			if !roots.AppendCertsFromPEM(rootPem) {
+				return errors.New("no DCAP root certificate found")
+			}
+			verifierOpts = append(verifierOpts, attestation.WithRootCertificates(roots))
+		}
+		return attestation.NewDcapVerifier(verifierOpts...).VerifyEvidence([]byte(evidenceData), statement, opts.mrenclave, policy)
+	default:
+		return fmt.Errorf("bad attestation type '%s'", evidenceType)
+	}
+}
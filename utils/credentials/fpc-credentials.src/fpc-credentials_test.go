@@ -0,0 +1,194 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation"
+	"github.com/hyperledger/fabric-private-chaincode/internal/attestation/iastest"
+	"github.com/hyperledger/fabric-private-chaincode/internal/crypto"
+	"github.com/hyperledger/fabric-private-chaincode/internal/protos"
+	"github.com/hyperledger/fabric-private-chaincode/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMrEnclave = strings.Repeat("ab", 32)
+
+func newTestCredentials(t *testing.T) *protos.Credentials {
+	csp := crypto.GetDefaultCSP()
+	enclaveVk, _, err := csp.NewECDSAKeys()
+	require.NoError(t, err)
+	chaincodeEk, _, err := csp.NewRSAKeys()
+	require.NoError(t, err)
+
+	attestedData := &protos.AttestedData{
+		CcParams: &protos.CCParameters{
+			ChaincodeId: "some-chaincode",
+			Version:     testMrEnclave,
+			Sequence:    1,
+			ChannelId:   "mychannel",
+		},
+		HostParams: &protos.HostParameters{
+			PeerMspId:    "Org1MSP",
+			PeerEndpoint: "peer0.org1.example.com:7051",
+		},
+		EnclaveVk:   enclaveVk,
+		ChaincodeEk: chaincodeEk,
+	}
+	attestedDataBytes, err := proto.Marshal(attestedData)
+	require.NoError(t, err)
+
+	return &protos.Credentials{
+		SerializedAttestedData: &any.Any{
+			TypeUrl: proto.MessageName(attestedData),
+			Value:   attestedDataBytes,
+		},
+		Attestation: []byte(`{"attestation_type":"simulated","attestation":"MA=="}`),
+	}
+}
+
+// testEpidQuote returns an EPID quote of a release enclave with the test mrenclave binding the statement
+func testEpidQuote(statement []byte) string {
+	// quote header (48 bytes), report body (384 bytes), signature length and a dummy signature
+	quote := make([]byte, 432+4+64)
+	reportBody := quote[48:]
+	reportBody[48] = 0x05 // attributes: initialized, 64 bit mode
+	mrenclave, _ := hex.DecodeString(testMrEnclave)
+	copy(reportBody[64:], mrenclave)
+	statementHash := sha256.Sum256(statement)
+	copy(reportBody[320:], statementHash[:])
+	quote[432] = 64
+	return base64.StdEncoding.EncodeToString(quote)
+}
+
+func runWith(t *testing.T, credentials *protos.Credentials, args ...string) (string, error) {
+	stdin := strings.NewReader(utils.MarshallProto(credentials) + "\n")
+	stdout := &bytes.Buffer{}
+	err := run(append([]string{"fpc-credentials"}, args...), stdin, stdout)
+	return stdout.String(), err
+}
+
+func TestDescribe(t *testing.T) {
+	credentials := newTestCredentials(t)
+	attestedData := &protos.AttestedData{}
+	require.NoError(t, proto.Unmarshal(credentials.SerializedAttestedData.Value, attestedData))
+
+	out, err := runWith(t, credentials)
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		"some-chaincode", testMrEnclave, "mychannel", "Org1MSP", "peer0.org1.example.com:7051",
+		"ECDSA P-256", "RSA 3072", utils.GetEnclaveId(attestedData),
+		"attestation type:          simulated",
+		"missing; the attestation has not been converted yet",
+	} {
+		assert.Contains(t, out, expected)
+	}
+	assert.NotContains(t, out, "Verification")
+
+	// read from file
+	path, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+	credentialsFile := filepath.Join(path, "credentials.txt")
+	require.NoError(t, ioutil.WriteFile(credentialsFile, []byte(utils.MarshallProto(credentials)), 0600))
+	stdout := &bytes.Buffer{}
+	assert.NoError(t, run([]string{"fpc-credentials", credentialsFile}, strings.NewReader(""), stdout))
+	assert.Equal(t, out, stdout.String())
+
+	// no evidence to verify
+	_, err = runWith(t, credentials, "-mrenclave", testMrEnclave)
+	assert.EqualError(t, err, "verification failed: no evidence")
+
+	// bad input
+	err = run([]string{"fpc-credentials"}, strings.NewReader("not base64"), &bytes.Buffer{})
+	assert.Contains(t, err.Error(), "cannot decode credentials")
+}
+
+func TestVerifySimulated(t *testing.T) {
+	credentials := newTestCredentials(t)
+	credentials.Evidence = []byte(`{"attestation_type":"simulated","evidence":"MA=="}`)
+
+	out, err := runWith(t, credentials)
+	assert.NoError(t, err)
+	assert.Contains(t, out, "simulated attestation does not provide any security guarantees")
+
+	out, err = runWith(t, credentials, "-mrenclave", testMrEnclave)
+	assert.EqualError(t, err, "verification failed: simulated attestation is not accepted (see -allow-simulated)")
+	assert.Contains(t, out, "FAILED")
+
+	out, err = runWith(t, credentials, "-mrenclave", testMrEnclave, "-allow-simulated")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "OK")
+
+	// the chaincode version must match the mrenclave
+	_, err = runWith(t, credentials, "-mrenclave", strings.Repeat("cd", 32), "-allow-simulated")
+	assert.EqualError(t, err, "verification failed: mrenclave does not match the chaincode version of the cc params")
+}
+
+func TestVerifyEpid(t *testing.T) {
+	server, err := iastest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	path, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+	rootCAPath := filepath.Join(path, "root_ca.pem")
+	require.NoError(t, ioutil.WriteFile(rootCAPath, server.RootCertificatePem(), 0600))
+
+	credentials := newTestCredentials(t)
+	ias := attestation.NewIASClient("some_key", attestation.WithUrl(server.ReportURL()))
+	converter := attestation.NewEpidLinkableConverter(attestation.WithIntelAttestationService(ias))
+	report, err := converter.Converter([]byte(testEpidQuote(credentials.SerializedAttestedData.Value)))
+	require.NoError(t, err)
+	credentials.Evidence, err = json.Marshal(map[string]string{
+		"attestation_type": attestation.EpidLinkableType,
+		"evidence":         string(report),
+	})
+	require.NoError(t, err)
+
+	out, err := runWith(t, credentials, "-mrenclave", testMrEnclave, "-ias-root-ca", rootCAPath)
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		"quote status:            OK",
+		"mrenclave:               " + testMrEnclave,
+		"debug:                   false",
+		"binds attested data:     true",
+		"result:                    OK",
+	} {
+		assert.Contains(t, out, expected)
+	}
+
+	_, err = runWith(t, credentials, "-mrenclave", testMrEnclave)
+	assert.EqualError(t, err, "verification failed: no IAS root certificate configured (see -ias-root-ca)")
+
+	// the verification policy is applied
+	policyPath := filepath.Join(path, "policy.json")
+	require.NoError(t, ioutil.WriteFile(policyPath, []byte(`{"allowed_quote_statuses": ["GROUP_OUT_OF_DATE"]}`), 0600))
+	_, err = runWith(t, credentials, "-mrenclave", testMrEnclave, "-ias-root-ca", rootCAPath, "-policy", policyPath)
+	assert.EqualError(t, err, "verification failed: invalid quote status 'OK'")
+
+	// the evidence must bind the attested data
+	other := newTestCredentials(t)
+	other.Evidence = credentials.Evidence
+	out, err = runWith(t, other, "-mrenclave", testMrEnclave, "-ias-root-ca", rootCAPath)
+	assert.EqualError(t, err, "verification failed: expected statement mismatch")
+	assert.Contains(t, out, "binds attested data:     false")
+}